@@ -0,0 +1,163 @@
+package chain
+
+import (
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 区块模板中除奖励交易之外的交易大小之和的上限
+const maxBlockTemplateSize = 100
+
+// 交易池中交易之间的依赖关系，以 hex(txID) 索引
+type MempoolGraph struct {
+    Entries  map[string]MempoolEntry
+    Order    []string            // 交易池中的顺序
+    parents  map[string][]string // 在交易池中的父交易
+    children map[string][]string // 在交易池中的子交易
+}
+
+func NewMempoolGraph(entries []MempoolEntry) *MempoolGraph {
+    graph := &MempoolGraph{
+        make(map[string]MempoolEntry), nil, make(map[string][]string), make(map[string][]string),
+    }
+    for _, entry := range entries {
+        id := hex.EncodeToString(entry.Tx.ID)
+        graph.Entries[id] = entry
+        graph.Order = append(graph.Order, id)
+    }
+
+    for _, id := range graph.Order {
+        seen := make(map[string]bool)
+        for _, vin := range graph.Entries[id].Tx.Vin {
+            parentID := hex.EncodeToString(vin.Txid)
+            if _, ok := graph.Entries[parentID]; !ok || seen[parentID] { continue }
+
+            seen[parentID] = true
+            graph.parents[id] = append(graph.parents[id], parentID)
+            graph.children[parentID] = append(graph.children[parentID], id)
+        }
+    }
+
+    return graph
+}
+
+// id 的祖先交易，父交易在前，不包括 exclude 中的交易
+func (graph *MempoolGraph) Ancestors(id string, exclude map[string]bool) []string {
+    var result []string
+    visited := make(map[string]bool)
+
+    var visit func(string)
+    visit = func(txID string) {
+        for _, parentID := range graph.parents[txID] {
+            if visited[parentID] || exclude[parentID] { continue }
+            visited[parentID] = true
+
+            visit(parentID)
+            result = append(result, parentID)
+        }
+    }
+    visit(id)
+
+    return result
+}
+
+// id 的后代交易
+func (graph *MempoolGraph) Descendants(id string) []string {
+    var result []string
+    visited := make(map[string]bool)
+
+    queue := []string{id}
+    for len(queue) > 0 {
+        txID := queue[0]
+        queue = queue[1:]
+
+        for _, childID := range graph.children[txID] {
+            if visited[childID] { continue }
+            visited[childID] = true
+
+            result = append(result, childID)
+            queue = append(queue, childID)
+        }
+    }
+
+    return result
+}
+
+// 交易组的手续费和大小之和
+func (graph *MempoolGraph) PackageFee(ids []string) (int, int) {
+    fee, size := 0, 0
+    for _, id := range ids {
+        entry := graph.Entries[id]
+        fee += entry.Fee
+        size += entry.Tx.Size()
+    }
+
+    return fee, size
+}
+
+/*
+选出写入高度为 height 的区块的交易，父交易在前
+    每笔交易连同其尚未被选中的祖先交易作为一组，按组的手续费率从高到低选入，直到区块模板已满
+    因此手续费率低的父交易可以由手续费率高的子交易带入区块 (child pays for parent)
+    时间锁尚未到期的交易及其后代交易不会被选中
+*/
+func (m Mempool) BlockTemplate(height, medianTime int64) ([]*types.Transaction, error) {
+    bc := m.Blockchain
+    entries, err := m.Entries()
+    if err != nil { return nil, err }
+    graph := NewMempoolGraph(entries)
+
+    var selected []*types.Transaction
+    selectedIDs := make(map[string]bool)
+    failed := make(map[string]bool)
+    size := 0
+    view := NewTxView(bc)
+
+    for {
+        var best []string
+        bestFee, bestSize := 0, 1
+
+        for _, id := range graph.Order {
+            if selectedIDs[id] || failed[id] { continue }
+
+            pkg := append(graph.Ancestors(id, selectedIDs), id)
+            fee, pkgSize := graph.PackageFee(pkg)
+            if size + pkgSize > maxBlockTemplateSize { continue }
+
+            // 手续费率 fee / pkgSize 更高
+            if best == nil || fee * bestSize > bestFee * pkgSize {
+                best, bestFee, bestSize = pkg, fee, pkgSize
+            }
+        }
+        if best == nil { break }
+
+        // 组内的交易均可以被写入区块时才选入，否则跳过失败的交易及其后代交易
+        trial := view.Copy()
+        ok := true
+        for _, id := range best {
+            tx := graph.Entries[id].Tx
+            _, err := bc.ValidateTransaction(&tx, trial, height, medianTime)
+            if err != nil {
+                failed[id] = true
+                for _, descendant := range graph.Descendants(id) {
+                    failed[descendant] = true
+                }
+                ok = false
+                break
+            }
+            trial.AddTransaction(&tx)
+        }
+        if !ok { continue }
+
+        view = trial
+        for _, id := range best {
+            tx := graph.Entries[id].Tx
+            selected = append(selected, &tx)
+            selectedIDs[id] = true
+        }
+        size += bestSize
+    }
+
+    return selected, nil
+}
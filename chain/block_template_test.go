@@ -0,0 +1,40 @@
+package chain
+
+import (
+    "encoding/hex"
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 手续费率低的父交易由手续费率高的子交易带入区块模板，并排在子交易之前
+func TestBlockTemplateChildPaysForParent(t *testing.T) {
+    bc, privKey, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    mempool := bc.Mempool()
+
+    // split 手续费率 1；parent 不付手续费，child 手续费 8，parent + child 的手续费率为 2
+    // other 手续费率 1.5，高于 parent 但低于 parent + child
+    split := newTestPayment(t, privKey, address, genesis.Transactions[0], 0, types.SequenceFinal, 10, 13)
+    parent := newTestPayment(t, privKey, address, split, 0, types.SequenceFinal, 10)
+    child := newTestPayment(t, privKey, address, parent, 0, types.SequenceFinal, 2)
+    other := newTestPayment(t, privKey, address, split, 1, types.SequenceFinal, 10)
+
+    if err := mempool.Add(split); err != nil { t.Fatal(err) }
+    if err := mempool.Add(parent); !errors.Is(err, ErrFeeTooLow) { t.Fatalf("got %v, want %v", err, ErrFeeTooLow) }
+    if err := mempool.AddPackage([]*types.Transaction{parent, child}); err != nil { t.Fatal(err) }
+    if err := mempool.Add(other); err != nil { t.Fatal(err) }
+
+    height, medianTime, err := bc.NextBlockLockContext()
+    if err != nil { t.Fatal(err) }
+    template, err := mempool.BlockTemplate(height, medianTime)
+    if err != nil { t.Fatal(err) }
+
+    want := []*types.Transaction{split, parent, child, other}
+    if len(template) != len(want) { t.Fatalf("%d transactions in template, want %d", len(template), len(want)) }
+    for i, tx := range template {
+        if hex.EncodeToString(tx.ID) != hex.EncodeToString(want[i].ID) { t.Errorf("template[%d] = %x, want %x", i, tx.ID, want[i].ID) }
+    }
+}
@@ -0,0 +1,57 @@
+package chain
+
+import (
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/consensus"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+/*
+完整校验一个区块能否连接在 parent 之后，parent 为 nil 时为创世区块
+    区块头：父区块 hash 和高度，时间戳大于父区块的 MedianTimePast，
+    父区块有 merkle root 时不能为空，工作量证明，见 CheckBlockHeader
+    交易：第一笔且只有第一笔为奖励交易，merkle root，见 CheckBlockTransactions
+    其余交易依次校验，可以花费同一区块内之前的交易的输出
+    奖励交易的输出不能超过 subsidy 与全部手续费之和
+    UTXORoot 需为写入区块之后的 utxo 承诺，父区块有 UTXORoot 时不能为空
+utxo 需为 parent 写入之后的状态
+*/
+func (bc *Blockchain) ValidateBlock(block *types.Block, parent *types.Block) error {
+    height, medianTime := int64(0), int64(0)
+    if parent != nil {
+        var err error
+        height = block.Number().Int64()
+        if medianTime, err = bc.MedianTimePast(parent); err != nil { return err }
+    }
+
+    if err := consensus.CheckBlockHeader(block, parent, medianTime); err != nil { return err }
+    if err := consensus.CheckBlockTransactions(block); err != nil { return err }
+
+    fees := 0
+    view := NewTxView(bc)
+    for _, tx := range block.Transactions[1:] {
+        fee, err := bc.ValidateTransaction(tx, view, height, medianTime)
+        if err != nil { return err }
+
+        fees += fee
+        view.AddTransaction(tx)
+    }
+
+    for _, out := range block.Transactions[0].Vout {
+        if out.IsUnspendable() || out.HTLC != nil { return consensus.ErrInvalidCoinbase }
+    }
+    reward, err := block.Transactions[0].OutputValue()
+    if err != nil || reward > types.Subsidy + fees { return consensus.ErrInvalidCoinbase }
+
+    // UTXORoot 为空的区块为写入 utxo 承诺之前产生的区块
+    if (block.UTXORoot() == common.Hash{}) {
+        if parent != nil && (parent.UTXORoot() != common.Hash{}) { return consensus.ErrMissingUTXORoot }
+        return nil
+    }
+    root, err := bc.UTXOSet().StateRootAfter(block.Transactions)
+    if err != nil { return err }
+    if block.UTXORoot() != root { return utxo.ErrInvalidUTXORoot }
+
+    return nil
+}
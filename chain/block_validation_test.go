@@ -0,0 +1,29 @@
+package chain
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/consensus"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 挖出时不包含 merkle root 的区块，交易可以在挖出之后被替换，不能连接
+func TestValidateBlockRejectsMissingTxHash(t *testing.T) {
+    bc, _, address := newTestBlockchain(t)
+    parent, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    medianTime, err := bc.MedianTimePast(parent)
+    if err != nil { t.Fatal(err) }
+
+    transactions := []*types.Transaction{types.NewRewardTx(address, "", 0)}
+    utxoRoot, err := bc.UTXOSet().StateRootAfter(transactions)
+    if err != nil { t.Fatal(err) }
+
+    block := consensus.NewBlock(address, parent, nil, utxoRoot, medianTime)
+    block.Transactions = transactions
+    if err := bc.ValidateBlock(block, parent); !errors.Is(err, consensus.ErrMissingTxHash) { t.Fatalf("got %v, want %v", err, consensus.ErrMissingTxHash) }
+
+    block = consensus.NewBlock(address, parent, transactions, utxoRoot, medianTime)
+    if err := bc.ValidateBlock(block, parent); err != nil { t.Fatal(err) }
+}
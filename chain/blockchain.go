@@ -0,0 +1,523 @@
+package chain
+
+import (
+    "os"
+    "fmt"
+    "bytes"
+    "sync"
+    "errors"
+    "crypto/ecdsa"
+    "encoding/hex"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/consensus"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+const dbFile = "data/chain.db"
+const blocksBucket = "blocks" // means database.
+const latestBlockName = "latest"
+const genesisCoinbaseData = "Do not go gentle into that good night"
+
+var ErrInvalidSignature = errors.New("ERROR: Invalid transaction: signature verification failed")
+var ErrTransactionNotFound = errors.New("ERROR: Transaction is not found")
+var ErrNegativeFee = errors.New("ERROR: Invalid transaction: outputs exceed inputs")
+var ErrChainNotFound = errors.New("ERROR: No existing blockchain found. Create one first.")
+var ErrChainExists = errors.New("ERROR: Blockchain already exists.")
+
+/*
+Blockchain 可以被多个 goroutine 同时使用
+    读取区块和 utxo 的操作可以并发，每次读取在一个 bolt 只读事务中完成
+    写入区块链的操作（写入区块、交易池、修剪）持有 writeMu 依次进行，
+    写入之前读到的数据不会被其他写入改变
+    tip 只在写入区块的事务提交之后更新
+*/
+type Blockchain struct {
+    tip []byte
+    db  *bolt.DB
+    // blocks []*Block
+
+    tipMu   sync.RWMutex // 保护 tip
+    writeMu sync.Mutex   // 写锁，写入操作依次进行
+
+    utxoRepaired bool // 打开时 utxo 与最新区块不一致，已由全部区块重建
+}
+
+/*
+创建一个新的 Blockchain 实例
+设置 Blockchain 实例的 tip 为数据库中存储的最后一个块的哈希
+utxo 与最新区块不一致时由全部区块重建，见 checkUTXOSet 和 UTXOSetRepaired
+*/
+func NewBlockchain() (*Blockchain, error) {
+    return openBlockchain(true)
+}
+
+// 打开区块链但不重建与最新区块不一致的 utxo，用于 verifychain 报告不一致
+func NewBlockchainWithoutRepair() (*Blockchain, error) {
+    return openBlockchain(false)
+}
+
+func openBlockchain(repair bool) (*Blockchain, error) {
+    if DBExists() == false { return nil, ErrChainNotFound }
+    var tip []byte
+    db, err := bolt.Open(dbFile, 0600, nil)
+    if err != nil { return nil, err }
+
+    err = db.Update(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(blocksBucket))
+        // bolt 返回的数据只在事务内有效，之后的写入可能覆盖它
+        if latest := b.Get([]byte(latestBlockName)); latest != nil { tip = append([]byte{}, latest...) }
+
+        return nil
+    })
+
+    bc := &Blockchain{tip: tip, db: db}
+    if err == nil { err = bc.upgradeUTXOSet() }
+    if err == nil { err = bc.UTXOSet().EnsureStateTree() }
+    if err == nil { err = bc.UTXOSet().EnsureAddrIndex() }
+    if err == nil && repair { err = bc.checkUTXOSet() }
+    if err != nil {
+        db.Close()
+        return nil, err
+    }
+
+    return bc, nil
+}
+
+/*
+创建创世块
+把奖励交易发送到指定address
+存储到数据库，与创世块的 utxo 在同一个事务中写入
+将创世块哈希保存为最后一个块的哈希
+创建一个新的 Blockchain 实例，其 tip 指向创世块（tip 有尾部，尖端的意思，在这里 tip 存储的是最后一个块的哈希）
+*/
+func CreateBlockchain(address string) (*Blockchain, error) {
+    if !crypto.ValidateAddress(address) { return nil, crypto.ErrInvalidAddress }
+    if DBExists() { return nil, ErrChainExists }
+
+    var tip []byte
+    db, err := bolt.Open(dbFile, 0600, nil)
+    if err != nil { return nil, err }
+
+    err = db.Update(func(tx *bolt.Tx) error {
+        rewardTx := types.NewRewardTx(address, genesisCoinbaseData, 0)
+        genesis, err := NewGenesisBlock(address, rewardTx)
+        if err != nil { return err }
+        data, err := genesis.Serialize()
+        if err != nil { return err }
+
+        b, err := tx.CreateBucket([]byte(blocksBucket))
+        if err != nil { return err }
+
+        err = b.Put(genesis.Hash.Bytes(), data)
+        if err != nil { return err }
+
+        err = b.Put([]byte(latestBlockName), genesis.Hash.Bytes())
+        if err != nil { return err }
+
+        if err := utxo.CreateBuckets(tx); err != nil { return err }
+        if err := utxo.UpdateTx(tx, genesis); err != nil { return err }
+
+        tip = genesis.Hash.Bytes()
+
+        return nil
+    })
+    if err != nil {
+        db.Close()
+        os.Remove(dbFile)
+        return nil, err
+    }
+
+    return &Blockchain{tip: tip, db: db}, nil
+}
+
+// 旧格式的 utxo 由全部区块重建，见 utxo.Set.IsOutdated
+func (bc *Blockchain) upgradeUTXOSet() error {
+    outdated, err := bc.UTXOSet().IsOutdated()
+    if err != nil || !outdated { return err }
+
+    return bc.ReindexUTXO()
+}
+
+/*
+启动时检查 utxo 与最新区块是否一致：utxo 承诺的树根需等于最新区块头中的 UTXORoot
+    旧版本写入区块和更新 utxo 不在同一个事务中，两者之间中断时 utxo 落后于最新区块
+    不一致时由全部区块重建 utxo，并记录在 utxoRepaired 中，由调用者决定如何提示
+    区块已被修剪时无法重建，返回 ErrUTXOSetMismatch
+    最新区块没有 utxo 承诺时（承诺之前挖出的区块）无法检查
+*/
+func (bc *Blockchain) checkUTXOSet() error {
+    if bc.tip == nil { return nil }
+
+    block, err := bc.GetBlock(bc.tip)
+    if err != nil { return err }
+    if (block.UTXORoot() == common.Hash{}) { return nil }
+    root, err := bc.UTXOSet().StateRoot()
+    if err != nil || root == block.UTXORoot() { return err }
+
+    if err := bc.CheckBlocksAvailable(0); err != nil { return fmt.Errorf("%w, at height %d: %v", ErrUTXOSetMismatch, block.Number(), err) }
+    utxos, err := bc.FindUTXO()
+    if err != nil { return err }
+    if err := bc.UTXOSet().Reindex(utxos); err != nil { return err }
+
+    root, err = bc.UTXOSet().StateRoot()
+    if err != nil { return err }
+    if root != block.UTXORoot() { return fmt.Errorf("%w, at height %d", ErrUTXOSetMismatch, block.Number()) }
+
+    bc.utxoRepaired = true
+    return nil
+}
+
+// 打开时 utxo 是否与最新区块不一致并已被重建
+func (bc *Blockchain) UTXOSetRepaired() bool {
+    return bc.utxoRepaired
+}
+
+// 判断数据库是否已经存在
+func DBExists() bool {
+    // os.IsNotExist f func(err error) bool
+    if _, err := os.Stat(dbFile); os.IsNotExist(err) { return false }
+    return true
+}
+
+// 挖出一个新区块并连接到最新区块之后
+// 交易无效时返回交易 ID 和校验的错误，不写入区块
+// 从读取最新区块到写入新区块期间持有写锁，不会有其他写入
+func (bc *Blockchain) MineBlock(miner string, transactions []*types.Transaction) (*types.Block, error) {
+    bc.writeMu.Lock()
+    defer bc.writeMu.Unlock()
+
+    lastBlock, err := bc.GetBlock(bc.Tip())
+    if err != nil { return nil, err }
+    height := lastBlock.Number().Int64() + 1
+    medianTime, err := bc.MedianTimePast(lastBlock)
+    if err != nil { return nil, err }
+
+    // 校验将被写入区块的所有交易
+    // 输入与输出的差额即手续费，归矿工所有
+    // 交易可以花费同一区块内之前的交易的输出，但不能花费相同的输出
+    fees := 0
+    view := NewTxView(bc)
+    for _, tx := range transactions {
+        fee, err := bc.ValidateTransaction(tx, view, height, medianTime)
+        if err != nil { return nil, fmt.Errorf("%w: transaction %x", err, tx.ID) }
+        fees += fee
+
+        view.AddTransaction(tx)
+    }
+
+    // load last block by lastHash
+    transactions = append([]*types.Transaction{types.NewRewardTx(miner, "", fees)}, transactions...)
+    utxoRoot, err := bc.UTXOSet().StateRootAfter(transactions)
+    if err != nil { return nil, err }
+    newBlock := consensus.NewBlock(miner, lastBlock, transactions, utxoRoot, medianTime)
+    // transactions = append(transactions, NewRewardTx(miner, ""))
+
+    if err := bc.connectBlock(newBlock); err != nil { return nil, err }
+
+    return newBlock, nil
+}
+
+// 保存一个区块作为最新区块，并更新 utxo
+// 区块需已经校验，见 ValidateBlock
+func (bc *Blockchain) ConnectBlock(block *types.Block) error {
+    bc.writeMu.Lock()
+    defer bc.writeMu.Unlock()
+
+    return bc.connectBlock(block)
+}
+
+// 区块、最新区块的 hash 和 utxo 在同一个事务中写入，任何一步失败时都不写入
+// 调用者需持有 writeMu
+func (bc *Blockchain) connectBlock(block *types.Block) error {
+    data, err := block.Serialize()
+    if err != nil { return err }
+
+    err = bc.db.Update(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(blocksBucket))
+
+        err := b.Put(block.Hash.Bytes(), data)
+        if err != nil { return err }
+
+        err = b.Put([]byte(latestBlockName), block.Hash.Bytes())
+        if err != nil { return err }
+
+        return utxo.UpdateTx(tx, block)
+    })
+    if err != nil { return err }
+
+    bc.tipMu.Lock()
+    bc.tip = block.Hash.Bytes()
+    bc.tipMu.Unlock()
+
+    return nil
+}
+
+// 数据库中是否有该区块
+func (bc *Blockchain) HasBlock(hash []byte) (bool, error) {
+    found := false
+
+    err := bc.db.View(func(tx *bolt.Tx) error {
+        found = tx.Bucket([]byte(blocksBucket)).Get(hash) != nil
+        return nil
+    })
+
+    return found, err
+}
+
+// 下一个区块的高度，以及校验时间锁使用的 MedianTimePast
+func (bc *Blockchain) NextBlockLockContext() (int64, int64, error) {
+    lastBlock, err := bc.GetBlock(bc.Tip())
+    if err != nil { return 0, 0, err }
+
+    medianTime, err := bc.MedianTimePast(lastBlock)
+    if err != nil { return 0, 0, err }
+
+    return lastBlock.Number().Int64() + 1, medianTime, nil
+}
+
+// 最新区块的高度
+func (bc *Blockchain) GetBestHeight() (int64, error) {
+    lastBlock, err := bc.GetBlock(bc.Tip())
+    if err != nil { return 0, err }
+
+    return lastBlock.Number().Int64(), nil
+}
+
+func (bc *Blockchain) Iterator() *BlockchainIterator {
+    bci := &BlockchainIterator{bc.Tip(), bc.db}
+
+    return bci
+}
+
+// 最新区块的哈希，空链（见 NewEmptyBlockchain）为 nil
+func (bc *Blockchain) Tip() []byte {
+    bc.tipMu.RLock()
+    defer bc.tipMu.RUnlock()
+
+    return bc.tip
+}
+
+// utxo 集合，与区块链使用同一个数据库
+func (bc *Blockchain) UTXOSet() utxo.Set {
+    return utxo.NewSet(bc.db)
+}
+
+// 交易池，与区块链使用同一个数据库
+func (bc *Blockchain) Mempool() Mempool {
+    return Mempool{bc}
+}
+
+// 由全部区块重建 utxo 集合，区块被修剪后无法重建
+func (bc *Blockchain) ReindexUTXO() error {
+    bc.writeMu.Lock()
+    defer bc.writeMu.Unlock()
+
+    if err := bc.CheckBlocksAvailable(0); err != nil { return err }
+    utxos, err := bc.FindUTXO()
+    if err != nil { return err }
+
+    return bc.UTXOSet().Reindex(utxos)
+}
+
+// 关闭数据库
+func (bc *Blockchain) Close() error {
+    return bc.db.Close()
+}
+
+// 找到所有未花费的输出
+// return map[txID]TXOutputs
+func (bc *Blockchain) FindUTXO() (map[string]types.TXOutputs, error) {
+    UTXO := make(map[string]types.TXOutputs)
+    spentTXOs := make(map[string][]int)
+    bci := bc.Iterator()
+
+    for {
+        block, err := bci.Next()
+        if err != nil { return nil, err }
+
+        // 遍历区块中全部交易，从后往前，同一区块内之后的交易可以花费之前的交易的输出
+        for i := len(block.Transactions) - 1; i >= 0; i-- {
+            tx := block.Transactions[i]
+            // hex.EncodeToString f func(src []byte) string
+            txID := hex.EncodeToString(tx.ID)
+
+        // 多层循环 continue作用于指定的循环
+        Outputs:
+            // 遍历交易的所有输出
+            // 因为区块是从最新往前遍历的
+            // 所以可以先检查输出，再检查输入
+            for outIdx, out := range tx.Vout {
+                // 数据输出不可花费，不加入 utxo
+                if out.IsUnspendable() { continue }
+
+                // 如果输出已经被包含在某个输入内 即已被花费 则跳过
+                for _, spentOut := range spentTXOs[txID] {
+                    if spentOut == outIdx {
+                        continue Outputs
+                    }
+                }
+
+                outs, ok := UTXO[txID]
+                if !ok { outs = types.TXOutputs{Outputs: make(map[int]types.TXOutput)} }
+                outs.Outputs[outIdx] = out
+                UTXO[txID] = outs
+            }
+
+            // 遍历交易的所有输入
+            if tx.IsCoinbase() == false {
+                for _, in := range tx.Vin {
+                    inTxID := hex.EncodeToString(in.Txid)
+                    spentTXOs[inTxID] = append(spentTXOs[inTxID], in.Vout)
+                }
+            }
+        }
+
+        // 循环至创世块
+        if (block.ParentHash() == common.Hash{}) { break }
+    }
+
+    return UTXO, nil
+}
+
+// 根据 tx.ID 找到交易
+// 所在区块被修剪时返回 ErrBlockPruned
+func (bc *Blockchain) FindTransaction(ID []byte) (types.Transaction, error) {
+    tx, block, err := bc.FindTransactionWithBlock(ID)
+    if err != nil { return tx, err }
+    if err := bc.CheckBlocksAvailable(block.Number().Int64()); err != nil { return types.Transaction{}, err }
+
+    return tx, nil
+}
+
+// 根据 tx.ID 找到交易及其所在的区块
+// 被修剪的区块只保留交易的 ID，见 prune.go
+func (bc *Blockchain) FindTransactionWithBlock(ID []byte) (types.Transaction, *types.Block, error) {
+    bci := bc.Iterator()
+
+    for {
+        block, err := bci.Next()
+        if err != nil { return types.Transaction{}, nil, err }
+
+        for _, tx := range block.Transactions {
+            if bytes.Compare(tx.ID, ID) == 0 { return *tx, block, nil }
+        }
+
+        if (block.ParentHash() == common.Hash{}) { break }
+    }
+
+    return types.Transaction{}, nil, fmt.Errorf("%w: %x", ErrTransactionNotFound, ID)
+}
+
+// 获取交易全部输入引用的上一笔交易
+// return map[txID]Transaction
+func (bc *Blockchain) FindPrevTransactions(tx *types.Transaction) (map[string]types.Transaction, error) {
+    prevTXs := make(map[string]types.Transaction)
+    view := NewTxView(bc)
+
+    for _, vin := range tx.Vin {
+        prevTX, err := view.FindTransaction(vin.Txid)
+        if err != nil { return nil, err }
+
+        prevTXs[hex.EncodeToString(prevTX.ID)] = prevTX
+    }
+
+    return prevTXs, nil
+}
+
+// 交易签名
+func (bc *Blockchain) SignTransaction(tx *types.Transaction, privKey ecdsa.PrivateKey) error {
+    prevTXs, err := bc.FindPrevTransactions(tx)
+    if err != nil { return err }
+
+    return tx.Sign(privKey, prevTXs)
+}
+
+// 使用多个私钥签名，每个输入使用其引用输出对应的私钥
+// privKeys: map[hex(pubKeyHash)]ecdsa.PrivateKey
+func (bc *Blockchain) SignTransactionWithKeys(tx *types.Transaction, privKeys map[string]ecdsa.PrivateKey) error {
+    prevTXs, err := bc.FindPrevTransactions(tx)
+    if err != nil { return err }
+
+    return tx.SignWithKeys(privKeys, prevTXs)
+}
+
+// 校验一笔将被写入高度为 height 的区块的交易，返回交易的手续费
+// view 包含同一区块内之前的交易，medianTime 为父区块的 MedianTimePast
+func (bc *Blockchain) ValidateTransaction(tx *types.Transaction, view *TxView, height, medianTime int64) (int, error) {
+    fee, err := bc.CheckTransactionInputs(tx, view)
+    if err != nil { return 0, err }
+
+    err = bc.CheckTransactionLocks(tx, view, height, medianTime)
+    if err != nil { return 0, err }
+
+    return fee, nil
+}
+
+// 校验交易的 ID、输入、签名、输出和手续费，不校验时间锁，返回交易的手续费
+// 可花费输出的金额必须大于 0，见 TXOutput.CheckValue
+func (bc *Blockchain) CheckTransactionInputs(tx *types.Transaction, view *TxView) (int, error) {
+    if tx.IsCoinbase() || len(tx.Vin) == 0 { return 0, types.ErrInvalidTransaction }
+    if err := tx.CheckID(); err != nil { return 0, err }
+
+    // 输入引用的输出必须尚未被花费
+    for _, vin := range tx.Vin {
+        if _, err := view.FindOutput(vin.Txid, vin.Vout); err != nil { return 0, err }
+    }
+
+    if !tx.Verify(view.PrevTransactions(tx)) { return 0, ErrInvalidSignature }
+
+    for _, out := range tx.Vout {
+        if err := out.CheckValue(); err != nil { return 0, err }
+    }
+
+    fee, err := view.CalculateFee(tx)
+    if err != nil { return 0, err }
+    if fee < 0 { return 0, ErrNegativeFee }
+
+    return fee, nil
+}
+
+// 验证交易
+func (bc *Blockchain) VerifyTransaction(tx *types.Transaction) (bool, error) {
+    if tx.IsCoinbase() { return true, nil }
+
+    prevTXs, err := bc.FindPrevTransactions(tx)
+    if err != nil { return false, err }
+
+    return tx.Verify(prevTXs), nil
+}
+
+// 计算交易的手续费，即输入总额与输出总额之差
+func (bc *Blockchain) CalculateFee(tx *types.Transaction) (int, error) {
+    if tx.IsCoinbase() { return 0, nil }
+
+    fee := 0
+    for _, vin := range tx.Vin {
+        prevTX, err := bc.FindTransaction(vin.Txid)
+        if err != nil { return 0, err }
+        if vin.Vout < 0 || vin.Vout >= len(prevTX.Vout) { return 0, utxo.ErrMissingInput }
+
+        fee += prevTX.Vout[vin.Vout].Value
+    }
+
+    outputs, err := tx.OutputValue()
+    if err != nil { return 0, err }
+
+    return fee - outputs, nil
+}
+
+// 获取创世块
+// rewardTx 矿工的奖励交易，不需要引用之前交易。
+// @return: *Block
+// func NewGenesisBlock(miner common.Address, rewardTx *Transaction) *Block {
+func NewGenesisBlock(miner string, rewardTx *types.Transaction) (*types.Block, error) {
+    // return NewBlock(miner, nil, []*Transaction{})
+    utxoRoot, err := utxo.GenesisStateRoot([]*types.Transaction{rewardTx})
+    if err != nil { return nil, err }
+
+    return consensus.NewBlock(miner, nil, []*types.Transaction{rewardTx}, utxoRoot, 0), nil
+}
@@ -0,0 +1,32 @@
+package chain
+
+import (
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 区块链迭代
+type BlockchainIterator struct {
+    currentHash []byte
+    db          *bolt.DB
+}
+
+// 其实是查找上一个区块
+// 区块不存在或无法解码时返回错误，迭代器停留在当前位置
+func (i *BlockchainIterator) Next() (*types.Block, error) {
+    var block *types.Block
+
+    err := i.db.View(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(blocksBucket))
+        encodedBlock := b.Get(i.currentHash)
+        var err error
+        block, err = types.DeserializeBlock(encodedBlock)
+
+        return err
+    })
+    if err != nil { return nil, err }
+
+    i.currentHash = block.ParentHash().Bytes()
+
+    return block, nil
+}
@@ -0,0 +1,214 @@
+package chain
+
+import (
+    "os"
+    "sync"
+    "bytes"
+    "math"
+    "errors"
+    "testing"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+// 在临时目录中创建区块链，创世区块的奖励属于返回的私钥
+// 数据库路径为相对路径 dbFile，因此切换到临时目录，测试不能并行
+func newTestBlockchain(t *testing.T) (*Blockchain, ecdsa.PrivateKey, string) {
+    t.Chdir(t.TempDir())
+    if err := os.Mkdir("data", 0755); err != nil { t.Fatal(err) }
+
+    private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil { t.Fatal(err) }
+    pubKey := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
+    address := crypto.PubKeyHashToAddress(crypto.HashPubKey(pubKey))
+
+    bc, err := CreateBlockchain(address)
+    if err != nil { t.Fatal(err) }
+    t.Cleanup(func() { bc.Close() })
+
+    return bc, *private, address
+}
+
+// 花费 prevTx 的第一个输出，转回同一地址，支付 fee
+func newTestSpend(t *testing.T, bc *Blockchain, prevTx *types.Transaction, privKey ecdsa.PrivateKey, address string, fee int) *types.Transaction {
+    in := types.TXInput{Txid: prevTx.ID, Vout: 0, Sequence: types.SequenceFinal}
+    tx := &types.Transaction{Vin: []types.TXInput{in}, Vout: []types.TXOutput{*types.NewTXOutput(prevTx.Vout[0].Value - fee, address)}}
+
+    return signTestTx(t, privKey, tx, prevTx)
+}
+
+// 填写输入的公钥和交易 ID，并用 privKey 签名全部输入
+// prevTxs 为输入引用的交易，可以是交易池中的交易
+func signTestTx(t *testing.T, privKey ecdsa.PrivateKey, tx *types.Transaction, prevTxs ...*types.Transaction) *types.Transaction {
+    pubKey := append(privKey.PublicKey.X.Bytes(), privKey.PublicKey.Y.Bytes()...)
+    for i := range tx.Vin {
+        tx.Vin[i].PubKey = pubKey
+    }
+    tx.ID = tx.UnsignedHash()
+
+    prevTXs := make(map[string]types.Transaction)
+    for _, prevTx := range prevTxs {
+        prevTXs[hex.EncodeToString(prevTx.ID)] = *prevTx
+    }
+    if err := tx.Sign(privKey, prevTXs); err != nil { t.Fatal(err) }
+
+    return tx
+}
+
+// 读取区块链直到 done 被关闭，区块高度只能增加，余额为奖励的整数倍
+func readUntilDone(t *testing.T, bc *Blockchain, address string, done <-chan struct{}) {
+    last := int64(-1)
+    for {
+        select {
+        case <-done:
+            return
+        default:
+        }
+
+        height, err := bc.GetBestHeight()
+        if err != nil { t.Error(err); return }
+        if height < last { t.Errorf("best height went back from %d to %d", last, height) }
+        last = height
+
+        if _, err := bc.FindUTXO(); err != nil { t.Error(err); return }
+        if _, err := bc.Mempool().Entries(); err != nil { t.Error(err); return }
+        if _, _, err := bc.NextBlockLockContext(); err != nil { t.Error(err); return }
+
+        balance, err := bc.UTXOSet().GetBalance(address)
+        if err != nil { t.Error(err); return }
+        if balance < types.Subsidy || balance % types.Subsidy != 0 { t.Errorf("unexpected balance %d", balance) }
+    }
+}
+
+// 并发挖矿、加入交易池和读取，运行 go test -race 检查数据竞争
+// vendor 中的 bolt 不能通过 checkptr 检查，需加上 -gcflags=all=-d=checkptr=0
+func TestConcurrentMineBlock(t *testing.T) {
+    bc, privKey, address := newTestBlockchain(t)
+
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    spend := newTestSpend(t, bc, genesis.Transactions[0], privKey, address, MinRelayFee(2))
+
+    const miners = 2
+    done := make(chan struct{})
+    var readers sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        readers.Add(1)
+        go func() {
+            defer readers.Done()
+            readUntilDone(t, bc, address, done)
+        }()
+    }
+
+    var writers sync.WaitGroup
+    for i := 0; i < miners; i++ {
+        writers.Add(1)
+        go func() {
+            defer writers.Done()
+            if _, err := bc.MineBlock(address, nil); err != nil { t.Error(err) }
+        }()
+    }
+    writers.Add(1)
+    go func() {
+        defer writers.Done()
+        if err := bc.Mempool().Add(spend); err != nil { t.Error(err) }
+    }()
+
+    writers.Wait()
+    close(done)
+    readers.Wait()
+
+    height, err := bc.GetBestHeight()
+    if err != nil { t.Fatal(err) }
+    if height != miners { t.Fatalf("best height %d, want %d", height, miners) }
+
+    // 每个区块连接在之前的区块之后，utxo 与最新区块一致
+    if _, err := bc.VerifyChain(MaxVerifyLevel, 0); err != nil { t.Fatal(err) }
+
+    entries, err := bc.Mempool().Entries()
+    if err != nil { t.Fatal(err) }
+    if len(entries) != 1 { t.Fatalf("%d mempool entries, want 1", len(entries)) }
+}
+
+// 依次连接区块的同时并发读取
+func TestConcurrentConnectBlock(t *testing.T) {
+    bc, _, address := newTestBlockchain(t)
+    for i := 0; i < 2; i++ {
+        if _, err := bc.MineBlock(address, nil); err != nil { t.Fatal(err) }
+    }
+    blocks, err := bc.readChain()
+    if err != nil { t.Fatal(err) }
+
+    scratch, err := openScratchBlockchain()
+    if err != nil { t.Fatal(err) }
+    defer scratch.Remove()
+
+    // 读取需要至少一个区块
+    if err := scratch.ConnectBlock(blocks[0]); err != nil { t.Fatal(err) }
+
+    done := make(chan struct{})
+    var readers sync.WaitGroup
+    for i := 0; i < 4; i++ {
+        readers.Add(1)
+        go func() {
+            defer readers.Done()
+            readUntilDone(t, scratch, address, done)
+        }()
+    }
+
+    // 出错时先停止读取，再结束测试
+    parent := blocks[0]
+    for _, block := range blocks[1:] {
+        if err = scratch.ValidateBlock(block, parent); err != nil { break }
+        if err = scratch.ConnectBlock(block); err != nil { break }
+        parent = block
+    }
+    close(done)
+    readers.Wait()
+    if err != nil { t.Fatal(err) }
+
+    if !bytes.Equal(scratch.Tip(), parent.Hash.Bytes()) { t.Fatalf("tip %x, want %x", scratch.Tip(), parent.Hash) }
+
+    want, err := bc.UTXOSet().StateRoot()
+    if err != nil { t.Fatal(err) }
+    got, err := scratch.UTXOSet().StateRoot()
+    if err != nil { t.Fatal(err) }
+    if got != want { t.Fatalf("UTXO root %x, want %x", got, want) }
+}
+
+// 负数金额的输出不能抵消其他输出而凭空产生金额，金额为 0 的输出和总额溢出同样无效
+func TestRejectInvalidOutputValues(t *testing.T) {
+    bc, privKey, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    coinbase := genesis.Transactions[0]
+
+    tests := []struct {
+        values []int
+        want   error
+    }{
+        {[]int{1000000, -999978}, types.ErrInvalidOutputValue},
+        {[]int{0, types.Subsidy - 4}, types.ErrInvalidOutputValue},
+        {[]int{math.MaxInt, math.MaxInt}, types.ErrOutputValueOverflow},
+    }
+
+    for _, test := range tests {
+        tx := &types.Transaction{Vin: []types.TXInput{{Txid: coinbase.ID, Vout: 0, Sequence: types.SequenceFinal}}}
+        for _, value := range test.values {
+            tx.Vout = append(tx.Vout, *types.NewTXOutput(value, address))
+        }
+        signTestTx(t, privKey, tx, coinbase)
+
+        if err := bc.Mempool().Add(tx); !errors.Is(err, test.want) { t.Errorf("outputs %v: mempool got %v, want %v", test.values, err, test.want) }
+        if _, err := bc.MineBlock(address, []*types.Transaction{tx}); !errors.Is(err, test.want) { t.Errorf("outputs %v: block got %v, want %v", test.values, err, test.want) }
+    }
+
+    balance, err := bc.UTXOSet().GetBalance(address)
+    if err != nil { t.Fatal(err) }
+    if balance != types.Subsidy { t.Fatalf("balance %d, want %d", balance, types.Subsidy) }
+}
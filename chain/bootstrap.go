@@ -0,0 +1,169 @@
+package chain
+
+/*
+区块链的导出文件 (bootstrap)，与比特币的 bootstrap.dat 类似：
+    区块按高度从低到高依次写入，每个区块为一帧：
+        4 字节 bootstrapMagic + 4 字节大端序长度 + 序列化的区块
+    导入时每个区块都经过完整校验，见 ValidateBlock
+*/
+
+import (
+    "io"
+    "os"
+    "io/ioutil"
+    "bytes"
+    "errors"
+    "encoding/binary"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+var bootstrapMagic = []byte{0x53, 0x42, 0x43, 0x42} // "SBCB"
+
+// 单个区块的长度上限，防止读取损坏的文件时分配过多内存
+const maxBootstrapFrameSize = 32 << 20
+
+var ErrInvalidBootstrap = errors.New("ERROR: Invalid bootstrap file")
+
+// 写入一个区块
+func WriteBootstrapBlock(w io.Writer, block *types.Block) error {
+    data, err := block.Serialize()
+    if err != nil { return err }
+
+    header := make([]byte, 8)
+    copy(header, bootstrapMagic)
+    binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+
+    if _, err := w.Write(header); err != nil { return err }
+    _, err = w.Write(data)
+    return err
+}
+
+// 读取下一个区块，文件结束时返回 io.EOF
+func ReadBootstrapBlock(r io.Reader) (*types.Block, error) {
+    header := make([]byte, 8)
+    if _, err := io.ReadFull(r, header); err != nil {
+        if err == io.ErrUnexpectedEOF { return nil, ErrInvalidBootstrap }
+        return nil, err
+    }
+    if bytes.Compare(header[:4], bootstrapMagic) != 0 { return nil, ErrInvalidBootstrap }
+
+    size := binary.BigEndian.Uint32(header[4:])
+    if size > maxBootstrapFrameSize { return nil, ErrInvalidBootstrap }
+
+    data := make([]byte, size)
+    if _, err := io.ReadFull(r, data); err != nil { return nil, ErrInvalidBootstrap }
+
+    return types.DeserializeBlock(data)
+}
+
+// 高度在 [from, to] 之间的区块的 hash，按高度从低到高
+func (bc *Blockchain) BlockHashes(from, to int64) ([][]byte, error) {
+    var hashes [][]byte
+
+    bci := bc.Iterator()
+    for {
+        block, err := bci.Next()
+        if err != nil { return nil, err }
+        height := block.Number().Int64()
+
+        if height < from { break }
+        if height <= to { hashes = append([][]byte{block.Hash.Bytes()}, hashes...) }
+
+        if (block.ParentHash() == common.Hash{}) { break }
+    }
+
+    return hashes, nil
+}
+
+// 读取一个区块，区块不存在或数据损坏时返回错误
+func (bc *Blockchain) GetBlock(hash []byte) (*types.Block, error) {
+    var block *types.Block
+
+    err := bc.db.View(func(tx *bolt.Tx) error {
+        var err error
+        block, err = getBlockTx(tx, hash)
+        return err
+    })
+
+    return block, err
+}
+
+// 在事务 tx 中读取一个区块，见 GetBlock
+func getBlockTx(tx *bolt.Tx, hash []byte) (*types.Block, error) {
+    encodedBlock := tx.Bucket([]byte(blocksBucket)).Get(hash)
+    if encodedBlock == nil { return nil, errors.New("ERROR: Block is not found") }
+
+    return types.DeserializeBlock(encodedBlock)
+}
+
+// 用于导入区块的空区块链，数据库不能已经存在
+func NewEmptyBlockchain() (*Blockchain, error) {
+    if DBExists() { return nil, ErrChainExists }
+
+    return openEmptyBlockchain(dbFile)
+}
+
+// 导入区块时使用的临时数据库
+const importDBFile = dbFile + ".import"
+
+/*
+用于导入区块的空区块链，在临时数据库中导入
+    全部导入成功之后调用 Commit 成为区块链，失败时调用 Remove 删除，不会留下没有区块的数据库
+    上次导入中断时留下的临时数据库被删除
+*/
+func NewImportBlockchain() (*Blockchain, error) {
+    if DBExists() { return nil, ErrChainExists }
+    if err := os.Remove(importDBFile); err != nil && !os.IsNotExist(err) { return nil, err }
+
+    return openEmptyBlockchain(importDBFile)
+}
+
+// 关闭导入完成的临时数据库，将其作为区块链的数据库
+func (bc *Blockchain) Commit() error {
+    if bc.Tip() == nil { return ErrInvalidBootstrap }
+
+    path := bc.db.Path()
+    if err := bc.db.Close(); err != nil { return err }
+    if DBExists() { return ErrChainExists }
+
+    return os.Rename(path, dbFile)
+}
+
+// 临时数据库中的空区块链，用于重放区块，使用后调用 Remove 删除
+func openScratchBlockchain() (*Blockchain, error) {
+    f, err := ioutil.TempFile("", "scratchchain")
+    if err != nil { return nil, err }
+    path := f.Name()
+    f.Close()
+    os.Remove(path)
+
+    return openEmptyBlockchain(path)
+}
+
+// 关闭并删除数据库文件
+func (bc *Blockchain) Remove() {
+    path := bc.db.Path()
+    bc.db.Close()
+    os.Remove(path)
+}
+
+// 在 path 创建一个没有区块的数据库
+func openEmptyBlockchain(path string) (*Blockchain, error) {
+    db, err := bolt.Open(path, 0600, nil)
+    if err != nil { return nil, err }
+
+    err = db.Update(func(tx *bolt.Tx) error {
+        if _, err := tx.CreateBucket([]byte(blocksBucket)); err != nil { return err }
+        return utxo.CreateBuckets(tx)
+    })
+    if err != nil {
+        db.Close()
+        return nil, err
+    }
+
+    return &Blockchain{db: db}, nil
+}
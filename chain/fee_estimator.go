@@ -0,0 +1,198 @@
+package chain
+
+/*
+手续费估计，与比特币的 estimatesmartfee 类似：
+    交易池中的交易按手续费率（每个输入/输出的手续费）分组，
+    记录每组交易从加入交易池到被写入区块经过的区块数，
+    估计在 N 个区块内被确认的交易中，成功率达到 feeSuccessThreshold 的最低手续费率
+    统计数据按区块衰减，近期的区块影响更大，并保存在文件中
+*/
+
+import (
+    "os"
+    "bytes"
+    "errors"
+    "io/ioutil"
+    "encoding/gob"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+const feeEstimatesFile = "data/fee_estimates.dat"
+
+const (
+    MaxConfirmTarget     = 25    // 最多估计多少个区块内确认
+    DefaultConfirmTarget = 6     // send 默认的确认目标
+    feeDecay             = 0.998 // 每个区块的衰减系数
+    feeSuccessThreshold  = 0.85  // 在目标区块数内确认的比例
+    feeMinSamples        = 1.0   // 每组至少需要的（衰减后的）交易数
+)
+
+// 手续费率分组的下限
+var feeBucketRates = []int{0, 1, 2, 3, 4, 5, 6, 8, 10, 12, 15, 20, 25, 30, 40, 50, 60, 80, 100}
+
+var ErrInsufficientFeeData = errors.New("ERROR: Insufficient data to estimate fee")
+
+// 一个手续费率分组的统计
+type FeeBucket struct {
+    FeeRate   int
+    Confirmed []float64 // Confirmed[t-1]: t 个区块内被确认的交易数
+    Total     float64   // 被确认的交易数
+}
+
+type FeeEstimator struct {
+    Buckets []FeeBucket
+    Height  int64 // 已统计的最高区块，-1 表示尚未统计
+}
+
+func NewFeeEstimator() *FeeEstimator {
+    estimator := FeeEstimator{nil, -1}
+    for _, rate := range feeBucketRates {
+        estimator.Buckets = append(estimator.Buckets, FeeBucket{rate, make([]float64, MaxConfirmTarget), 0})
+    }
+
+    return &estimator
+}
+
+// 交易所属的分组
+func feeBucketIndex(fee, size int) int {
+    rate := fee / size
+
+    index := 0
+    for i, bucketRate := range feeBucketRates {
+        if rate >= bucketRate { index = i }
+    }
+    return index
+}
+
+// 是否统计交易池中的这笔交易
+// 时间锁交易的确认时间与手续费无关
+func feeTracked(entry MempoolEntry) bool {
+    return entry.Tx.LockTime == 0
+}
+
+// 统计新区块中此前在交易池中的交易
+// entries 为区块写入前的交易池，已统计过的区块会被忽略
+func (e *FeeEstimator) ProcessBlock(block *types.Block, entries []MempoolEntry) {
+    height := block.Number().Int64()
+    if height <= e.Height { return }
+
+    for i := range e.Buckets {
+        bucket := &e.Buckets[i]
+        for t := range bucket.Confirmed {
+            bucket.Confirmed[t] *= feeDecay
+        }
+        bucket.Total *= feeDecay
+    }
+
+    pool := make(map[string]MempoolEntry)
+    for _, entry := range entries {
+        pool[string(entry.Tx.ID)] = entry
+    }
+
+    for _, tx := range block.Transactions {
+        entry, ok := pool[string(tx.ID)]
+        if !ok || !feeTracked(entry) { continue }
+
+        blocks := int(height - entry.Height)
+        if blocks < 1 { blocks = 1 }
+
+        bucket := &e.Buckets[feeBucketIndex(entry.Fee, entry.Tx.Size())]
+        for t := blocks; t <= MaxConfirmTarget; t++ {
+            bucket.Confirmed[t - 1]++
+        }
+        bucket.Total++
+    }
+
+    e.Height = height
+}
+
+/*
+估计在 target 个区块内被确认需要的手续费率
+    pending 为交易池中尚未确认的交易，已等待超过 target 个区块的交易计为失败
+    从手续费率最高的分组开始，找到成功率达到阈值的最低分组，数据不足的分组被跳过
+*/
+func (e *FeeEstimator) EstimateFee(target int, pending []MempoolEntry, height int64) (int, error) {
+    if target < 1 || target > MaxConfirmTarget { return 0, errors.New("ERROR: Confirmation target out of range") }
+
+    failed := make([]float64, len(e.Buckets))
+    for _, entry := range pending {
+        if !feeTracked(entry) || height - entry.Height < int64(target) { continue }
+        failed[feeBucketIndex(entry.Fee, entry.Tx.Size())]++
+    }
+
+    estimate := -1
+    for i := len(e.Buckets) - 1; i >= 0; i-- {
+        bucket := e.Buckets[i]
+
+        total := bucket.Total + failed[i]
+        if total < feeMinSamples { continue }
+        if bucket.Confirmed[target - 1] / total < feeSuccessThreshold { break }
+
+        estimate = bucket.FeeRate
+    }
+
+    if estimate < 0 { return 0, ErrInsufficientFeeData }
+    return estimate, nil
+}
+
+// 新区块写入后更新手续费统计，entries 为区块写入前的交易池
+func UpdateFeeEstimates(block *types.Block, entries []MempoolEntry) error {
+    estimator, err := LoadFeeEstimator()
+    if err != nil { return err }
+
+    estimator.ProcessBlock(block, entries)
+    return estimator.SaveToFile()
+}
+
+// 导入新的区块链之后重置手续费统计，height 为最新区块的高度
+// 导入的区块没有经过交易池，没有可以统计的交易，此前的统计属于其他区块链
+func ResetFeeEstimates(height int64) error {
+    estimator := NewFeeEstimator()
+    estimator.Height = height
+
+    return estimator.SaveToFile()
+}
+
+// 在 target 个区块内被确认需要的手续费率，数据不足时为最低手续费率
+func EstimateFeeRate(bc *Blockchain, target int) (int, error) {
+    estimator, err := LoadFeeEstimator()
+    if err != nil { return 0, err }
+    entries, err := Mempool{bc}.Entries()
+    if err != nil { return 0, err }
+    height, err := bc.GetBestHeight()
+    if err != nil { return 0, err }
+
+    rate, err := estimator.EstimateFee(target, entries, height)
+    if err != nil || rate < MinRelayFeeRate { return MinRelayFeeRate, nil }
+
+    return rate, nil
+}
+
+// 从文件中加载手续费统计，没有文件时返回新的统计
+func LoadFeeEstimator() (*FeeEstimator, error) {
+    if _, err := os.Stat(feeEstimatesFile); os.IsNotExist(err) {
+        return NewFeeEstimator(), nil
+    }
+
+    fileContent, err := ioutil.ReadFile(feeEstimatesFile)
+    if err != nil { return nil, err }
+
+    var estimator FeeEstimator
+
+    decoder := gob.NewDecoder(bytes.NewReader(fileContent))
+    err = decoder.Decode(&estimator)
+    if err != nil { return nil, err }
+
+    return &estimator, nil
+}
+
+func (e *FeeEstimator) SaveToFile() error {
+    var content bytes.Buffer
+
+    encoder := gob.NewEncoder(&content)
+    err := encoder.Encode(e)
+    if err != nil { return err }
+
+    return ioutil.WriteFile(feeEstimatesFile, content.Bytes(), 0644)
+}
@@ -0,0 +1,100 @@
+package chain
+
+import (
+    "errors"
+    "math"
+    "math/big"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 交易池中的一笔交易，大小为 size，在高度 height 加入交易池
+func newFeeTestEntry(id int, fee, size int, height int64) MempoolEntry {
+    tx := types.Transaction{ID: []byte{byte(id), byte(id >> 8)}, Vin: make([]types.TXInput, 1), Vout: make([]types.TXOutput, size - 1)}
+    return MempoolEntry{tx, fee, 0, height}
+}
+
+func newFeeTestBlock(height int64, entries ...MempoolEntry) *types.Block {
+    block := &types.Block{Header: &types.Header{Number: big.NewInt(height), Timestamp: big.NewInt(0)}}
+    for i := range entries {
+        block.Transactions = append(block.Transactions, &entries[i].Tx)
+    }
+    return block
+}
+
+func TestFeeEstimatorProcessBlock(t *testing.T) {
+    const eps = 1e-9
+    estimator := NewFeeEstimator()
+
+    // 手续费率 5，在 2 个区块内被确认
+    entry := newFeeTestEntry(1, 10, 2, 0)
+    timelocked := newFeeTestEntry(2, 10, 2, 0)
+    timelocked.Tx.LockTime = 1
+    estimator.ProcessBlock(newFeeTestBlock(2, entry, timelocked), []MempoolEntry{entry, timelocked})
+
+    bucket := &estimator.Buckets[feeBucketIndex(10, 2)]
+    if bucket.FeeRate != 5 { t.Fatalf("bucket fee rate %d, want 5", bucket.FeeRate) }
+    if bucket.Total != 1 { t.Errorf("total %v, want 1", bucket.Total) }
+    if bucket.Confirmed[0] != 0 || bucket.Confirmed[1] != 1 || bucket.Confirmed[MaxConfirmTarget - 1] != 1 {
+        t.Errorf("confirmed %v, want 1 from target 2", bucket.Confirmed)
+    }
+
+    // 每个区块衰减一次，已统计过的区块被忽略
+    estimator.ProcessBlock(newFeeTestBlock(3), nil)
+    estimator.ProcessBlock(newFeeTestBlock(3), nil)
+    estimator.ProcessBlock(newFeeTestBlock(2, entry), []MempoolEntry{entry})
+    if estimator.Height != 3 { t.Errorf("height %d, want 3", estimator.Height) }
+    if math.Abs(bucket.Total - feeDecay) > eps || math.Abs(bucket.Confirmed[1] - feeDecay) > eps {
+        t.Errorf("total %v, confirmed %v after one block, want %v", bucket.Total, bucket.Confirmed[1], feeDecay)
+    }
+
+    estimator.ProcessBlock(newFeeTestBlock(13), nil)
+    if want := math.Pow(feeDecay, 2); math.Abs(bucket.Total - want) > eps { t.Errorf("total %v after two blocks, want %v", bucket.Total, want) }
+
+    // 不在交易池中的交易不被统计
+    estimator.ProcessBlock(newFeeTestBlock(14, entry), nil)
+    if want := math.Pow(feeDecay, 3); math.Abs(bucket.Total - want) > eps { t.Errorf("total %v, want %v", bucket.Total, want) }
+}
+
+func TestFeeEstimatorEstimateFee(t *testing.T) {
+    estimator := NewFeeEstimator()
+    if _, err := estimator.EstimateFee(1, nil, 0); !errors.Is(err, ErrInsufficientFeeData) { t.Errorf("got %v, want %v", err, ErrInsufficientFeeData) }
+
+    // 手续费率 10 的交易在 1 个区块内被确认，手续费率 2 的交易在 4 个区块内被确认
+    var fast, slow []MempoolEntry
+    for i := 0; i < 5; i++ {
+        fast = append(fast, newFeeTestEntry(i, 20, 2, 0))
+        slow = append(slow, newFeeTestEntry(10 + i, 4, 2, 0))
+    }
+    estimator.ProcessBlock(newFeeTestBlock(1, fast...), append(fast, slow...))
+    estimator.ProcessBlock(newFeeTestBlock(2), slow)
+    estimator.ProcessBlock(newFeeTestBlock(3), slow)
+    estimator.ProcessBlock(newFeeTestBlock(4, slow...), slow)
+
+    // 等待了 4 个区块仍未被确认的交易计为失败
+    stuck := newFeeTestEntry(20, 4, 2, 0)
+    recent := newFeeTestEntry(21, 4, 2, 1)
+
+    tests := []struct {
+        target  int
+        pending []MempoolEntry
+        want    int
+    }{
+        {1, nil, 10},
+        {3, nil, 10},
+        {4, nil, 2},
+        {MaxConfirmTarget, nil, 2},
+        {4, []MempoolEntry{recent}, 2},
+        {4, []MempoolEntry{stuck}, 10},
+    }
+    for _, test := range tests {
+        rate, err := estimator.EstimateFee(test.target, test.pending, 4)
+        if err != nil { t.Errorf("target %d: %v", test.target, err); continue }
+        if rate != test.want { t.Errorf("target %d, %d pending: got %d, want %d", test.target, len(test.pending), rate, test.want) }
+    }
+
+    for _, target := range []int{0, MaxConfirmTarget + 1} {
+        if _, err := estimator.EstimateFee(target, nil, 4); err == nil { t.Errorf("target %d: expected an error", target) }
+    }
+}
@@ -0,0 +1,32 @@
+package chain
+
+import (
+    "bytes"
+    "errors"
+
+    "github.com/guoxingx/simple-blockchain/common"
+)
+
+// 在链上找到花费合约的 redeem 交易，返回其中的 secret
+func (bc *Blockchain) ExtractSecret(txID []byte, vout int) ([]byte, error) {
+    bci := bc.Iterator()
+
+    for {
+        block, err := bci.Next()
+        if err != nil { return nil, err }
+
+        for _, tx := range block.Transactions {
+            for _, vin := range tx.Vin {
+                if bytes.Compare(vin.Txid, txID) == 0 && vin.Vout == vout && vin.Secret != nil {
+                    return vin.Secret, nil
+                }
+            }
+        }
+
+        if (block.ParentHash() == common.Hash{}) { break }
+    }
+
+    // redeem 交易可能在被修剪的区块中
+    if err := bc.CheckBlocksAvailable(0); err != nil { return nil, err }
+    return nil, errors.New("ERROR: Contract has not been redeemed")
+}
@@ -0,0 +1,86 @@
+package chain
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+func newTestKey(t *testing.T) (ecdsa.PrivateKey, []byte) {
+    private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil { t.Fatal(err) }
+    pubKey := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
+
+    return *private, crypto.HashPubKey(pubKey)
+}
+
+// 合约的 redeem 和 refund：secret 正确，时间锁到期，且由合约指定的一方签名
+func TestHTLCSpend(t *testing.T) {
+    bc, payerKey, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    payerHash := crypto.AddressToPubKeyHash(address)
+    recipientKey, recipientHash := newTestKey(t)
+    malloryKey, _ := newTestKey(t)
+
+    const lockTime = 10
+    secret, secretHash := types.NewHTLCSecret()
+    wrongSecret, _ := types.NewHTLCSecret()
+    contract := &types.HTLC{SecretHash: secretHash, RecipientPubKeyHash: recipientHash, RefundPubKeyHash: payerHash, LockTime: lockTime}
+
+    coinbase := genesis.Transactions[0]
+    fund := &types.Transaction{
+        Vin:  []types.TXInput{{Txid: coinbase.ID, Vout: 0, Sequence: types.SequenceFinal}},
+        Vout: []types.TXOutput{*types.NewHTLCOutput(types.Subsidy - 2, contract)},
+    }
+    signTestTx(t, payerKey, fund, coinbase)
+    if _, err := bc.MineBlock(address, []*types.Transaction{fund}); err != nil { t.Fatal(err) }
+
+    // signer 以合约要求的一方的身份签名，不是该方时校验失败
+    spend := func(secret []byte, signer ecdsa.PrivateKey, sequence uint32, lockTime int64) *types.Transaction {
+        in := types.TXInput{Txid: fund.ID, Vout: 0, PubKey: append(signer.PublicKey.X.Bytes(), signer.PublicKey.Y.Bytes()...), Sequence: sequence, Secret: secret}
+        tx := &types.Transaction{Vin: []types.TXInput{in}, Vout: []types.TXOutput{*types.NewTXOutput(types.Subsidy - 4, address)}, LockTime: lockTime}
+        tx.ID = tx.UnsignedHash()
+
+        privKeys := map[string]ecdsa.PrivateKey{hex.EncodeToString(contract.SpenderPubKeyHash(in)): signer}
+        if err := tx.SignWithKeys(privKeys, map[string]types.Transaction{hex.EncodeToString(fund.ID): *fund}); err != nil { t.Fatal(err) }
+        return tx
+    }
+
+    const refundSequence = types.SequenceFinal - 1
+    tests := []struct {
+        name   string
+        tx     *types.Transaction
+        height int64
+        want   error
+    }{
+        {"redeem", spend(secret, recipientKey, types.SequenceFinal, 0), 2, nil},
+        {"redeem with wrong secret", spend(wrongSecret, recipientKey, types.SequenceFinal, 0), 2, ErrInvalidSignature},
+        {"redeem with refund key", spend(secret, payerKey, types.SequenceFinal, 0), 2, ErrInvalidSignature},
+        {"redeem with other key", spend(secret, malloryKey, types.SequenceFinal, 0), 2, ErrInvalidSignature},
+        {"refund before lock time", spend(nil, payerKey, refundSequence, lockTime), lockTime, types.ErrTransactionNotFinal},
+        {"refund after lock time", spend(nil, payerKey, refundSequence, lockTime), lockTime + 1, nil},
+        {"refund with earlier lock time", spend(nil, payerKey, refundSequence, lockTime - 1), lockTime + 1, ErrInvalidSignature},
+        {"refund with final sequence", spend(nil, payerKey, types.SequenceFinal, lockTime), lockTime + 1, ErrInvalidSignature},
+        {"refund with recipient key", spend(nil, recipientKey, refundSequence, lockTime), lockTime + 1, ErrInvalidSignature},
+    }
+
+    for _, test := range tests {
+        _, err := bc.ValidateTransaction(test.tx, NewTxView(bc), test.height, 0)
+        if !errors.Is(err, test.want) { t.Errorf("%s: got %v, want %v", test.name, err, test.want) }
+    }
+
+    // redeem 写入区块之后，可以从中得到 secret
+    redeem := tests[0].tx
+    if _, err := bc.MineBlock(address, []*types.Transaction{redeem}); err != nil { t.Fatal(err) }
+    extracted, err := bc.ExtractSecret(fund.ID, 0)
+    if err != nil { t.Fatal(err) }
+    if !bytes.Equal(extracted, secret) { t.Fatalf("extracted secret %x, want %x", extracted, secret) }
+}
@@ -0,0 +1,90 @@
+package chain
+
+import (
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+// 一个未花费输出及其确认数，0 为交易池中的交易
+type UnspentOutput struct {
+    types.UTXO
+    Confirmations int64
+}
+
+/*
+addresses 的未花费输出
+    确认数不小于 minConf 的输出，minConf 为 0 时包括交易池中交易的输出
+    已被交易池中的交易花费的输出不列出
+*/
+func (bc *Blockchain) ListUnspent(addresses []string, minConf int64) ([]UnspentOutput, error) {
+    u := bc.UTXOSet()
+    mempool := Mempool{bc}
+    entries, err := mempool.Entries()
+    if err != nil { return nil, err }
+    spent := spentOutputs(entries)
+
+    pubKeyHashes := make(map[string]bool)
+    var utxos []types.UTXO
+    for _, address := range addresses {
+        if !crypto.ValidateAddress(address) { return nil, crypto.ErrInvalidAddress }
+        pubKeyHash := crypto.AddressToPubKeyHash(address)
+        pubKeyHashes[hex.EncodeToString(pubKeyHash)] = true
+
+        unspent, err := u.FindUnspentOutputs(pubKeyHash)
+        if err != nil { return nil, err }
+        for _, utxo := range unspent {
+            if !spent[types.OutpointKey(utxo.TxID, utxo.Index)] { utxos = append(utxos, utxo) }
+        }
+    }
+
+    txIDs := make(map[string]bool)
+    for _, utxo := range utxos {
+        txIDs[hex.EncodeToString(utxo.TxID)] = true
+    }
+    heights, err := bc.TransactionHeights(txIDs)
+    if err != nil { return nil, err }
+    bestHeight, err := bc.GetBestHeight()
+    if err != nil { return nil, err }
+
+    var result []UnspentOutput
+    for _, utxo := range utxos {
+        confirmations := bestHeight - heights[hex.EncodeToString(utxo.TxID)] + 1
+        if confirmations >= minConf { result = append(result, UnspentOutput{utxo, confirmations}) }
+    }
+
+    if minConf > 0 { return result, nil }
+    for _, entry := range entries {
+        for outIdx, out := range entry.Tx.Vout {
+            if !pubKeyHashes[hex.EncodeToString(out.PubKeyHash)] || out.IsUnspendable() { continue }
+            if spent[types.OutpointKey(entry.Tx.ID, outIdx)] { continue }
+
+            result = append(result, UnspentOutput{types.UTXO{TxID: entry.Tx.ID, Index: outIdx, Output: out}, 0})
+        }
+    }
+    return result, nil
+}
+
+// txIDs 中的交易所在区块的高度，从最新区块往前查找，直到全部找到
+// 被修剪的区块保留了交易 ID，因此也可以查找
+func (bc *Blockchain) TransactionHeights(txIDs map[string]bool) (map[string]int64, error) {
+    heights := make(map[string]int64)
+    if len(txIDs) == 0 { return heights, nil }
+
+    bci := bc.Iterator()
+    for {
+        block, err := bci.Next()
+        if err != nil { return nil, err }
+
+        for _, tx := range block.Transactions {
+            txID := hex.EncodeToString(tx.ID)
+            if txIDs[txID] { heights[txID] = block.Number().Int64() }
+        }
+        if len(heights) == len(txIDs) { break }
+
+        if (block.ParentHash() == common.Hash{}) { break }
+    }
+    return heights, nil
+}
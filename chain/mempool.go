@@ -0,0 +1,292 @@
+package chain
+
+import (
+    "time"
+    "bytes"
+    "errors"
+    "encoding/gob"
+    "encoding/hex"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 尚未被写入区块的交易，例如时间锁尚未到期的交易
+const mempoolBucket = "mempool"
+
+// 进入交易池的最低手续费，每个输入/输出
+const MinRelayFeeRate = 1
+
+var ErrFeeTooLow = errors.New("ERROR: Transaction fee is below the minimum relay fee")
+var ErrInvalidPackage = errors.New("ERROR: Package transactions must be parents of a later transaction in the package")
+
+type Mempool struct {
+    Blockchain *Blockchain
+}
+
+// 交易池中的一笔交易
+type MempoolEntry struct {
+    Tx     types.Transaction
+    Fee    int
+    Time   int64 // 加入交易池的时间
+    Height int64 // 加入交易池时的区块高度
+}
+
+// 大小为 size 的交易进入交易池的最低手续费
+func MinRelayFee(size int) int {
+    return MinRelayFeeRate * size
+}
+
+// 加入一笔交易，见 AddPackage
+func (m Mempool) Add(tx *types.Transaction) error {
+    return m.AddPackage([]*types.Transaction{tx})
+}
+
+/*
+加入一组交易，父交易在前
+    交易的签名、输入和手续费需有效，时间锁可以尚未到期
+    输入可以引用交易池中或者同一组中之前的交易的输出
+    已在交易池中的交易被跳过
+    与交易池中的交易花费相同的输出时，需满足替换条件，见 rbf.go
+    新加入交易的手续费之和不能低于其大小之和的最低手续费，
+    因此手续费过低的父交易可以和子交易一起加入 (child pays for parent)
+*/
+func (m Mempool) AddPackage(txs []*types.Transaction) error {
+    bc := m.Blockchain
+    bc.writeMu.Lock()
+    defer bc.writeMu.Unlock()
+
+    entries, err := m.Entries()
+    if err != nil { return err }
+
+    inPool := make(map[string]bool)
+    for _, entry := range entries {
+        inPool[hex.EncodeToString(entry.Tx.ID)] = true
+    }
+
+    var newTxs []*types.Transaction
+    for _, tx := range txs {
+        if tx.IsCoinbase() { return errors.New("ERROR: Coinbase transaction can not be added to mempool") }
+        if !inPool[hex.EncodeToString(tx.ID)] { newTxs = append(newTxs, tx) }
+    }
+    if len(newTxs) == 0 { return nil }
+    if !isChildWithParents(newTxs) { return ErrInvalidPackage }
+
+    // 被替换的交易不再可见
+    replaced := make(map[string]MempoolEntry)
+    direct := make(map[string]bool)
+    for _, tx := range newTxs {
+        conflicts, conflictIDs, err := m.conflicts(tx, entries)
+        if err != nil { return err }
+
+        for _, entry := range conflicts {
+            replaced[hex.EncodeToString(entry.Tx.ID)] = entry
+        }
+        for id := range conflictIDs {
+            direct[id] = true
+        }
+    }
+
+    view := NewTxView(bc)
+    for i := range entries {
+        if _, ok := replaced[hex.EncodeToString(entries[i].Tx.ID)]; !ok { view.AddTransaction(&entries[i].Tx) }
+    }
+
+    height, err := bc.GetBestHeight()
+    if err != nil { return err }
+
+    var added []MempoolEntry
+    fees, size := 0, 0
+    for _, tx := range newTxs {
+        fee, err := bc.CheckTransactionInputs(tx, view)
+        if err != nil { return err }
+
+        view.AddTransaction(tx)
+        added = append(added, MempoolEntry{*tx, fee, time.Now().Unix(), height})
+        fees += fee
+        size += tx.Size()
+    }
+
+    if fees < MinRelayFee(size) { return ErrFeeTooLow }
+
+    var replacedEntries []MempoolEntry
+    for _, entry := range replaced {
+        replacedEntries = append(replacedEntries, entry)
+    }
+    if len(replacedEntries) > 0 {
+        if err := checkReplacement(fees, size, replacedEntries, direct); err != nil { return err }
+    }
+
+    return bc.db.Update(func(btx *bolt.Tx) error {
+        b, err := btx.CreateBucketIfNotExists([]byte(mempoolBucket))
+        if err != nil { return err }
+
+        for _, entry := range replacedEntries {
+            if err := b.Delete(entry.Tx.ID); err != nil { return err }
+        }
+        for _, entry := range added {
+            data, err := entry.Serialize()
+            if err != nil { return err }
+            if err := b.Put(entry.Tx.ID, data); err != nil { return err }
+        }
+        return nil
+    })
+}
+
+// 除最后一笔之外，每笔交易的输出都被之后的交易花费
+func isChildWithParents(txs []*types.Transaction) bool {
+    for i := 0; i < len(txs) - 1; i++ {
+        parentID := hex.EncodeToString(txs[i].ID)
+        spent := false
+
+        for _, child := range txs[i + 1:] {
+            for _, vin := range child.Vin {
+                if hex.EncodeToString(vin.Txid) == parentID { spent = true }
+            }
+        }
+        if !spent { return false }
+    }
+    return true
+}
+
+// 根据 ID 找到交易池中的交易
+func (m Mempool) Get(txID []byte) (MempoolEntry, bool, error) {
+    var entry MempoolEntry
+    found := false
+
+    err := m.Blockchain.db.View(func(btx *bolt.Tx) error {
+        b := btx.Bucket([]byte(mempoolBucket))
+        if b == nil { return nil }
+
+        data := b.Get(txID)
+        if data == nil { return nil }
+
+        var err error
+        entry, err = DeserializeMempoolEntry(data)
+        found = err == nil
+        return err
+    })
+
+    return entry, found, err
+}
+
+// 交易池中的全部交易
+func (m Mempool) Entries() ([]MempoolEntry, error) {
+    var entries []MempoolEntry
+
+    err := m.Blockchain.db.View(func(btx *bolt.Tx) error {
+        b := btx.Bucket([]byte(mempoolBucket))
+        if b == nil { return nil }
+
+        return b.ForEach(func(k, v []byte) error {
+            entry, err := DeserializeMempoolEntry(v)
+            if err != nil { return err }
+
+            entries = append(entries, entry)
+            return nil
+        })
+    })
+    if err != nil { return nil, err }
+
+    return entries, nil
+}
+
+// 包含交易池中全部交易的 view
+func (m Mempool) View() (*TxView, error) {
+    view := NewTxView(m.Blockchain)
+
+    entries, err := m.Entries()
+    if err != nil { return nil, err }
+    for i := range entries {
+        view.AddTransaction(&entries[i].Tx)
+    }
+
+    return view, nil
+}
+
+// 交易池中已被花费的输出
+func (m Mempool) SpentOutputs() (map[string]bool, error) {
+    entries, err := m.Entries()
+    if err != nil { return nil, err }
+
+    return spentOutputs(entries), nil
+}
+
+func spentOutputs(entries []MempoolEntry) map[string]bool {
+    spent := make(map[string]bool)
+
+    for _, entry := range entries {
+        for _, vin := range entry.Tx.Vin {
+            spent[types.OutpointKey(vin.Txid, vin.Vout)] = true
+        }
+    }
+
+    return spent
+}
+
+// 新区块写入后，移除已被写入的交易，与区块内交易花费相同输出的交易，以及后者的后代交易
+func (m Mempool) RemoveBlock(block *types.Block) error {
+    m.Blockchain.writeMu.Lock()
+    defer m.Blockchain.writeMu.Unlock()
+
+    blockSpent := make(map[string]bool)
+    included := make(map[string]bool)
+    for _, tx := range block.Transactions {
+        included[hex.EncodeToString(tx.ID)] = true
+        if tx.IsCoinbase() { continue }
+        for _, vin := range tx.Vin {
+            blockSpent[types.OutpointKey(vin.Txid, vin.Vout)] = true
+        }
+    }
+
+    entries, err := m.Entries()
+    if err != nil { return err }
+
+    graph := NewMempoolGraph(entries)
+    removed := make(map[string]bool)
+    for _, id := range graph.Order {
+        for _, vin := range graph.Entries[id].Tx.Vin {
+            if !blockSpent[types.OutpointKey(vin.Txid, vin.Vout)] { continue }
+
+            // 被写入区块的交易的子交易保留，冲突交易的后代交易一并移除
+            removed[id] = true
+            if !included[id] {
+                for _, descendant := range graph.Descendants(id) {
+                    removed[descendant] = true
+                }
+            }
+            break
+        }
+    }
+
+    return m.Blockchain.db.Update(func(btx *bolt.Tx) error {
+        b := btx.Bucket([]byte(mempoolBucket))
+        if b == nil { return nil }
+
+        for id := range removed {
+            if err := b.Delete(graph.Entries[id].Tx.ID); err != nil { return err }
+        }
+        return nil
+    })
+}
+
+// Serialize serializes MempoolEntry
+func (entry MempoolEntry) Serialize() ([]byte, error) {
+    var buff bytes.Buffer
+
+    enc := gob.NewEncoder(&buff)
+    err := enc.Encode(entry)
+    if err != nil { return nil, err }
+
+    return buff.Bytes(), nil
+}
+
+// DeserializeMempoolEntry deserializes MempoolEntry
+func DeserializeMempoolEntry(data []byte) (MempoolEntry, error) {
+    var entry MempoolEntry
+
+    dec := gob.NewDecoder(bytes.NewReader(data))
+    err := dec.Decode(&entry)
+
+    return entry, err
+}
@@ -0,0 +1,28 @@
+package chain
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 一组交易中有无效的交易时，整组都不会被加入交易池
+func TestAddPackageAtomic(t *testing.T) {
+    bc, privKey, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    mempool := bc.Mempool()
+
+    parent := newTestPayment(t, privKey, address, genesis.Transactions[0], 0, types.SequenceFinal, 26)
+    child := newTestPayment(t, privKey, address, parent, 0, types.SequenceFinal, 20)
+    // 签名之后修改输出金额，签名不再有效
+    child.Vout[0].Value = 25
+    child.ID = child.UnsignedHash()
+
+    if err := mempool.AddPackage([]*types.Transaction{parent, child}); !errors.Is(err, ErrInvalidSignature) { t.Fatalf("got %v, want %v", err, ErrInvalidSignature) }
+
+    entries, err := mempool.Entries()
+    if err != nil { t.Fatal(err) }
+    if len(entries) != 0 { t.Fatalf("%d mempool entries after rejected package, want 0", len(entries)) }
+}
@@ -0,0 +1,65 @@
+package chain
+
+/*
+链上公证：
+    将文件的 sha256 写入一个数据输出，区块的时间戳即可证明文件在该时间之前已经存在
+    数据为 notaryTag + sha256(file)，与其他用途的数据输出区分
+*/
+
+import (
+    "bytes"
+    "errors"
+    "io/ioutil"
+    "crypto/sha256"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+const notaryTag = "NTRY"
+
+var ErrNotarizationNotFound = errors.New("ERROR: Document has not been notarized")
+
+// 文件的 sha256
+func HashFile(file string) ([]byte, error) {
+    content, err := ioutil.ReadFile(file)
+    if err != nil { return nil, err }
+
+    hash := sha256.Sum256(content)
+    return hash[:], nil
+}
+
+// 公证 hash 时写入数据输出的数据
+func NotarizationData(hash []byte) []byte {
+    return append([]byte(notaryTag), hash...)
+}
+
+// 在链上找到公证 hash 的交易及其所在区块，有多个时返回最早的一个
+func (bc *Blockchain) FindNotarization(hash []byte) (*types.Transaction, *types.Block, error) {
+    var foundTx *types.Transaction
+    var foundBlock *types.Block
+    data := NotarizationData(hash)
+
+    bci := bc.Iterator()
+    for {
+        block, err := bci.Next()
+        if err != nil { return nil, nil, err }
+
+        for _, tx := range block.Transactions {
+            for _, out := range tx.Vout {
+                if out.IsUnspendable() && bytes.Compare(out.Data, data) == 0 {
+                    foundTx, foundBlock = tx, block
+                }
+            }
+        }
+
+        if (block.ParentHash() == common.Hash{}) { break }
+    }
+
+    if foundTx == nil {
+        // 可能在被修剪的区块中
+        if err := bc.CheckBlocksAvailable(0); err != nil { return nil, nil, err }
+        return nil, nil, ErrNotarizationNotFound
+    }
+    return foundTx, foundBlock, nil
+}
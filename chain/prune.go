@@ -0,0 +1,198 @@
+package chain
+
+/*
+修剪模式：
+    新区块只需要 utxo 即可校验，旧区块的交易可以删除以节省空间
+    被修剪的区块保留区块头和交易的 ID，用于遍历区块链、计算 MedianTimePast 和相对时间锁
+    最新的 MinPruneDepth 个区块作为重组窗口，保留完整的交易和 undo 数据
+    需要被修剪的交易的操作（例如 rescan）返回 ErrBlockPruned
+
+修剪目标：
+    DEPTH: 保留最新的 DEPTH 个区块
+    SIZE:  保留最新的区块，直到其大小之和超过 SIZE，单位为 KB、MB 或 GB
+    设置后每写入一个新区块修剪一次
+*/
+
+import (
+    "fmt"
+    "bytes"
+    "errors"
+    "strings"
+    "strconv"
+    "encoding/gob"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+// blocksBucket 中保存修剪高度和修剪目标的 key
+const prunedHeightName = "pruned"
+const pruneTargetName = "prunetarget"
+
+// 重组窗口，最新的区块不会被修剪
+const MinPruneDepth = 6
+
+var ErrBlockPruned = errors.New("ERROR: Block data has been pruned")
+var ErrInvalidPruneTarget = errors.New("ERROR: Prune target must be a depth or a size like 10MB")
+
+// 修剪目标，Depth 和 Size 只有一个不为 0
+type PruneTarget struct {
+    Depth int64
+    Size  int64 // 字节
+}
+
+// 解析 DEPTH 或 SIZE，0 表示关闭修剪，返回 nil
+func ParsePruneTarget(s string) (*PruneTarget, error) {
+    units := map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30}
+
+    for suffix, unit := range units {
+        if !strings.HasSuffix(strings.ToUpper(s), suffix) { continue }
+
+        size, err := strconv.ParseInt(s[:len(s) - len(suffix)], 10, 64)
+        if err != nil || size <= 0 { return nil, ErrInvalidPruneTarget }
+        return &PruneTarget{0, size * unit}, nil
+    }
+
+    depth, err := strconv.ParseInt(s, 10, 64)
+    if err != nil || depth < 0 { return nil, ErrInvalidPruneTarget }
+    if depth == 0 { return nil, nil }
+    if depth < MinPruneDepth { return nil, fmt.Errorf("%w, depth must be at least %d", ErrInvalidPruneTarget, MinPruneDepth) }
+
+    return &PruneTarget{depth, 0}, nil
+}
+
+func (t PruneTarget) String() string {
+    if t.Depth > 0 { return fmt.Sprintf("depth %d", t.Depth) }
+    return fmt.Sprintf("size %d bytes", t.Size)
+}
+
+// 已被修剪的最高区块，没有被修剪的区块时为 -1
+func (bc *Blockchain) PruneHeight() (int64, error) {
+    height := int64(-1)
+
+    err := bc.db.View(func(tx *bolt.Tx) error {
+        data := tx.Bucket([]byte(blocksBucket)).Get([]byte(prunedHeightName))
+        if data != nil { height = common.BytesToInt64(data) }
+        return nil
+    })
+
+    return height, err
+}
+
+// 高度不低于 from 的区块均未被修剪
+func (bc *Blockchain) CheckBlocksAvailable(from int64) error {
+    pruned, err := bc.PruneHeight()
+    if err != nil { return err }
+    if from <= pruned { return fmt.Errorf("%w up to height %d", ErrBlockPruned, pruned) }
+
+    return nil
+}
+
+// 当前的修剪目标，没有开启修剪时为 nil
+func (bc *Blockchain) PruneTarget() (*PruneTarget, error) {
+    var target *PruneTarget
+
+    err := bc.db.View(func(tx *bolt.Tx) error {
+        data := tx.Bucket([]byte(blocksBucket)).Get([]byte(pruneTargetName))
+        if data == nil { return nil }
+
+        target = &PruneTarget{}
+        return gob.NewDecoder(bytes.NewReader(data)).Decode(target)
+    })
+    if err != nil { return nil, err }
+
+    return target, nil
+}
+
+// 设置修剪目标，nil 时关闭修剪，已被修剪的区块不会恢复
+func (bc *Blockchain) SetPruneTarget(target *PruneTarget) error {
+    return bc.db.Update(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(blocksBucket))
+        if target == nil { return b.Delete([]byte(pruneTargetName)) }
+
+        var buff bytes.Buffer
+        if err := gob.NewEncoder(&buff).Encode(target); err != nil { return err }
+        return b.Put([]byte(pruneTargetName), buff.Bytes())
+    })
+}
+
+// 按修剪目标修剪区块，返回修剪高度
+func (bc *Blockchain) Prune() (int64, error) {
+    bc.writeMu.Lock()
+    defer bc.writeMu.Unlock()
+
+    pruned, err := bc.PruneHeight()
+    if err != nil { return 0, err }
+    target, err := bc.PruneTarget()
+    if err != nil || target == nil { return pruned, err }
+
+    best, err := bc.GetBestHeight()
+    if err != nil { return 0, err }
+    height := best - MinPruneDepth
+
+    if target.Depth > 0 {
+        if best - target.Depth < height { height = best - target.Depth }
+    } else {
+        // 从最新区块往前累计大小，超过目标的区块及其之前的区块被修剪
+        size := int64(0)
+        bci := bc.Iterator()
+        for {
+            block, err := bci.Next()
+            if err != nil { return 0, err }
+            if block.Number().Int64() <= pruned {
+                height = pruned
+                break
+            }
+
+            data, err := block.Serialize()
+            if err != nil { return 0, err }
+            size += int64(len(data))
+            if size > target.Size {
+                if block.Number().Int64() < height { height = block.Number().Int64() }
+                break
+            }
+
+            if (block.ParentHash() == common.Hash{}) {
+                height = pruned
+                break
+            }
+        }
+    }
+
+    if height <= pruned { return pruned, nil }
+
+    if err := bc.pruneBlocks(pruned, height); err != nil { return 0, err }
+    return height, nil
+}
+
+// 修剪高度在 (from, to] 之间的区块：只保留区块头和交易 ID，删除 undo 数据
+func (bc *Blockchain) pruneBlocks(from, to int64) error {
+    return bc.db.Update(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(blocksBucket))
+        undo := tx.Bucket([]byte(utxo.UndoBucket))
+
+        hash := bc.Tip()
+        for {
+            block, err := types.DeserializeBlock(b.Get(hash))
+            if err != nil { return err }
+            height := block.Number().Int64()
+            if height <= from { break }
+
+            if height <= to {
+                data, err := block.PrunedCopy().Serialize()
+                if err != nil { return err }
+                if err := b.Put(hash, data); err != nil { return err }
+                if undo != nil {
+                    if err := undo.Delete(hash); err != nil { return err }
+                }
+            }
+
+            if (block.ParentHash() == common.Hash{}) { break }
+            hash = block.ParentHash().Bytes()
+        }
+
+        return b.Put([]byte(prunedHeightName), common.Int64ToBytes(to))
+    })
+}
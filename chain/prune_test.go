@@ -0,0 +1,81 @@
+package chain
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+func TestParsePruneTarget(t *testing.T) {
+    tests := []struct {
+        s    string
+        want *PruneTarget
+        err  error
+    }{
+        {"0", nil, nil},
+        {"6", &PruneTarget{6, 0}, nil},
+        {"100", &PruneTarget{100, 0}, nil},
+        {"2kb", &PruneTarget{0, 2 << 10}, nil},
+        {"10MB", &PruneTarget{0, 10 << 20}, nil},
+        {"1GB", &PruneTarget{0, 1 << 30}, nil},
+        {"5", nil, ErrInvalidPruneTarget},
+        {"-1", nil, ErrInvalidPruneTarget},
+        {"0MB", nil, ErrInvalidPruneTarget},
+        {"10TB", nil, ErrInvalidPruneTarget},
+        {"", nil, ErrInvalidPruneTarget},
+    }
+
+    for _, test := range tests {
+        target, err := ParsePruneTarget(test.s)
+        if !errors.Is(err, test.err) { t.Errorf("ParsePruneTarget(%q): got error %v, want %v", test.s, err, test.err); continue }
+        if (target == nil) != (test.want == nil) || target != nil && *target != *test.want { t.Errorf("ParsePruneTarget(%q) = %v, want %v", test.s, target, test.want) }
+    }
+}
+
+// 被修剪的区块保留区块头和交易 ID，最新的 MinPruneDepth 个区块不会被修剪
+func TestPrune(t *testing.T) {
+    bc, _, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+
+    blocks := []*types.Block{genesis}
+    for i := 1; i <= MinPruneDepth + 3; i++ {
+        blocks = append(blocks, connectTestBlock(t, bc, blocks[i - 1], int64(i), address))
+    }
+    best := int64(len(blocks) - 1)
+
+    // 没有修剪目标时不修剪
+    if height, err := bc.Prune(); err != nil || height != -1 { t.Fatalf("Prune() = %d, %v, want -1", height, err) }
+
+    // 修剪目标低于重组窗口时，只修剪到重组窗口之前
+    if err := bc.SetPruneTarget(&PruneTarget{Depth: 2}); err != nil { t.Fatal(err) }
+    height, err := bc.Prune()
+    if err != nil { t.Fatal(err) }
+    if want := best - MinPruneDepth; height != want { t.Fatalf("pruned to height %d, want %d", height, want) }
+    if pruned, err := bc.PruneHeight(); err != nil || pruned != height { t.Fatalf("PruneHeight() = %d, %v, want %d", pruned, err, height) }
+
+    for _, block := range blocks {
+        stored, err := bc.GetBlock(block.Hash.Bytes())
+        if err != nil { t.Fatal(err) }
+        if stored.Header.Number.Cmp(block.Number()) != 0 || stored.ParentHash() != block.ParentHash() || stored.TxHash() != block.TxHash() { t.Errorf("block %d header changed", block.Number()) }
+        if len(stored.Transactions) != len(block.Transactions) { t.Fatalf("block %d has %d transactions, want %d", block.Number(), len(stored.Transactions), len(block.Transactions)) }
+
+        for i, tx := range stored.Transactions {
+            if !bytes.Equal(tx.ID, block.Transactions[i].ID) { t.Errorf("block %d transaction %d ID changed", block.Number(), i) }
+            if isPruned := tx.Vout == nil; isPruned != (block.Number().Int64() <= height) { t.Errorf("block %d transaction pruned: %v", block.Number(), isPruned) }
+        }
+    }
+
+    // 需要被修剪的交易的操作返回 ErrBlockPruned
+    if err := bc.CheckBlocksAvailable(height); !errors.Is(err, ErrBlockPruned) { t.Errorf("CheckBlocksAvailable(%d): got %v, want %v", height, err, ErrBlockPruned) }
+    if err := bc.CheckBlocksAvailable(height + 1); err != nil { t.Errorf("CheckBlocksAvailable(%d): %v", height + 1, err) }
+    if _, err := bc.FindTransaction(blocks[1].Transactions[0].ID); !errors.Is(err, ErrBlockPruned) { t.Errorf("FindTransaction: got %v, want %v", err, ErrBlockPruned) }
+    if _, err := bc.FindTransaction(blocks[best].Transactions[0].ID); err != nil { t.Errorf("FindTransaction: %v", err) }
+
+    // 已被修剪的区块不会恢复，新区块写入后继续修剪
+    if height, err := bc.Prune(); err != nil || height != best - MinPruneDepth { t.Errorf("Prune() = %d, %v, want %d", height, err, best - MinPruneDepth) }
+    connectTestBlock(t, bc, blocks[best], best + 1, address)
+    if height, err := bc.Prune(); err != nil || height != best + 1 - MinPruneDepth { t.Errorf("Prune() = %d, %v, want %d", height, err, best + 1 - MinPruneDepth) }
+}
@@ -0,0 +1,91 @@
+package chain
+
+/*
+交易替换 (replace-by-fee)，与比特币的 BIP125 类似：
+    任一输入的 Sequence 不大于 SequenceMaxReplaceable 时，交易声明可以被替换
+    与交易池中的交易花费相同输出的新交易，满足以下条件时替换这些交易及其后代交易：
+        1. 被直接替换的交易均声明可以被替换
+        2. 新交易的手续费大于全部被替换交易的手续费之和
+        3. 多出的手续费不少于新交易每个输入/输出 minReplacementFeeIncrement
+        4. 新交易的手续费率高于每笔被直接替换的交易
+        5. 被替换的交易（包括后代交易）不超过 MaxReplacementEvictions 笔
+    加入一组交易时，手续费和大小为整组新交易之和
+*/
+
+import (
+    "errors"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 替换交易每个输入/输出需要多支付的最低手续费
+const minReplacementFeeIncrement = 1
+
+// 一次替换最多移除的交易池交易数
+const MaxReplacementEvictions = 100
+
+var ErrReplacementNotAllowed = errors.New("ERROR: Conflicting mempool transaction is not replaceable")
+var ErrReplacementFeeTooLow = errors.New("ERROR: Replacement transaction does not pay enough fee")
+var ErrReplacementFeeRateTooLow = errors.New("ERROR: Replacement transaction fee rate is not higher than the replaced transaction")
+var ErrTooManyReplacements = errors.New("ERROR: Replacement transaction would evict too many mempool transactions")
+
+// 替换交易的最低手续费，replacedFee 为全部被替换交易的手续费之和，size 为替换交易的大小
+func MinReplacementFee(size, replacedFee int) int {
+    return replacedFee + minReplacementFeeIncrement * size
+}
+
+// 交易池中与 tx 花费相同输出的交易及其后代交易，即加入 tx 时需要被替换的交易
+// 被直接替换的交易不可替换时返回错误
+// 同时返回其中与 tx 直接冲突的交易的 hex(txID)，其余为它们的后代交易
+func (m Mempool) conflicts(tx *types.Transaction, entries []MempoolEntry) ([]MempoolEntry, map[string]bool, error) {
+    graph := NewMempoolGraph(entries)
+
+    spenders := make(map[string]string)
+    for _, id := range graph.Order {
+        for _, vin := range graph.Entries[id].Tx.Vin {
+            spenders[types.OutpointKey(vin.Txid, vin.Vout)] = id
+        }
+    }
+
+    var replaced []MempoolEntry
+    direct := make(map[string]bool)
+    seen := make(map[string]bool)
+    for _, vin := range tx.Vin {
+        id, ok := spenders[types.OutpointKey(vin.Txid, vin.Vout)]
+        if !ok { continue }
+        direct[id] = true
+        if seen[id] { continue }
+
+        conflict := graph.Entries[id].Tx
+        if !conflict.IsReplaceable() { return nil, nil, ErrReplacementNotAllowed }
+
+        // 花费被替换交易输出的后代交易也需要移除
+        for _, txID := range append([]string{id}, graph.Descendants(id)...) {
+            if seen[txID] { continue }
+            seen[txID] = true
+            replaced = append(replaced, graph.Entries[txID])
+        }
+    }
+
+    return replaced, direct, nil
+}
+
+/*
+替换交易需满足的条件 2 到 5，fee 和 size 为替换交易的手续费和大小
+    replaced 为全部被替换的交易，direct 为其中与替换交易直接冲突的交易的 hex(txID)
+*/
+func checkReplacement(fee, size int, replaced []MempoolEntry, direct map[string]bool) error {
+    if len(replaced) > MaxReplacementEvictions { return ErrTooManyReplacements }
+
+    replacedFee := 0
+    for _, entry := range replaced {
+        replacedFee += entry.Fee
+
+        // 比较 fee / size，交叉相乘避免整数除法的舍入
+        if direct[hex.EncodeToString(entry.Tx.ID)] && fee * entry.Tx.Size() <= entry.Fee * size { return ErrReplacementFeeRateTooLow }
+    }
+    if fee <= replacedFee || fee < MinReplacementFee(size, replacedFee) { return ErrReplacementFeeTooLow }
+
+    return nil
+}
@@ -0,0 +1,96 @@
+package chain
+
+import (
+    "errors"
+    "testing"
+    "crypto/ecdsa"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 花费 prevTx 的第 vout 个输出，输出金额为 values，全部转到 address
+func newTestPayment(t *testing.T, privKey ecdsa.PrivateKey, address string, prevTx *types.Transaction, vout int, sequence uint32, values ...int) *types.Transaction {
+    tx := &types.Transaction{Vin: []types.TXInput{{Txid: prevTx.ID, Vout: vout, Sequence: sequence}}}
+    for _, value := range values {
+        tx.Vout = append(tx.Vout, *types.NewTXOutput(value, address))
+    }
+
+    return signTestTx(t, privKey, tx, prevTx)
+}
+
+func TestReplaceByFee(t *testing.T) {
+    bc, privKey, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    mempool := bc.Mempool()
+    const replaceable = types.SequenceMaxReplaceable
+
+    // parent 的两个输出分别被不可替换的 final 和可替换的 original 花费
+    // original 的大小为 2，手续费 6，手续费率 3
+    parent := newTestPayment(t, privKey, address, genesis.Transactions[0], 0, types.SequenceFinal, 5, 18)
+    final := newTestPayment(t, privKey, address, parent, 0, types.SequenceFinal, 3)
+    original := newTestPayment(t, privKey, address, parent, 1, replaceable, 12)
+    for _, tx := range []*types.Transaction{parent, final, original} {
+        if err := mempool.Add(tx); err != nil { t.Fatal(err) }
+    }
+
+    tests := []struct {
+        name string
+        tx   *types.Transaction
+        want error
+    }{
+        {"original does not signal", newTestPayment(t, privKey, address, parent, 0, replaceable, 1), ErrReplacementNotAllowed},
+        {"same fee", newTestPayment(t, privKey, address, parent, 1, replaceable, 6, 6), ErrReplacementFeeRateTooLow},
+        {"lower fee rate", newTestPayment(t, privKey, address, parent, 1, replaceable, 1, 2, 2, 2), ErrReplacementFeeRateTooLow},
+        {"fee increase below the minimum", newTestPayment(t, privKey, address, parent, 1, replaceable, 11), ErrReplacementFeeTooLow},
+    }
+    for _, test := range tests {
+        if err := mempool.Add(test.tx); !errors.Is(err, test.want) { t.Errorf("%s: got %v, want %v", test.name, err, test.want) }
+    }
+
+    // 手续费 8，替换 original
+    replacement := newTestPayment(t, privKey, address, parent, 1, replaceable, 10)
+    if err := mempool.Add(replacement); err != nil { t.Fatal(err) }
+    if _, found, _ := mempool.Get(original.ID); found { t.Fatal("replaced transaction is still in the mempool") }
+
+    // 替换有后代交易的交易时，需要支付被替换的全部交易的手续费 8 + 2，并多支付 2
+    child := newTestPayment(t, privKey, address, replacement, 0, types.SequenceFinal, 8)
+    if err := mempool.Add(child); err != nil { t.Fatal(err) }
+
+    if err := mempool.Add(newTestPayment(t, privKey, address, parent, 1, replaceable, 7)); !errors.Is(err, ErrReplacementFeeTooLow) { t.Errorf("descendant fees not paid: got %v, want %v", err, ErrReplacementFeeTooLow) }
+    if err := mempool.Add(newTestPayment(t, privKey, address, parent, 1, replaceable, 6)); err != nil { t.Fatal(err) }
+
+    entries, err := mempool.Entries()
+    if err != nil { t.Fatal(err) }
+    if len(entries) != 3 { t.Fatalf("%d mempool entries, want 3", len(entries)) }
+    for _, tx := range []*types.Transaction{replacement, child} {
+        if _, found, _ := mempool.Get(tx.ID); found { t.Errorf("replaced transaction %x is still in the mempool", tx.ID) }
+    }
+}
+
+// 一次替换最多移除 MaxReplacementEvictions 笔交易，包括后代交易
+func TestCheckReplacementEvictions(t *testing.T) {
+    replaced := func(n int) []MempoolEntry {
+        var entries []MempoolEntry
+        for i := 0; i < n; i++ {
+            tx := types.Transaction{ID: []byte{byte(i), byte(i >> 8)}, Vin: make([]types.TXInput, 1), Vout: make([]types.TXOutput, 1)}
+            entries = append(entries, MempoolEntry{Tx: tx, Fee: 2})
+        }
+        return entries
+    }
+    direct := map[string]bool{"0000": true}
+
+    tests := []struct {
+        evicted int
+        want    error
+    }{
+        {1, nil},
+        {MaxReplacementEvictions, nil},
+        {MaxReplacementEvictions + 1, ErrTooManyReplacements},
+    }
+    for _, test := range tests {
+        // 足够支付全部被替换交易的手续费
+        fee := MinReplacementFee(2, 2 * test.evicted)
+        if err := checkReplacement(fee, 2, replaced(test.evicted), direct); !errors.Is(err, test.want) { t.Errorf("%d evicted: got %v, want %v", test.evicted, err, test.want) }
+    }
+}
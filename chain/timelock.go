@@ -0,0 +1,84 @@
+package chain
+
+import (
+    "sort"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// block 及其之前共 medianTimeBlocks 个区块时间戳的中位数
+// 只能单调递增，不受单个矿工设置时间的影响
+func (bc *Blockchain) MedianTimePast(block *types.Block) (int64, error) {
+    var timestamps []int64
+
+    bci := &BlockchainIterator{block.Hash.Bytes(), bc.db}
+    for i := 0; i < types.MedianTimeBlocks; i++ {
+        b, err := bci.Next()
+        if err != nil { return 0, err }
+        timestamps = append(timestamps, b.Timestamp().Int64())
+
+        if (b.ParentHash() == common.Hash{}) { break }
+    }
+
+    return medianTime(timestamps), nil
+}
+
+// 已按高度排列的 blocks 中，blocks[i] 的父区块的 MedianTimePast，i 为 0 时为 0
+// 用于校验已经读入内存的区块头，blocks 需从创世区块开始
+func parentMedianTime(blocks []*types.Block, i int) int64 {
+    var timestamps []int64
+    for j := i - 1; j >= 0 && j >= i - types.MedianTimeBlocks; j-- {
+        timestamps = append(timestamps, blocks[j].Timestamp().Int64())
+    }
+    if len(timestamps) == 0 { return 0 }
+
+    return medianTime(timestamps)
+}
+
+func medianTime(timestamps []int64) int64 {
+    sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+    return timestamps[len(timestamps) / 2]
+}
+
+// 校验交易全部输入的相对时间锁
+// height 为交易将被写入的区块高度，medianTime 为其父区块的 MedianTimePast
+// 所花费输出属于 view 中的 pending 交易时，该交易将被写入同一区块，相对时间锁只能为 0
+func (bc *Blockchain) CheckSequenceLocks(tx *types.Transaction, view *TxView, height, medianTime int64) error {
+    if tx.IsCoinbase() { return nil }
+
+    for _, vin := range tx.Vin {
+        if vin.Sequence & types.SequenceLockTimeDisableFlag != 0 { continue }
+
+        value := int64(vin.Sequence & types.SequenceLockTimeMask)
+        if view.IsPending(vin.Txid) {
+            if value > 0 { return types.ErrSequenceLockNotMet }
+            continue
+        }
+
+        _, prevBlock, err := bc.FindTransactionWithBlock(vin.Txid)
+        if err != nil { return err }
+
+        if vin.Sequence & types.SequenceLockTimeTypeFlag != 0 {
+            // 以所花费输出所在区块的父区块的 MedianTimePast 为起点
+            coinTime := prevBlock.Timestamp().Int64()
+            if (prevBlock.ParentHash() != common.Hash{}) {
+                parent, err := bc.GetBlock(prevBlock.ParentHash().Bytes())
+                if err != nil { return err }
+                coinTime, err = bc.MedianTimePast(parent)
+                if err != nil { return err }
+            }
+            if coinTime + (value << types.SequenceLockTimeGranularity) > medianTime { return types.ErrSequenceLockNotMet }
+        } else {
+            if prevBlock.Number().Int64() + value > height { return types.ErrSequenceLockNotMet }
+        }
+    }
+    return nil
+}
+
+// 校验交易的绝对和相对时间锁
+func (bc *Blockchain) CheckTransactionLocks(tx *types.Transaction, view *TxView, height, medianTime int64) error {
+    if !tx.IsFinal(height, medianTime) { return types.ErrTransactionNotFinal }
+
+    return bc.CheckSequenceLocks(tx, view, height, medianTime)
+}
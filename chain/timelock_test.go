@@ -0,0 +1,121 @@
+package chain
+
+import (
+    "errors"
+    "testing"
+    "math/big"
+    "crypto/sha256"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 不经过挖矿连接一个时间戳为 timestamp 的区块，只包含奖励交易，用于不校验工作量证明的测试
+func connectTestBlock(t *testing.T, bc *Blockchain, parent *types.Block, timestamp int64, address string) *types.Block {
+    number := new(big.Int).Add(parent.Number(), big.NewInt(1))
+    header := &types.Header{ParentHash: parent.Hash, Number: number, Timestamp: big.NewInt(timestamp)}
+    block := &types.Block{Header: header, Transactions: []*types.Transaction{types.NewRewardTx(address, "", 0)}}
+    block.HashTransactions()
+    block.Hash = sha256.Sum256(append(parent.Hash.Bytes(), number.Bytes()...))
+
+    if err := bc.ConnectBlock(block); err != nil { t.Fatal(err) }
+    return block
+}
+
+func TestMedianTime(t *testing.T) {
+    tests := []struct {
+        timestamps []int64
+        want       int64
+    }{
+        {[]int64{5}, 5},
+        {[]int64{3, 1, 2}, 2},
+        {[]int64{1, 2, 3, 4}, 3},
+        {[]int64{9, 9, 1, 1, 5}, 5},
+    }
+
+    for _, test := range tests {
+        if got := medianTime(append([]int64{}, test.timestamps...)); got != test.want { t.Errorf("medianTime(%v) = %d, want %d", test.timestamps, got, test.want) }
+    }
+}
+
+// 只统计最近 MedianTimeBlocks 个区块，与读入内存的区块头计算的结果相同
+func TestMedianTimePast(t *testing.T) {
+    bc, _, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+
+    // 时间戳 genesis+1000, genesis+1..genesis+12，较早的大时间戳在 11 个区块之后不再计入
+    base := genesis.Timestamp().Int64()
+    blocks := []*types.Block{genesis}
+    parent := connectTestBlock(t, bc, genesis, base + 1000, address)
+    blocks = append(blocks, parent)
+    for i := int64(1); i <= 12; i++ {
+        parent = connectTestBlock(t, bc, parent, base + i, address)
+        blocks = append(blocks, parent)
+    }
+
+    tests := []struct {
+        height int
+        want   int64
+    }{
+        {0, base},
+        {1, base + 1000},
+        {2, base + 1},
+        {10, base + 5},
+        {11, base + 6},
+        {12, base + 6},
+        {13, base + 7},
+    }
+    for _, test := range tests {
+        got, err := bc.MedianTimePast(blocks[test.height])
+        if err != nil { t.Fatal(err) }
+        if got != test.want { t.Errorf("height %d: median time past %d, want %d", test.height, got, test.want) }
+        if got := parentMedianTime(blocks, test.height + 1); got != test.want { t.Errorf("height %d: parentMedianTime %d, want %d", test.height, got, test.want) }
+    }
+}
+
+// 相对时间锁：按区块数或按 512 秒计算，设置 SequenceLockTimeDisableFlag 时不生效
+func TestCheckSequenceLocks(t *testing.T) {
+    bc, _, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+
+    // 所花费的输出在高度 1，起始时间为其父区块（创世区块）的 MedianTimePast
+    base := genesis.Timestamp().Int64()
+    block := connectTestBlock(t, bc, genesis, base + 600, address)
+    coinbase := block.Transactions[0]
+
+    tests := []struct {
+        name       string
+        sequence   uint32
+        height     int64
+        medianTime int64
+        want       error
+    }{
+        {"final", types.SequenceFinal, 2, base, nil},
+        {"zero blocks", types.RelativeLockSequence(0, false), 2, base, nil},
+        {"height before maturity", types.RelativeLockSequence(5, false), 5, base, types.ErrSequenceLockNotMet},
+        {"height at maturity", types.RelativeLockSequence(5, false), 6, base, nil},
+        {"time before maturity", types.RelativeLockSequence(2, true), 100, base + 1023, types.ErrSequenceLockNotMet},
+        {"time at maturity", types.RelativeLockSequence(2, true), 100, base + 1024, nil},
+        {"time lock ignores height", types.RelativeLockSequence(2, true), 1000, base, types.ErrSequenceLockNotMet},
+        {"disabled", types.SequenceLockTimeDisableFlag | types.RelativeLockSequence(5, false), 2, base, nil},
+        {"disabled time lock", types.SequenceLockTimeDisableFlag | types.RelativeLockSequence(2, true), 2, base, nil},
+    }
+
+    for _, test := range tests {
+        tx := &types.Transaction{Vin: []types.TXInput{{Txid: coinbase.ID, Vout: 0, Sequence: test.sequence}}}
+        err := bc.CheckSequenceLocks(tx, NewTxView(bc), test.height, test.medianTime)
+        if !errors.Is(err, test.want) { t.Errorf("%s: got %v, want %v", test.name, err, test.want) }
+    }
+
+    // 花费同一区块内之前的交易时，相对时间锁只能为 0
+    view := NewTxView(bc)
+    parent := &types.Transaction{ID: []byte("parent"), Vout: []types.TXOutput{*types.NewTXOutput(1, address)}}
+    view.AddTransaction(parent)
+    for _, value := range []uint32{0, 1} {
+        tx := &types.Transaction{Vin: []types.TXInput{{Txid: parent.ID, Vout: 0, Sequence: types.RelativeLockSequence(value, false)}}}
+        var want error
+        if value > 0 { want = types.ErrSequenceLockNotMet }
+        if err := bc.CheckSequenceLocks(tx, view, 2, base); !errors.Is(err, want) { t.Errorf("pending parent, %d blocks: got %v, want %v", value, err, want) }
+    }
+}
@@ -0,0 +1,111 @@
+package chain
+
+import (
+    "fmt"
+    "errors"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+/*
+校验交易时可见的输出：
+    utxo 中的输出，以及尚未写入区块的 pending 交易的输出
+    pending 交易为同一区块内之前的交易，或者交易池中的交易
+    被 pending 交易花费的输出不再可见
+*/
+type TxView struct {
+    bc      *Blockchain
+    UTXOSet utxo.Set
+    pending map[string]*types.Transaction // 以 hex(txID) 索引
+    spent   map[string]bool         // 被 pending 交易花费的输出，以 outpointKey 索引
+}
+
+func NewTxView(bc *Blockchain) *TxView {
+    return &TxView{bc, bc.UTXOSet(), make(map[string]*types.Transaction), make(map[string]bool)}
+}
+
+// 复制一个 view，修改副本不影响原有的 view
+func (v *TxView) Copy() *TxView {
+    view := NewTxView(v.bc)
+    for txID, tx := range v.pending {
+        view.pending[txID] = tx
+    }
+    for key := range v.spent {
+        view.spent[key] = true
+    }
+
+    return view
+}
+
+// 加入一笔 pending 交易，其输入引用的输出被标记为已花费
+func (v *TxView) AddTransaction(tx *types.Transaction) {
+    v.pending[hex.EncodeToString(tx.ID)] = tx
+    for _, vin := range tx.Vin {
+        v.spent[types.OutpointKey(vin.Txid, vin.Vout)] = true
+    }
+}
+
+// 交易是否为尚未写入区块的 pending 交易
+func (v *TxView) IsPending(txID []byte) bool {
+    _, ok := v.pending[hex.EncodeToString(txID)]
+    return ok
+}
+
+// 根据位置找到一个可以被花费的输出，没有时返回 utxo.ErrMissingInput
+func (v *TxView) FindOutput(txID []byte, vout int) (types.TXOutput, error) {
+    missing := fmt.Errorf("%w: %s", utxo.ErrMissingInput, types.OutpointKey(txID, vout))
+    if v.spent[types.OutpointKey(txID, vout)] { return types.TXOutput{}, missing }
+
+    if tx, ok := v.pending[hex.EncodeToString(txID)]; ok {
+        if vout < 0 || vout >= len(tx.Vout) || tx.Vout[vout].IsUnspendable() { return types.TXOutput{}, missing }
+        return tx.Vout[vout], nil
+    }
+
+    return v.UTXOSet.FindOutput(txID, vout)
+}
+
+// 根据 ID 找到交易，pending 交易优先
+// 有未花费输出的交易由 utxo 构造，见 UTXOSet.PrevTransaction
+func (v *TxView) FindTransaction(txID []byte) (types.Transaction, error) {
+    if tx, ok := v.pending[hex.EncodeToString(txID)]; ok { return *tx, nil }
+    tx, err := v.UTXOSet.PrevTransaction(txID)
+    if err == nil { return tx, nil }
+    if !errors.Is(err, utxo.ErrMissingInput) { return types.Transaction{}, err }
+
+    return v.bc.FindTransaction(txID)
+}
+
+// 获取交易全部输入引用的上一笔交易
+// return map[txID]Transaction
+func (v *TxView) PrevTransactions(tx *types.Transaction) map[string]types.Transaction {
+    prevTXs := make(map[string]types.Transaction)
+
+    for _, vin := range tx.Vin {
+        prevTX, err := v.FindTransaction(vin.Txid)
+        if err != nil { continue }
+
+        prevTXs[hex.EncodeToString(prevTX.ID)] = prevTX
+    }
+
+    return prevTXs
+}
+
+// 计算交易的手续费，输入须可见
+// 输出金额为负数或总额溢出时返回错误，见 Transaction.OutputValue
+func (v *TxView) CalculateFee(tx *types.Transaction) (int, error) {
+    fee := 0
+    for _, vin := range tx.Vin {
+        prevTX, err := v.FindTransaction(vin.Txid)
+        if err != nil { return 0, err }
+        if vin.Vout < 0 || vin.Vout >= len(prevTX.Vout) { return 0, utxo.ErrMissingInput }
+
+        fee += prevTX.Vout[vin.Vout].Value
+    }
+
+    outputs, err := tx.OutputValue()
+    if err != nil { return 0, err }
+
+    return fee - outputs, nil
+}
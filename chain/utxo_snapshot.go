@@ -0,0 +1,269 @@
+package chain
+
+/*
+utxo 快照，与比特币的 assumeutxo 类似：
+    dumputxoset 将最新区块时的 utxo 及全部区块头写入文件，同一状态总是产生相同的文件
+    快照的 hash 为文件中之前全部内容的 sha256，写在文件末尾
+    loadutxoset 在新的数据目录中加载快照，快照之前的区块视为已修剪，可以立即校验和写入新区块
+        快照在校验之前标记为未校验 (Verified 为 false)
+    verifyutxoset 在临时数据库中重放 exportchain 导出的快照之前的区块，确认得到相同的快照 hash，
+        之后将快照标记为已校验；重放期间不占用数据库，其他命令可以同时使用区块链
+    校验不会自动进行：没有网络，快照之前的区块只能由用户另外提供导出的文件
+
+文件格式，整数为 varint，字节串之前写入 uvarint 长度：
+    magic | 区块 hash | 高度 | 区块头个数 | 区块头... | 交易个数 | (txID | 输出个数 | (序号 | 输出)...)... | 快照 hash
+    区块头按高度从低到高，包含区块 hash 和交易 ID，见 writeSnapshotHeader
+*/
+
+import (
+    "io"
+    "fmt"
+    "bytes"
+    "bufio"
+    "errors"
+    "io/ioutil"
+    "crypto/sha256"
+    "encoding/gob"
+    "encoding/hex"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/consensus"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+var snapshotMagic = []byte{0x53, 0x42, 0x55, 0x53} // "SBUS"
+
+// blocksBucket 中保存已加载快照的 key
+const snapshotName = "snapshot"
+
+var ErrSnapshotMismatch = errors.New("ERROR: UTXO snapshot does not match the blocks")
+
+// 快照的摘要，加载快照后保存在数据库中
+type UTXOSnapshot struct {
+    BlockHash []byte
+    Height    int64
+    Outputs   int
+    Hash      []byte
+    Verified  bool // 已通过重放区块确认
+}
+
+// 将最新区块时的 utxo 写入 w
+// 区块和 utxo 在同一个只读事务中读取，同时写入的新区块不会使二者不一致
+func (bc *Blockchain) WriteUTXOSnapshot(w io.Writer) (*UTXOSnapshot, error) {
+    h := sha256.New()
+    sw := utxo.NewSnapshotWriter(io.MultiWriter(w, h))
+    var snapshot *UTXOSnapshot
+
+    err := bc.db.View(func(tx *bolt.Tx) error {
+        blocks, err := readChainTx(tx)
+        if err != nil { return err }
+        tip := blocks[len(blocks) - 1]
+        snapshot = &UTXOSnapshot{tip.Hash.Bytes(), tip.Number().Int64(), 0, nil, true}
+
+        sw.Write(snapshotMagic)
+        sw.WriteBytes(snapshot.BlockHash)
+        sw.WriteVarint(snapshot.Height)
+
+        sw.WriteUvarint(uint64(len(blocks)))
+        for _, block := range blocks {
+            sw.WriteSnapshotHeader(block)
+        }
+
+        b := tx.Bucket([]byte(utxo.Bucket))
+        sw.WriteUvarint(uint64(b.Stats().KeyN))
+
+        // bolt 的 key 有序，输出按序号排列
+        return b.ForEach(func(k, v []byte) error {
+            outs, err := types.DeserializeOutputs(v)
+            if err != nil { return err }
+
+            sw.WriteBytes(k)
+            sw.WriteUvarint(uint64(len(outs.Outputs)))
+            for _, outIdx := range outs.Indexes() {
+                sw.WriteUvarint(uint64(outIdx))
+                sw.WriteSnapshotOutput(outs.Outputs[outIdx])
+                snapshot.Outputs++
+            }
+            return sw.Err
+        })
+    })
+    if err != nil { return nil, err }
+
+    snapshot.Hash = h.Sum(nil)
+    sw.Write(snapshot.Hash)
+
+    return snapshot, sw.Err
+}
+
+/*
+在新的数据库中加载快照
+    校验文件末尾的快照 hash，expected 不为 nil 时需与其相同
+    校验区块头的连接、时间戳和工作量证明，最后一个区块需为快照的区块
+    快照之前的区块以修剪后的形式保存，见 prune.go
+    快照区块带有 UTXORoot 时，加载的 utxo 需与其相同
+*/
+func LoadUTXOSnapshot(r io.Reader, expected []byte) (*UTXOSnapshot, error) {
+    sr := utxo.NewSnapshotReader(r)
+
+    if bytes.Compare(sr.Read(len(snapshotMagic)), snapshotMagic) != 0 { return nil, utxo.ErrInvalidSnapshot }
+    snapshot := &UTXOSnapshot{}
+    snapshot.BlockHash = sr.ReadBytes()
+    snapshot.Height = sr.ReadVarint()
+
+    var blocks []*types.Block
+    var parent *types.Block
+    count := sr.ReadUvarint()
+    for i := uint64(0); i < count && sr.Err == nil; i++ {
+        block := sr.ReadSnapshotHeader()
+        if sr.Err != nil { break }
+
+        if err := consensus.CheckBlockHeader(block, parent, parentMedianTime(blocks, len(blocks))); err != nil { return nil, blockError(block, err) }
+        blocks = append(blocks, block)
+        parent = block
+    }
+    if sr.Err != nil { return nil, sr.Err }
+    if parent == nil || parent.Number().Int64() != snapshot.Height || bytes.Compare(parent.Hash.Bytes(), snapshot.BlockHash) != 0 {
+        return nil, utxo.ErrInvalidSnapshot
+    }
+
+    utxos := make(map[string]types.TXOutputs)
+    count = sr.ReadUvarint()
+    for i := uint64(0); i < count && sr.Err == nil; i++ {
+        txID := sr.ReadBytes()
+        outs := types.TXOutputs{Outputs: make(map[int]types.TXOutput)}
+
+        n := sr.ReadUvarint()
+        for j := uint64(0); j < n && sr.Err == nil; j++ {
+            outIdx := int(sr.ReadUvarint())
+            outs.Outputs[outIdx] = sr.ReadSnapshotOutput()
+            snapshot.Outputs++
+        }
+        utxos[hex.EncodeToString(txID)] = outs
+    }
+    if sr.Err != nil { return nil, sr.Err }
+
+    snapshot.Hash = sr.Sum()
+    if bytes.Compare(sr.Read(len(snapshot.Hash)), snapshot.Hash) != 0 || sr.Err != nil { return nil, utxo.ErrInvalidSnapshot }
+    if expected != nil && bytes.Compare(expected, snapshot.Hash) != 0 { return nil, ErrSnapshotMismatch }
+
+    bc, err := NewEmptyBlockchain()
+    if err != nil { return nil, err }
+    defer bc.db.Close()
+
+    err = bc.db.Update(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(blocksBucket))
+        for _, block := range blocks {
+            data, err := block.Serialize()
+            if err != nil { return err }
+            if err := b.Put(block.Hash.Bytes(), data); err != nil { return err }
+        }
+        if err := b.Put([]byte(latestBlockName), snapshot.BlockHash); err != nil { return err }
+        if err := b.Put([]byte(prunedHeightName), common.Int64ToBytes(snapshot.Height)); err != nil { return err }
+
+        data, err := snapshot.Serialize()
+        if err != nil { return err }
+        if err := b.Put([]byte(snapshotName), data); err != nil { return err }
+
+        root, err := utxo.ReindexTx(tx, utxos)
+        if err != nil { return err }
+        if (parent.UTXORoot() != common.Hash{}) && root != parent.UTXORoot() { return ErrSnapshotMismatch }
+        return nil
+    })
+    if err != nil {
+        // 不保留不完整的数据库
+        bc.Remove()
+        return nil, err
+    }
+
+    return snapshot, nil
+}
+
+/*
+校验快照：在临时数据库中重放 blocks 中快照高度及之前的区块，
+得到的快照 hash 需与 snapshot 相同，不使用区块链的数据库
+*/
+func VerifyUTXOSnapshot(snapshot *UTXOSnapshot, blocks io.Reader) error {
+    scratch, err := openScratchBlockchain()
+    if err != nil { return err }
+    defer scratch.Remove()
+
+    var parent *types.Block
+    r := bufio.NewReader(blocks)
+    for {
+        block, err := ReadBootstrapBlock(r)
+        if err == io.EOF { break }
+        if err != nil { return err }
+        if block.Number().Int64() > snapshot.Height { break }
+
+        if err := scratch.ValidateBlock(block, parent); err != nil { return blockError(block, err) }
+        if err := scratch.ConnectBlock(block); err != nil { return blockError(block, err) }
+        parent = block
+    }
+    if parent == nil || bytes.Compare(parent.Hash.Bytes(), snapshot.BlockHash) != 0 {
+        return fmt.Errorf("%w, blocks do not reach height %d block %x", ErrSnapshotMismatch, snapshot.Height, snapshot.BlockHash)
+    }
+
+    replayed, err := scratch.WriteUTXOSnapshot(ioutil.Discard)
+    if err != nil { return err }
+    if bytes.Compare(replayed.Hash, snapshot.Hash) != 0 { return ErrSnapshotMismatch }
+
+    return nil
+}
+
+// 已加载的快照，没有时为 nil
+func (bc *Blockchain) LoadedSnapshot() (*UTXOSnapshot, error) {
+    var snapshot *UTXOSnapshot
+
+    err := bc.db.View(func(tx *bolt.Tx) error {
+        data := tx.Bucket([]byte(blocksBucket)).Get([]byte(snapshotName))
+        if data == nil { return nil }
+
+        var err error
+        snapshot, err = DeserializeUTXOSnapshot(data)
+        return err
+    })
+    if err != nil { return nil, err }
+
+    return snapshot, nil
+}
+
+// 记录快照已通过校验，见 VerifyUTXOSnapshot
+func (bc *Blockchain) MarkSnapshotVerified() error {
+    return bc.db.Update(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(blocksBucket))
+        data := b.Get([]byte(snapshotName))
+        if data == nil { return nil }
+
+        snapshot, err := DeserializeUTXOSnapshot(data)
+        if err != nil { return err }
+        snapshot.Verified = true
+
+        data, err = snapshot.Serialize()
+        if err != nil { return err }
+        return b.Put([]byte(snapshotName), data)
+    })
+}
+
+// Serialize serializes UTXOSnapshot
+func (snapshot UTXOSnapshot) Serialize() ([]byte, error) {
+    var buff bytes.Buffer
+
+    enc := gob.NewEncoder(&buff)
+    err := enc.Encode(snapshot)
+    if err != nil { return nil, err }
+
+    return buff.Bytes(), nil
+}
+
+// DeserializeUTXOSnapshot deserializes UTXOSnapshot
+func DeserializeUTXOSnapshot(data []byte) (*UTXOSnapshot, error) {
+    var snapshot UTXOSnapshot
+
+    dec := gob.NewDecoder(bytes.NewReader(data))
+    err := dec.Decode(&snapshot)
+    if err != nil { return nil, err }
+
+    return &snapshot, nil
+}
@@ -0,0 +1,66 @@
+package chain
+
+import (
+    "os"
+    "bytes"
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 导出 [0, to] 的区块
+func exportTestBlocks(t *testing.T, bc *Blockchain, to int64) *bytes.Buffer {
+    var buf bytes.Buffer
+    hashes, err := bc.BlockHashes(0, to)
+    if err != nil { t.Fatal(err) }
+    for _, hash := range hashes {
+        block, err := bc.GetBlock(hash)
+        if err != nil { t.Fatal(err) }
+        if err := WriteBootstrapBlock(&buf, block); err != nil { t.Fatal(err) }
+    }
+    return &buf
+}
+
+// 在新的数据库中加载快照，由导出的区块校验之后标记为已校验
+func TestLoadAndVerifyUTXOSnapshot(t *testing.T) {
+    bc, _, address := newTestBlockchain(t)
+    for i := 0; i < 2; i++ {
+        if _, err := bc.MineBlock(address, nil); err != nil { t.Fatal(err) }
+    }
+
+    var data bytes.Buffer
+    dumped, err := bc.WriteUTXOSnapshot(&data)
+    if err != nil { t.Fatal(err) }
+    blocks := exportTestBlocks(t, bc, 2).Bytes()
+    short := exportTestBlocks(t, bc, 1).Bytes()
+    bc.Close()
+
+    // 快照 hash 与期望的不同时不加载
+    if _, err := LoadUTXOSnapshot(bytes.NewReader(data.Bytes()), make([]byte, len(dumped.Hash))); !errors.Is(err, ErrSnapshotMismatch) { t.Fatalf("wrong hash: got %v, want %v", err, ErrSnapshotMismatch) }
+
+    if err := os.Remove(dbFile); err != nil { t.Fatal(err) }
+    loaded, err := LoadUTXOSnapshot(bytes.NewReader(data.Bytes()), dumped.Hash)
+    if err != nil { t.Fatal(err) }
+    if loaded.Height != 2 || loaded.Outputs != dumped.Outputs || loaded.Verified { t.Fatalf("loaded %+v, dumped %+v", loaded, dumped) }
+
+    bc, err = NewBlockchain()
+    if err != nil { t.Fatal(err) }
+    defer bc.Close()
+    balance, err := bc.UTXOSet().GetBalance(address)
+    if err != nil { t.Fatal(err) }
+    if balance != 3 * types.Subsidy { t.Fatalf("balance %d, want %d", balance, 3 * types.Subsidy) }
+    if err := bc.CheckBlocksAvailable(2); !errors.Is(err, ErrBlockPruned) { t.Fatalf("blocks before the snapshot: got %v, want %v", err, ErrBlockPruned) }
+
+    // 区块没有到达快照的高度时不能确认快照
+    if err := VerifyUTXOSnapshot(loaded, bytes.NewReader(short)); !errors.Is(err, ErrSnapshotMismatch) { t.Fatalf("missing blocks: got %v, want %v", err, ErrSnapshotMismatch) }
+
+    if err := VerifyUTXOSnapshot(loaded, bytes.NewReader(blocks)); err != nil { t.Fatal(err) }
+    if err := bc.MarkSnapshotVerified(); err != nil { t.Fatal(err) }
+    snapshot, err := bc.LoadedSnapshot()
+    if err != nil { t.Fatal(err) }
+    if !snapshot.Verified || !bytes.Equal(snapshot.Hash, dumped.Hash) { t.Fatalf("snapshot %+v after verification", snapshot) }
+
+    // 加载快照之后可以立即写入新区块
+    if _, err := bc.MineBlock(address, nil); err != nil { t.Fatal(err) }
+}
@@ -0,0 +1,238 @@
+package chain
+
+/*
+校验数据库中区块链的完整性，与比特币的 verifychain 类似，level 越高校验越多：
+    0: 区块可以读取，父区块 hash 和高度，工作量证明
+    1: 奖励交易的位置，重复交易，merkle root
+    2: 从创世区块重放，校验输入、签名、时间锁和奖励金额
+    3: 将重放得到的 utxo 与 chainstate、地址索引和 utxo 承诺比较
+depth 为校验最新的多少个区块，0 为全部
+被修剪的区块只校验区块头，区块被修剪后 level 2 以上不可用
+重放需要重建 utxo，depth 之外的区块也会被重放，但只确认输入存在
+*/
+
+import (
+    "fmt"
+    "sort"
+    "bytes"
+    "errors"
+    "reflect"
+    "encoding/gob"
+    "encoding/hex"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/consensus"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+const (
+    MinVerifyLevel     = 0
+    MaxVerifyLevel     = 3
+    DefaultVerifyLevel = 3
+)
+
+var ErrUTXOSetMismatch = errors.New("ERROR: UTXO set does not match the blocks")
+
+// 发现第一处错误时返回，错误包含出错区块的高度和 hash
+// 返回被校验的区块数
+func (bc *Blockchain) VerifyChain(level int, depth int64) (int, error) {
+    blocks, err := bc.readChain()
+    if err != nil { return 0, err }
+
+    checkFrom := int64(0)
+    if depth > 0 && depth < int64(len(blocks)) { checkFrom = int64(len(blocks)) - depth }
+
+    // 被修剪的区块只能校验区块头，重放需要全部区块的交易
+    pruned, err := bc.PruneHeight()
+    if err != nil { return 0, err }
+    if level >= 2 {
+        if err := bc.CheckBlocksAvailable(0); err != nil { return 0, err }
+    }
+
+    var parent *types.Block
+    for i, block := range blocks {
+        if block.Number().Int64() >= checkFrom {
+            if err := consensus.CheckBlockHeader(block, parent, parentMedianTime(blocks, i)); err != nil { return 0, blockError(block, err) }
+            if level >= 1 && block.Number().Int64() > pruned {
+                if err := consensus.CheckBlockTransactions(block); err != nil { return 0, blockError(block, err) }
+            }
+        }
+        parent = block
+    }
+
+    if level >= 2 {
+        if err := bc.replayChain(blocks, checkFrom, level >= 3); err != nil { return 0, err }
+    }
+
+    return len(blocks) - int(checkFrom), nil
+}
+
+func blockError(block *types.Block, err error) error {
+    return fmt.Errorf("%w, at height %d block %x", err, block.Number(), block.Hash)
+}
+
+// 从最新区块读取到创世区块，按高度从低到高返回
+// 区块不存在、数据损坏或高度不连续时返回错误
+func (bc *Blockchain) readChain() ([]*types.Block, error) {
+    var blocks []*types.Block
+
+    err := bc.db.View(func(tx *bolt.Tx) error {
+        var err error
+        blocks, err = readChainTx(tx)
+        return err
+    })
+
+    return blocks, err
+}
+
+// 在事务 tx 中读取区块链，见 readChain
+func readChainTx(tx *bolt.Tx) ([]*types.Block, error) {
+    hash := tx.Bucket([]byte(blocksBucket)).Get([]byte(latestBlockName))
+    if hash == nil { return nil, errors.New("ERROR: No latest block") }
+
+    var blocks []*types.Block
+    var child *types.Block
+    for {
+        block, err := getBlockTx(tx, hash)
+        if err != nil { return nil, fmt.Errorf("%w, block %x", err, hash) }
+        if bytes.Compare(block.Hash.Bytes(), hash) != 0 { return nil, fmt.Errorf("%w, block %x", types.ErrInvalidBlockData, hash) }
+
+        // 高度逐个递减，损坏的父区块 hash 不会造成循环
+        if child != nil && block.Number().Int64() + 1 != child.Number().Int64() { return nil, blockError(child, consensus.ErrInvalidBlockHeader) }
+        blocks = append(blocks, block)
+
+        if (block.ParentHash() == common.Hash{}) { break }
+        if block.Number().Sign() <= 0 { return nil, blockError(block, consensus.ErrInvalidBlockHeader) }
+
+        child = block
+        hash = block.ParentHash().Bytes()
+    }
+
+    for i, j := 0, len(blocks) - 1; i < j; i, j = i + 1, j - 1 {
+        blocks[i], blocks[j] = blocks[j], blocks[i]
+    }
+    return blocks, nil
+}
+
+// 在临时数据库中重放区块，重建 utxo
+// 高度不低于 checkFrom 的区块完整校验，见 ValidateBlock
+func (bc *Blockchain) replayChain(blocks []*types.Block, checkFrom int64, compareUTXO bool) error {
+    scratch, err := openScratchBlockchain()
+    if err != nil { return err }
+    defer scratch.Remove()
+
+    var parent *types.Block
+    for _, block := range blocks {
+        if block.Number().Int64() >= checkFrom {
+            err = scratch.ValidateBlock(block, parent)
+        } else {
+            err = checkBlockInputsExist(block, NewTxView(scratch))
+        }
+        if err != nil { return blockError(block, err) }
+
+        if err := scratch.ConnectBlock(block); err != nil { return blockError(block, err) }
+        parent = block
+    }
+
+    if !compareUTXO { return nil }
+    want, err := scratch.UTXOSet().StateRoot()
+    if err != nil { return err }
+    got, err := bc.UTXOSet().StateRoot()
+    if err != nil { return err }
+    if want != got { return ErrUTXOSetMismatch }
+    if err := compareUTXOSets(scratch.db, bc.db); err != nil { return err }
+    return compareAddrIndexes(scratch.db, bc.db)
+}
+
+// 区块内交易的输入均可花费，不校验签名等
+func checkBlockInputsExist(block *types.Block, view *TxView) error {
+    for _, tx := range block.Transactions {
+        if tx.IsCoinbase() { continue }
+
+        for _, vin := range tx.Vin {
+            if _, err := view.FindOutput(vin.Txid, vin.Vout); err != nil { return err }
+        }
+        view.AddTransaction(tx)
+    }
+    return nil
+}
+
+// 比较重建的 utxo 与数据库中的 chainstate
+func compareUTXOSets(expected, actual *bolt.DB) error {
+    want, err := readUTXOSet(expected)
+    if err != nil { return err }
+    got, err := readUTXOSet(actual)
+    if err != nil { return err }
+
+    var keys []string
+    for key := range want {
+        keys = append(keys, key)
+    }
+    for key := range got {
+        if _, ok := want[key]; !ok { keys = append(keys, key) }
+    }
+    sort.Strings(keys)
+
+    for _, key := range keys {
+        if !reflect.DeepEqual(want[key], got[key]) {
+            return fmt.Errorf("%w, outputs of transaction %s", ErrUTXOSetMismatch, key)
+        }
+    }
+    return nil
+}
+
+// 比较重建的地址索引与数据库中的地址索引
+func compareAddrIndexes(expected, actual *bolt.DB) error {
+    want, err := readAddrIndex(expected)
+    if err != nil { return err }
+    got, err := readAddrIndex(actual)
+    if err != nil { return err }
+
+    for key := range want {
+        if !got[key] { return fmt.Errorf("%w, address index is missing %s", ErrUTXOSetMismatch, key) }
+    }
+    for key := range got {
+        if !want[key] { return fmt.Errorf("%w, address index has extra %s", ErrUTXOSetMismatch, key) }
+    }
+    return nil
+}
+
+func readAddrIndex(db *bolt.DB) (map[string]bool, error) {
+    keys := make(map[string]bool)
+
+    err := db.View(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(utxo.AddrIndexBucket))
+        if b == nil { return fmt.Errorf("%w, no %s bucket", ErrUTXOSetMismatch, utxo.AddrIndexBucket) }
+
+        return b.ForEach(func(k, v []byte) error {
+            keys[hex.EncodeToString(k)] = true
+            return nil
+        })
+    })
+
+    return keys, err
+}
+
+// 读取 chainstate 中的全部输出，以 hex(txID) 索引
+func readUTXOSet(db *bolt.DB) (map[string]types.TXOutputs, error) {
+    utxos := make(map[string]types.TXOutputs)
+
+    err := db.View(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(utxo.Bucket))
+        if b == nil { return fmt.Errorf("%w, no %s bucket", ErrUTXOSetMismatch, utxo.Bucket) }
+
+        return b.ForEach(func(k, v []byte) error {
+            var outputs types.TXOutputs
+
+            err := gob.NewDecoder(bytes.NewReader(v)).Decode(&outputs)
+            if err != nil { return fmt.Errorf("%w, outputs of transaction %x: %v", ErrUTXOSetMismatch, k, err) }
+
+            utxos[hex.EncodeToString(k)] = outputs
+            return nil
+        })
+    })
+
+    return utxos, err
+}
@@ -0,0 +1,140 @@
+package chain
+
+import (
+    "time"
+    "errors"
+    "strings"
+    "testing"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/consensus"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+// 区块 1 没有工作量证明，只有 depth 不包括它时才能通过校验
+func TestVerifyChain(t *testing.T) {
+    bc, privKey, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+
+    connectTestBlock(t, bc, genesis, time.Now().Unix(), address)
+    if _, err := bc.MineBlock(address, []*types.Transaction{newTestSpend(t, bc, genesis.Transactions[0], privKey, address, 0)}); err != nil { t.Fatal(err) }
+    if _, err := bc.MineBlock(address, nil); err != nil { t.Fatal(err) }
+
+    tests := []struct {
+        level int
+        depth int64
+        want  int
+        err   error
+    }{
+        {0, 0, 0, consensus.ErrInvalidProofOfWork},
+        {0, 3, 0, consensus.ErrInvalidProofOfWork},
+        {3, 4, 0, consensus.ErrInvalidProofOfWork},
+        {0, 2, 2, nil},
+        {1, 2, 2, nil},
+        {2, 2, 2, nil},
+        {3, 2, 2, nil},
+        {3, 1, 1, nil},
+    }
+    for _, test := range tests {
+        checked, err := bc.VerifyChain(test.level, test.depth)
+        if !errors.Is(err, test.err) { t.Errorf("VerifyChain(%d, %d): got error %v, want %v", test.level, test.depth, err, test.err); continue }
+        if checked != test.want { t.Errorf("VerifyChain(%d, %d) checked %d blocks, want %d", test.level, test.depth, checked, test.want) }
+    }
+}
+
+// 在一个写事务中修改 bucket，corrupt 返回被修改的 key 和原来的值，值为 nil 时 key 原来不存在
+// 返回恢复修改的函数
+func corruptTestBucket(t *testing.T, bc *Blockchain, bucket string, corrupt func(b *bolt.Bucket) (key, value []byte)) func() {
+    var key, value []byte
+    err := bc.db.Update(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(bucket))
+        k, v := corrupt(b)
+        key = append([]byte{}, k...)
+        if v != nil { value = append([]byte{}, v...) }
+        return nil
+    })
+    if err != nil { t.Fatal(err) }
+
+    return func() {
+        err := bc.db.Update(func(tx *bolt.Tx) error {
+            b := tx.Bucket([]byte(bucket))
+            if value == nil { return b.Delete(key) }
+            return b.Put(key, value)
+        })
+        if err != nil { t.Fatal(err) }
+    }
+}
+
+// chainstate 或地址索引与区块不一致时只有 level 3 发现，区块的交易被修改时 level 1 发现
+func TestVerifyChainCorruption(t *testing.T) {
+    bc, privKey, address := newTestBlockchain(t)
+    genesis, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    block, err := bc.MineBlock(address, []*types.Transaction{newTestSpend(t, bc, genesis.Transactions[0], privKey, address, 0)})
+    if err != nil { t.Fatal(err) }
+
+    tests := []struct {
+        name    string
+        bucket  string
+        corrupt func(b *bolt.Bucket) ([]byte, []byte)
+        level   int // 最低发现错误的 level
+        err     error
+        message string
+    }{
+        {"output value", utxo.Bucket, func(b *bolt.Bucket) ([]byte, []byte) {
+            k, v := b.Cursor().First()
+            outputs, err := types.DeserializeOutputs(v)
+            if err != nil { t.Fatal(err) }
+            for i, out := range outputs.Outputs {
+                out.Value++
+                outputs.Outputs[i] = out
+            }
+            data, err := outputs.Serialize()
+            if err != nil { t.Fatal(err) }
+            if err := b.Put(k, data); err != nil { t.Fatal(err) }
+            return k, v
+        }, 3, ErrUTXOSetMismatch, ""},
+        {"missing address index entry", utxo.AddrIndexBucket, func(b *bolt.Bucket) ([]byte, []byte) {
+            k, v := b.Cursor().First()
+            if err := b.Delete(k); err != nil { t.Fatal(err) }
+            return k, v
+        }, 3, ErrUTXOSetMismatch, "address index is missing"},
+        {"extra address index entry", utxo.AddrIndexBucket, func(b *bolt.Bucket) ([]byte, []byte) {
+            k := []byte("extra")
+            if err := b.Put(k, []byte{}); err != nil { t.Fatal(err) }
+            return k, nil
+        }, 3, ErrUTXOSetMismatch, "address index has extra"},
+        {"block transaction", blocksBucket, func(b *bolt.Bucket) ([]byte, []byte) {
+            k := block.Hash.Bytes()
+            v := b.Get(k)
+            stored, err := types.DeserializeBlock(v)
+            if err != nil { t.Fatal(err) }
+            // 交易 ID 与内容一致，但与区块头中的 merkle root 不一致
+            coinbase := stored.Transactions[0]
+            coinbase.Vout[0].Value++
+            coinbase.ID = coinbase.UnsignedHash()
+            data, err := stored.Serialize()
+            if err != nil { t.Fatal(err) }
+            if err := b.Put(k, data); err != nil { t.Fatal(err) }
+            return k, v
+        }, 1, consensus.ErrInvalidMerkleRoot, ""},
+    }
+
+    for _, test := range tests {
+        restore := corruptTestBucket(t, bc, test.bucket, test.corrupt)
+
+        for level := MinVerifyLevel; level <= MaxVerifyLevel; level++ {
+            _, err := bc.VerifyChain(level, 0)
+            if level < test.level {
+                if err != nil { t.Errorf("%s: level %d: %v", test.name, level, err) }
+                continue
+            }
+            if !errors.Is(err, test.err) || !strings.Contains(err.Error(), test.message) { t.Errorf("%s: level %d: got %v, want %v %s", test.name, level, err, test.err, test.message) }
+        }
+
+        restore()
+        if _, err := bc.VerifyChain(MaxVerifyLevel, 0); err != nil { t.Fatalf("%s: restored chain: %v", test.name, err) }
+    }
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+func (cli *CLI) accounts() {
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    accounts := wallets.GetAddresses()
+
+    for _, account := range accounts {
+        fmt.Printf("%v, ", account)
+    }
+    fmt.Println()
+
+    watchOnly := wallets.GetWatchOnlyAddresses()
+    if accounts == nil { accounts = []string{} }
+    if watchOnly == nil { watchOnly = []string{} }
+    cli.setResult(struct {
+        Accounts  []string `json:"accounts"`
+        WatchOnly []string `json:"watch_only"`
+    }{accounts, watchOnly})
+}
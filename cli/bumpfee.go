@@ -0,0 +1,38 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 用手续费更高的交易替换交易池中的交易
+func (cli *CLI) bumpFee(txID string, fee int) {
+    id, err := hex.DecodeString(txID)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    mempool := bc.Mempool()
+    entry, ok, err := mempool.Get(id)
+    cli.check(err)
+    if !ok { cli.fail(ExitError, wallet.ErrNotInMempool) }
+
+    tx, err := wallet.NewBumpFeeTransaction(id, fee, bc)
+    cli.check(err)
+
+    err = mempool.Add(tx)
+    cli.check(err)
+
+    replacement, _, err := mempool.Get(tx.ID)
+    cli.check(err)
+    fmt.Printf("Replaced %x with %x, fee %d -> %d\n", id, tx.ID, entry.Fee, replacement.Fee)
+    cli.setResult(struct {
+        Replaced string `json:"replaced"`
+        OldFee   int    `json:"old_fee"`
+        Fee      int    `json:"fee"`
+        TxResultJSON
+    }{txID, entry.Fee, replacement.Fee, NewTxResultJSON(tx, nil)})
+}
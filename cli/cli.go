@@ -0,0 +1,709 @@
+package cli
+
+import (
+    "os"
+    "fmt"
+    "flag"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// init with a blockchain
+// json 时输出 JSON 结果，见 json.go
+type CLI struct {
+    json   bool
+    stdout *os.File
+    result interface{}
+}
+
+const usage = `
+Usage: COMMAND [-json] [OPTIONS]
+  -json may appear anywhere on the command line. stdout then holds a single JSON
+  object and nothing else: the result of the command on success, or
+  {"error": {"message": MESSAGE, "exit_code": N}} on failure. Hashes, txids, keys
+  and signatures are hex, times are unix timestamps; fields are only ever added.
+  Commands that create a transaction return
+  {"txid", "status": "mined"|"mempool"|"signed"|"unsigned", "block": {"hash", "height"}, "hex"},
+  with block only when mined and hex only when signed or unsigned.
+  Exit codes, with or without -json:
+    0  success
+    1  error, e.g. insufficient funds, invalid transaction, unreadable file
+    2  invalid arguments, e.g. missing flag, unknown command, invalid address or amount
+    3  check failed: verifychain, verifymessage, verifyutxoproof, verifyutxoset,
+       verifynotarization not found, estimatefee without enough data
+
+  printchain                             print all the blocks of the blockchain
+  createchain -account ACCOUNT      Create a blockchain and send genesis block reward to ACCOUNT
+  createwallet                           Generates a new key-pair and saves it into the wallet file
+  accounts                               Lists all accounts
+  getbalance [-account ACCOUNT]          Get balance of ACCOUNT, or of the whole wallet
+  send [-from FROM] -to TO -amount AMOUNT [-fee FEE] [-locktime LOCKTIME] [-relativelock BLOCKS | -relativetime SECONDS] [-strategy bnb|largest|smallest|random] [-rbf] [-mempool] [-raw] [-unsigned]
+                                         Send AMOUNT of coins from FROM account to TO,
+                                         paying FEE per input and output, by default the fee
+                                         estimated for 6 blocks. Without FROM, spend
+                                         from all accounts and send change to a new account.
+                                         LOCKTIME is a block height or unix time before which
+                                         the transaction waits in the mempool.
+                                         BLOCKS or SECONDS (rounded up to 512) is how long
+                                         it waits after the spent outputs are mined.
+                                         With -rbf, allow replacing it with bumpfee.
+                                         With -mempool, leave it in the mempool for mine.
+                                         With -raw, print the signed transaction without sending it.
+                                         With -unsigned, print the transaction for external signing
+  sendmany [-from FROM[,FROM...]] (-to TO:AMOUNT[,TO:AMOUNT...] | -file FILE) [-fee FEE] [-locktime LOCKTIME] [-relativelock BLOCKS | -relativetime SECONDS] [-strategy STRATEGY] [-rbf] [-mempool] [-raw] [-unsigned]
+                                         Pay several recipients in one transaction, funded by
+                                         the FROM accounts (default all accounts), paying FEE
+                                         per input and output (default estimated).
+                                         FILE is CSV (address,amount) or JSON
+  mine -miner MINER                      Mine a block with the ready mempool transactions
+  bumpfee -txid TXID [-fee FEE]          Replace a replaceable mempool transaction with one
+                                         paying FEE per input and output from the same inputs,
+                                         taking the extra fee from its change
+  cpfp (-txid TXID | -parent HEX) [-fee FEE]
+                                         Spend the wallet outputs of a parent transaction so that
+                                         parent and child together pay FEE per input and output
+  submitpackage -file FILE               Add the hex transactions in FILE, parents first, to the
+                                         mempool; their combined fee must clear the minimum
+  listunspent [-address ADDRESS] [-minconf MINCONF]
+                                         List unspent outputs of ADDRESS, or of the whole wallet,
+                                         with at least MINCONF confirmations (default 1)
+  createrawtransaction -inputs TXID:VOUT[,TXID:VOUT...] -outputs TO:AMOUNT[,TO:AMOUNT...] [-locktime LOCKTIME] [-relativelock BLOCKS | -relativetime SECONDS] [-rbf]
+                                         Print an unsigned transaction spending exactly the given
+                                         outputs; inputs minus outputs is the fee
+  signrawtransaction -hex HEX            Sign a transaction with the wallet keys
+  decoderawtransaction -hex HEX          Print a transaction in readable form
+  sendrawtransaction -hex HEX            Check a signed transaction and add it to the mempool
+  listmempool                            List mempool transactions with their ancestors and descendants
+  estimatefee -blocks BLOCKS             Estimate the fee per input and output for confirmation
+                                         within BLOCKS blocks, from recent mempool confirmations
+  exportchain -file FILE [-from HEIGHT] [-to HEIGHT]
+                                         Write blocks from HEIGHT to HEIGHT (default the whole
+                                         chain) to FILE in height order
+  importchain -file FILE                 Validate and add the blocks in FILE, creating the
+                                         blockchain if there is none
+  dumputxoset -file FILE                 Write the UTXO set at the latest block to FILE and print its hash
+  loadutxoset -file FILE [-hash HASH]    Start a new blockchain from the UTXO snapshot in FILE,
+                                         checking its hash against HASH if given
+  verifyutxoset -blocks FILE             Replay the blocks in FILE, written by exportchain, to verify
+                                         the loaded UTXO snapshot; not run automatically, other
+                                         commands can use the blockchain while it runs
+  getutxoproof -outpoint TXID:VOUT       Print a proof that the output is, or is not, in the UTXO set
+                                         committed to by the latest block
+  verifyutxoproof -proof HEX [-root HASH]
+                                         Check a proof from getutxoproof against HASH (default the
+                                         UTXO root of the latest block)
+  pruneblockchain -prune DEPTH|SIZE      Keep only the latest DEPTH blocks, or the latest blocks up to
+                                         SIZE (e.g. 10MB), pruning older ones after each new block;
+                                         0 turns pruning off
+  verifychain [-level LEVEL] [-depth DEPTH]
+                                         Check the latest DEPTH blocks (default all): 0 headers
+                                         and PoW, 1 merkle roots, 2 signatures and rewards,
+                                         3 the UTXO set (default)
+  listtransactions [-account ACCOUNT] [-count COUNT]
+                                         List the latest COUNT wallet transactions
+  rescan [-from HEIGHT]                  Rebuild wallet transactions from blocks since HEIGHT
+  dumpprivkey -address ADDRESS           Print the private key of ADDRESS in WIF
+  importprivkey -key KEY                 Import a WIF private key into the wallet and rescan
+  dumpwallet -file FILE                  Write all private keys of the wallet to FILE
+  importwallet -file FILE                Import all private keys in FILE and rescan
+  importaddress (-address ADDRESS | -pubkey PUBKEY)
+                                         Watch ADDRESS without its private key and rescan
+  signmessage -address ADDRESS -message MESSAGE
+                                         Sign MESSAGE with the private key of ADDRESS
+  verifymessage -address ADDRESS -signature SIGNATURE -message MESSAGE
+                                         Verify that SIGNATURE of MESSAGE was made by ADDRESS
+  initiate -from FROM -to TO -amount AMOUNT [-timeout BLOCKS] [-fee FEE]
+                                         Start an atomic swap: generate a secret and lock AMOUNT
+                                         in a contract TO can redeem with it, refundable to FROM
+                                         after BLOCKS blocks
+  participate -from FROM -to TO -amount AMOUNT -secrethash HASH [-timeout BLOCKS] [-fee FEE]
+                                         Join an atomic swap with a contract locked on HASH,
+                                         using a shorter timeout than the initiator
+  redeem -contract TXID:VOUT -secret SECRET [-fee FEE]
+                                         Spend a contract with its secret
+  refund -contract TXID:VOUT [-fee FEE]  Spend a contract back to its creator after the timeout
+  extractsecret -contract TXID:VOUT      Print the secret revealed by the redeem of a contract
+  notarize -file FILE [-from FROM] [-fee FEE]
+                                         Anchor the sha256 of FILE in an unspendable data output
+  verifynotarization -file FILE          Print the block and time FILE was notarized in
+`
+
+func (cli *CLI) Run() {
+    cli.parseGlobalFlags()
+    defer cli.recoverError()
+    cli.validateArgs()
+
+    // NewFlagSet  f func(name string, errorHandling flag.ErrorHandling) *flag.FlagSet
+    printChainCmd := flag.NewFlagSet("printchain", flag.ContinueOnError)
+    createChainCmd := flag.NewFlagSet("createchain", flag.ContinueOnError)
+    createWalletCmd := flag.NewFlagSet("createwallet", flag.ContinueOnError)
+    accountsCmd := flag.NewFlagSet("accounts", flag.ContinueOnError)
+    getBalanceCmd := flag.NewFlagSet("getbalance", flag.ContinueOnError)
+    sendCmd := flag.NewFlagSet("send", flag.ContinueOnError)
+    sendManyCmd := flag.NewFlagSet("sendmany", flag.ContinueOnError)
+    mineCmd := flag.NewFlagSet("mine", flag.ContinueOnError)
+    bumpFeeCmd := flag.NewFlagSet("bumpfee", flag.ContinueOnError)
+    cpfpCmd := flag.NewFlagSet("cpfp", flag.ContinueOnError)
+    submitPackageCmd := flag.NewFlagSet("submitpackage", flag.ContinueOnError)
+    listUnspentCmd := flag.NewFlagSet("listunspent", flag.ContinueOnError)
+    createRawTransactionCmd := flag.NewFlagSet("createrawtransaction", flag.ContinueOnError)
+    signRawTransactionCmd := flag.NewFlagSet("signrawtransaction", flag.ContinueOnError)
+    decodeRawTransactionCmd := flag.NewFlagSet("decoderawtransaction", flag.ContinueOnError)
+    sendRawTransactionCmd := flag.NewFlagSet("sendrawtransaction", flag.ContinueOnError)
+    listMempoolCmd := flag.NewFlagSet("listmempool", flag.ContinueOnError)
+    estimateFeeCmd := flag.NewFlagSet("estimatefee", flag.ContinueOnError)
+    exportChainCmd := flag.NewFlagSet("exportchain", flag.ContinueOnError)
+    importChainCmd := flag.NewFlagSet("importchain", flag.ContinueOnError)
+    verifyChainCmd := flag.NewFlagSet("verifychain", flag.ContinueOnError)
+    pruneBlockchainCmd := flag.NewFlagSet("pruneblockchain", flag.ContinueOnError)
+    dumpUTXOSetCmd := flag.NewFlagSet("dumputxoset", flag.ContinueOnError)
+    loadUTXOSetCmd := flag.NewFlagSet("loadutxoset", flag.ContinueOnError)
+    verifyUTXOSetCmd := flag.NewFlagSet("verifyutxoset", flag.ContinueOnError)
+    getUTXOProofCmd := flag.NewFlagSet("getutxoproof", flag.ContinueOnError)
+    verifyUTXOProofCmd := flag.NewFlagSet("verifyutxoproof", flag.ContinueOnError)
+    listTransactionsCmd := flag.NewFlagSet("listtransactions", flag.ContinueOnError)
+    rescanCmd := flag.NewFlagSet("rescan", flag.ContinueOnError)
+    dumpPrivKeyCmd := flag.NewFlagSet("dumpprivkey", flag.ContinueOnError)
+    importPrivKeyCmd := flag.NewFlagSet("importprivkey", flag.ContinueOnError)
+    dumpWalletCmd := flag.NewFlagSet("dumpwallet", flag.ContinueOnError)
+    importWalletCmd := flag.NewFlagSet("importwallet", flag.ContinueOnError)
+    importAddressCmd := flag.NewFlagSet("importaddress", flag.ContinueOnError)
+    signMessageCmd := flag.NewFlagSet("signmessage", flag.ContinueOnError)
+    verifyMessageCmd := flag.NewFlagSet("verifymessage", flag.ContinueOnError)
+    initiateCmd := flag.NewFlagSet("initiate", flag.ContinueOnError)
+    participateCmd := flag.NewFlagSet("participate", flag.ContinueOnError)
+    redeemCmd := flag.NewFlagSet("redeem", flag.ContinueOnError)
+    refundCmd := flag.NewFlagSet("refund", flag.ContinueOnError)
+    extractSecretCmd := flag.NewFlagSet("extractsecret", flag.ContinueOnError)
+    notarizeCmd := flag.NewFlagSet("notarize", flag.ContinueOnError)
+    verifyNotarizationCmd := flag.NewFlagSet("verifynotarization", flag.ContinueOnError)
+
+    // flag.FlagSet.String  f func(name string, value string, usage string) *string
+    createChainData := createChainCmd.String("account", "", "The account to send genesis block reward to")
+    getBalanceData := getBalanceCmd.String("account", "", "The account to get balance for, default the whole wallet")
+    sendFrom := sendCmd.String("from", "", "Source wallet account, default all accounts")
+    sendTo := sendCmd.String("to", "", "Destination wallet account")
+    sendAmount := sendCmd.Int("amount", 0, "Amount to send")
+    sendFee := sendCmd.Int("fee", -1, "Fee to pay per input and output, default estimated")
+    sendStrategy := sendCmd.String("strategy", "bnb", "Coin selection strategy: bnb, largest, smallest or random")
+    sendLockTime := sendCmd.Int64("locktime", 0, "Block height or unix time the transaction is locked until")
+    sendRelativeLock := sendCmd.Int64("relativelock", 0, "Number of blocks the spent outputs must be mined for, at most 65535")
+    sendRelativeTime := sendCmd.Int64("relativetime", 0, "Seconds the spent outputs must be mined for, rounded up to 512")
+    sendReplaceable := sendCmd.Bool("rbf", false, "Allow the transaction to be replaced by one paying a higher fee")
+    sendQueue := sendCmd.Bool("mempool", false, "Add the transaction to the mempool instead of mining it")
+    sendRaw := sendCmd.Bool("raw", false, "Print the signed transaction instead of sending it")
+    sendUnsigned := sendCmd.Bool("unsigned", false, "Print the unsigned transaction instead of sending it")
+    sendManyFrom := sendManyCmd.String("from", "", "Comma separated source wallet accounts, default all accounts")
+    sendManyTo := sendManyCmd.String("to", "", "Comma separated ACCOUNT:AMOUNT pairs")
+    sendManyFile := sendManyCmd.String("file", "", "CSV or JSON file of recipients")
+    sendManyFee := sendManyCmd.Int("fee", -1, "Fee to pay per input and output, default estimated")
+    sendManyStrategy := sendManyCmd.String("strategy", "bnb", "Coin selection strategy: bnb, largest, smallest or random")
+    sendManyLockTime := sendManyCmd.Int64("locktime", 0, "Block height or unix time the transaction is locked until")
+    sendManyRelativeLock := sendManyCmd.Int64("relativelock", 0, "Number of blocks the spent outputs must be mined for, at most 65535")
+    sendManyRelativeTime := sendManyCmd.Int64("relativetime", 0, "Seconds the spent outputs must be mined for, rounded up to 512")
+    sendManyReplaceable := sendManyCmd.Bool("rbf", false, "Allow the transaction to be replaced by one paying a higher fee")
+    sendManyQueue := sendManyCmd.Bool("mempool", false, "Add the transaction to the mempool instead of mining it")
+    sendManyRaw := sendManyCmd.Bool("raw", false, "Print the signed transaction instead of sending it")
+    sendManyUnsigned := sendManyCmd.Bool("unsigned", false, "Print the unsigned transaction instead of sending it")
+    mineMiner := mineCmd.String("miner", "", "The account to send block reward to")
+    bumpFeeTxID := bumpFeeCmd.String("txid", "", "The hex ID of the mempool transaction to replace")
+    bumpFeeFee := bumpFeeCmd.Int("fee", 0, "New fee per input and output, default one more than the old fee")
+    cpfpTxID := cpfpCmd.String("txid", "", "The hex ID of the mempool parent transaction")
+    cpfpParent := cpfpCmd.String("parent", "", "The hex signed parent transaction not yet in the mempool")
+    cpfpFee := cpfpCmd.Int("fee", 0, "Fee per input and output of parent and child together, default the minimum")
+    submitPackageFile := submitPackageCmd.String("file", "", "File of hex transactions, one per line")
+    listUnspentAddress := listUnspentCmd.String("address", "", "Only list outputs of ADDRESS")
+    listUnspentMinConf := listUnspentCmd.Int64("minconf", 1, "Minimum confirmations, 0 includes mempool outputs")
+    createRawTransactionInputs := createRawTransactionCmd.String("inputs", "", "Outputs to spend, TXID:VOUT,TXID:VOUT")
+    createRawTransactionOutputs := createRawTransactionCmd.String("outputs", "", "Recipients, ADDRESS:AMOUNT,ADDRESS:AMOUNT")
+    createRawTransactionLockTime := createRawTransactionCmd.Int64("locktime", 0, "Block height or unix time before which the transaction can not be mined")
+    createRawTransactionRelativeLock := createRawTransactionCmd.Int64("relativelock", 0, "Number of blocks the spent outputs must be mined for, at most 65535")
+    createRawTransactionRelativeTime := createRawTransactionCmd.Int64("relativetime", 0, "Seconds the spent outputs must be mined for, rounded up to 512")
+    createRawTransactionReplaceable := createRawTransactionCmd.Bool("rbf", false, "Allow the transaction to be replaced by one paying a higher fee")
+    signRawTransactionHex := signRawTransactionCmd.String("hex", "", "The transaction to sign")
+    decodeRawTransactionHex := decodeRawTransactionCmd.String("hex", "", "The transaction to decode")
+    sendRawTransactionHex := sendRawTransactionCmd.String("hex", "", "The signed transaction to send")
+    estimateFeeBlocks := estimateFeeCmd.Int("blocks", chain.DefaultConfirmTarget, "Number of blocks to be confirmed within")
+    exportChainFile := exportChainCmd.String("file", "", "The file to write blocks to")
+    exportChainFrom := exportChainCmd.Int64("from", 0, "Height of the first block to export")
+    exportChainTo := exportChainCmd.Int64("to", -1, "Height of the last block to export, default the latest")
+    importChainFile := importChainCmd.String("file", "", "The file to read blocks from")
+    verifyChainLevel := verifyChainCmd.Int("level", chain.DefaultVerifyLevel, "How thorough the check is, 0 to 3")
+    verifyChainDepth := verifyChainCmd.Int64("depth", 0, "Number of latest blocks to check, 0 for all")
+    pruneBlockchainTarget := pruneBlockchainCmd.String("prune", "", "Number of blocks or size of blocks to keep, 0 to turn off")
+    dumpUTXOSetFile := dumpUTXOSetCmd.String("file", "", "The file to write the snapshot to")
+    loadUTXOSetFile := loadUTXOSetCmd.String("file", "", "The snapshot file to load")
+    loadUTXOSetHash := loadUTXOSetCmd.String("hash", "", "Expected hash of the snapshot")
+    verifyUTXOSetBlocks := verifyUTXOSetCmd.String("blocks", "", "File of blocks written by exportchain")
+    getUTXOProofOutpoint := getUTXOProofCmd.String("outpoint", "", "Output to prove, TXID:VOUT")
+    verifyUTXOProofProof := verifyUTXOProofCmd.String("proof", "", "Proof printed by getutxoproof")
+    verifyUTXOProofRoot := verifyUTXOProofCmd.String("root", "", "UTXO root to check against")
+    listTransactionsAccount := listTransactionsCmd.String("account", "", "Only list transactions of ACCOUNT")
+    listTransactionsCount := listTransactionsCmd.Int("count", 10, "Number of transactions to list, 0 for all")
+    rescanFrom := rescanCmd.Int64("from", 0, "Height to rescan from")
+    dumpPrivKeyAddress := dumpPrivKeyCmd.String("address", "", "The address to dump private key for")
+    importPrivKeyKey := importPrivKeyCmd.String("key", "", "The private key in WIF")
+    dumpWalletFile := dumpWalletCmd.String("file", "", "The file to write keys to")
+    importWalletFile := importWalletCmd.String("file", "", "The file to read keys from")
+    importAddressAddress := importAddressCmd.String("address", "", "The watch-only address")
+    importAddressPubKey := importAddressCmd.String("pubkey", "", "The hex public key of the watch-only address")
+    signMessageAddress := signMessageCmd.String("address", "", "The address to sign with")
+    signMessageMessage := signMessageCmd.String("message", "", "The message to sign")
+    verifyMessageAddress := verifyMessageCmd.String("address", "", "The address of the signer")
+    verifyMessageSignature := verifyMessageCmd.String("signature", "", "The base64 signature")
+    verifyMessageMessage := verifyMessageCmd.String("message", "", "The signed message")
+    initiateFrom := initiateCmd.String("from", "", "The account to fund the contract and refund to")
+    initiateTo := initiateCmd.String("to", "", "The account able to redeem the contract")
+    initiateAmount := initiateCmd.Int("amount", 0, "Amount to lock in the contract")
+    initiateTimeout := initiateCmd.Int64("timeout", 20, "Number of blocks before the contract can be refunded")
+    initiateFee := initiateCmd.Int("fee", 0, "Fee to pay per input and output")
+    participateFrom := participateCmd.String("from", "", "The account to fund the contract and refund to")
+    participateTo := participateCmd.String("to", "", "The account able to redeem the contract")
+    participateAmount := participateCmd.Int("amount", 0, "Amount to lock in the contract")
+    participateSecretHash := participateCmd.String("secrethash", "", "The hex secret hash of the initiator's contract")
+    participateTimeout := participateCmd.Int64("timeout", 10, "Number of blocks before the contract can be refunded")
+    participateFee := participateCmd.Int("fee", 0, "Fee to pay per input and output")
+    redeemContract := redeemCmd.String("contract", "", "The contract outpoint TXID:VOUT")
+    redeemSecret := redeemCmd.String("secret", "", "The hex secret")
+    redeemFee := redeemCmd.Int("fee", 0, "Fee to pay")
+    refundContract := refundCmd.String("contract", "", "The contract outpoint TXID:VOUT")
+    refundFee := refundCmd.Int("fee", chain.MinRelayFee(2), "Fee to pay, at least the minimum relay fee to wait in the mempool")
+    extractSecretContract := extractSecretCmd.String("contract", "", "The contract outpoint TXID:VOUT")
+    notarizeFile := notarizeCmd.String("file", "", "The document to notarize")
+    notarizeFrom := notarizeCmd.String("from", "", "The account to pay the fee, default all accounts")
+    notarizeFee := notarizeCmd.Int("fee", 1, "Fee to pay per input and output")
+    verifyNotarizationFile := verifyNotarizationCmd.String("file", "", "The document to verify")
+
+    switch os.Args[1] {
+    case "printchain":
+        err := printChainCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "createchain":
+        err := createChainCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "createwallet":
+        err := createWalletCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "accounts":
+        err := accountsCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "getbalance":
+        err := getBalanceCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "send":
+        err := sendCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "sendmany":
+        err := sendManyCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "mine":
+        err := mineCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "bumpfee":
+        err := bumpFeeCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "cpfp":
+        err := cpfpCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "submitpackage":
+        err := submitPackageCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "listunspent":
+        err := listUnspentCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "createrawtransaction":
+        err := createRawTransactionCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "signrawtransaction":
+        err := signRawTransactionCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "decoderawtransaction":
+        err := decodeRawTransactionCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "sendrawtransaction":
+        err := sendRawTransactionCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "listmempool":
+        err := listMempoolCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "estimatefee":
+        err := estimateFeeCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "exportchain":
+        err := exportChainCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "importchain":
+        err := importChainCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "verifychain":
+        err := verifyChainCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "pruneblockchain":
+        err := pruneBlockchainCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "dumputxoset":
+        err := dumpUTXOSetCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "loadutxoset":
+        err := loadUTXOSetCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "verifyutxoset":
+        err := verifyUTXOSetCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "getutxoproof":
+        err := getUTXOProofCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "verifyutxoproof":
+        err := verifyUTXOProofCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "listtransactions":
+        err := listTransactionsCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "rescan":
+        err := rescanCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "dumpprivkey":
+        err := dumpPrivKeyCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "importprivkey":
+        err := importPrivKeyCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "dumpwallet":
+        err := dumpWalletCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "importwallet":
+        err := importWalletCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "importaddress":
+        err := importAddressCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "signmessage":
+        err := signMessageCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "verifymessage":
+        err := verifyMessageCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "initiate":
+        err := initiateCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "participate":
+        err := participateCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "redeem":
+        err := redeemCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "refund":
+        err := refundCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "extractsecret":
+        err := extractSecretCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "notarize":
+        err := notarizeCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    case "verifynotarization":
+        err := verifyNotarizationCmd.Parse(os.Args[2:])
+        if err != nil { cli.parseError(err) }
+    default:
+        cli.printUsage()
+        cli.fail(ExitUsage, "ERROR: Unknown command " + os.Args[1])
+    }
+
+    // flag.FlagSet.Parsed f func() bool
+    if printChainCmd.Parsed() { cli.printChain() }
+
+    if createChainCmd.Parsed() {
+        if *createChainData == "" {
+            cli.usageError(createChainCmd)
+        }
+        cli.createChain(*createChainData)
+    }
+
+    if createWalletCmd.Parsed() { cli.createWallet() }
+
+    if accountsCmd.Parsed() { cli.accounts() }
+
+    if getBalanceCmd.Parsed() { cli.getBalance(*getBalanceData) }
+
+    if sendCmd.Parsed() {
+        sequence, ok := relativeLockSequence(*sendRelativeLock, *sendRelativeTime)
+        if *sendTo == "" || *sendAmount <= 0 || *sendFee < -1 || *sendLockTime < 0 || !ok || (*sendUnsigned && *sendFrom == "") {
+            cli.usageError(sendCmd)
+        }
+        cli.send(*sendFrom, *sendTo, *sendAmount, *sendFee, *sendLockTime, sequence, *sendStrategy, *sendReplaceable, *sendQueue, *sendRaw, *sendUnsigned)
+    }
+
+    if sendManyCmd.Parsed() {
+        sequence, ok := relativeLockSequence(*sendManyRelativeLock, *sendManyRelativeTime)
+        if (*sendManyTo == "") == (*sendManyFile == "") || *sendManyFee < -1 || *sendManyLockTime < 0 || !ok || (*sendManyUnsigned && *sendManyFrom == "") {
+            cli.usageError(sendManyCmd)
+        }
+        cli.sendMany(*sendManyFrom, *sendManyTo, *sendManyFile, *sendManyFee, *sendManyLockTime, sequence, *sendManyStrategy, *sendManyReplaceable, *sendManyQueue, *sendManyRaw, *sendManyUnsigned)
+    }
+
+    if mineCmd.Parsed() {
+        if *mineMiner == "" {
+            cli.usageError(mineCmd)
+        }
+        cli.mine(*mineMiner)
+    }
+
+    if bumpFeeCmd.Parsed() {
+        if *bumpFeeTxID == "" || *bumpFeeFee < 0 {
+            cli.usageError(bumpFeeCmd)
+        }
+        cli.bumpFee(*bumpFeeTxID, *bumpFeeFee)
+    }
+
+    if cpfpCmd.Parsed() {
+        if (*cpfpTxID == "") == (*cpfpParent == "") || *cpfpFee < 0 {
+            cli.usageError(cpfpCmd)
+        }
+        cli.cpfp(*cpfpTxID, *cpfpParent, *cpfpFee)
+    }
+
+    if submitPackageCmd.Parsed() {
+        if *submitPackageFile == "" {
+            cli.usageError(submitPackageCmd)
+        }
+        cli.submitPackage(*submitPackageFile)
+    }
+
+    if listUnspentCmd.Parsed() { cli.listUnspent(*listUnspentAddress, *listUnspentMinConf) }
+
+    if createRawTransactionCmd.Parsed() {
+        sequence, ok := relativeLockSequence(*createRawTransactionRelativeLock, *createRawTransactionRelativeTime)
+        if *createRawTransactionInputs == "" || *createRawTransactionOutputs == "" || !ok {
+            cli.usageError(createRawTransactionCmd)
+        }
+        cli.createRawTransaction(*createRawTransactionInputs, *createRawTransactionOutputs, *createRawTransactionLockTime, sequence, *createRawTransactionReplaceable)
+    }
+
+    if signRawTransactionCmd.Parsed() {
+        if *signRawTransactionHex == "" {
+            cli.usageError(signRawTransactionCmd)
+        }
+        cli.signRawTransaction(*signRawTransactionHex)
+    }
+
+    if decodeRawTransactionCmd.Parsed() {
+        if *decodeRawTransactionHex == "" {
+            cli.usageError(decodeRawTransactionCmd)
+        }
+        cli.decodeRawTransaction(*decodeRawTransactionHex)
+    }
+
+    if sendRawTransactionCmd.Parsed() {
+        if *sendRawTransactionHex == "" {
+            cli.usageError(sendRawTransactionCmd)
+        }
+        cli.sendRawTransaction(*sendRawTransactionHex)
+    }
+
+    if listMempoolCmd.Parsed() { cli.listMempool() }
+
+    if estimateFeeCmd.Parsed() {
+        if *estimateFeeBlocks < 1 || *estimateFeeBlocks > chain.MaxConfirmTarget {
+            cli.usageError(estimateFeeCmd)
+        }
+        cli.estimateFee(*estimateFeeBlocks)
+    }
+
+    if exportChainCmd.Parsed() {
+        if *exportChainFile == "" || *exportChainFrom < 0 {
+            cli.usageError(exportChainCmd)
+        }
+        cli.exportChain(*exportChainFile, *exportChainFrom, *exportChainTo)
+    }
+
+    if importChainCmd.Parsed() {
+        if *importChainFile == "" {
+            cli.usageError(importChainCmd)
+        }
+        cli.importChain(*importChainFile)
+    }
+
+    if verifyChainCmd.Parsed() {
+        if *verifyChainLevel < chain.MinVerifyLevel || *verifyChainLevel > chain.MaxVerifyLevel || *verifyChainDepth < 0 {
+            cli.usageError(verifyChainCmd)
+        }
+        cli.verifyChain(*verifyChainLevel, *verifyChainDepth)
+    }
+
+    if pruneBlockchainCmd.Parsed() {
+        if *pruneBlockchainTarget == "" {
+            cli.usageError(pruneBlockchainCmd)
+        }
+        cli.pruneBlockchain(*pruneBlockchainTarget)
+    }
+
+    if dumpUTXOSetCmd.Parsed() {
+        if *dumpUTXOSetFile == "" {
+            cli.usageError(dumpUTXOSetCmd)
+        }
+        cli.dumpUTXOSet(*dumpUTXOSetFile)
+    }
+
+    if loadUTXOSetCmd.Parsed() {
+        if *loadUTXOSetFile == "" {
+            cli.usageError(loadUTXOSetCmd)
+        }
+        cli.loadUTXOSet(*loadUTXOSetFile, *loadUTXOSetHash)
+    }
+
+    if verifyUTXOSetCmd.Parsed() {
+        if *verifyUTXOSetBlocks == "" {
+            cli.usageError(verifyUTXOSetCmd)
+        }
+        cli.verifyUTXOSet(*verifyUTXOSetBlocks)
+    }
+
+    if getUTXOProofCmd.Parsed() {
+        if *getUTXOProofOutpoint == "" {
+            cli.usageError(getUTXOProofCmd)
+        }
+        cli.getUTXOProof(*getUTXOProofOutpoint)
+    }
+
+    if verifyUTXOProofCmd.Parsed() {
+        if *verifyUTXOProofProof == "" {
+            cli.usageError(verifyUTXOProofCmd)
+        }
+        cli.verifyUTXOProof(*verifyUTXOProofProof, *verifyUTXOProofRoot)
+    }
+
+    if listTransactionsCmd.Parsed() {
+        if *listTransactionsCount < 0 {
+            cli.usageError(listTransactionsCmd)
+        }
+        cli.listTransactions(*listTransactionsAccount, *listTransactionsCount)
+    }
+
+    if rescanCmd.Parsed() {
+        if *rescanFrom < 0 {
+            cli.usageError(rescanCmd)
+        }
+        cli.rescan(*rescanFrom)
+    }
+
+    if dumpPrivKeyCmd.Parsed() {
+        if *dumpPrivKeyAddress == "" {
+            cli.usageError(dumpPrivKeyCmd)
+        }
+        cli.dumpPrivKey(*dumpPrivKeyAddress)
+    }
+
+    if importPrivKeyCmd.Parsed() {
+        if *importPrivKeyKey == "" {
+            cli.usageError(importPrivKeyCmd)
+        }
+        cli.importPrivKey(*importPrivKeyKey)
+    }
+
+    if dumpWalletCmd.Parsed() {
+        if *dumpWalletFile == "" {
+            cli.usageError(dumpWalletCmd)
+        }
+        cli.dumpWallet(*dumpWalletFile)
+    }
+
+    if importWalletCmd.Parsed() {
+        if *importWalletFile == "" {
+            cli.usageError(importWalletCmd)
+        }
+        cli.importWallet(*importWalletFile)
+    }
+
+    if importAddressCmd.Parsed() {
+        if *importAddressAddress == "" && *importAddressPubKey == "" {
+            cli.usageError(importAddressCmd)
+        }
+        cli.importAddress(*importAddressAddress, *importAddressPubKey)
+    }
+
+    if signMessageCmd.Parsed() {
+        if *signMessageAddress == "" {
+            cli.usageError(signMessageCmd)
+        }
+        cli.signMessage(*signMessageAddress, *signMessageMessage)
+    }
+
+    if verifyMessageCmd.Parsed() {
+        if *verifyMessageAddress == "" || *verifyMessageSignature == "" {
+            cli.usageError(verifyMessageCmd)
+        }
+        cli.verifyMessage(*verifyMessageAddress, *verifyMessageSignature, *verifyMessageMessage)
+    }
+
+    if initiateCmd.Parsed() {
+        if *initiateFrom == "" || *initiateTo == "" || *initiateAmount <= 0 || *initiateTimeout <= 0 || *initiateFee < 0 {
+            cli.usageError(initiateCmd)
+        }
+        cli.initiate(*initiateFrom, *initiateTo, *initiateAmount, *initiateFee, *initiateTimeout)
+    }
+
+    if participateCmd.Parsed() {
+        if *participateFrom == "" || *participateTo == "" || *participateAmount <= 0 || *participateSecretHash == "" || *participateTimeout <= 0 || *participateFee < 0 {
+            cli.usageError(participateCmd)
+        }
+        cli.participate(*participateFrom, *participateTo, *participateAmount, *participateFee, *participateTimeout, *participateSecretHash)
+    }
+
+    if redeemCmd.Parsed() {
+        if *redeemContract == "" || *redeemSecret == "" || *redeemFee < 0 {
+            cli.usageError(redeemCmd)
+        }
+        cli.redeem(*redeemContract, *redeemSecret, *redeemFee)
+    }
+
+    if refundCmd.Parsed() {
+        if *refundContract == "" || *refundFee < 0 {
+            cli.usageError(refundCmd)
+        }
+        cli.refund(*refundContract, *refundFee)
+    }
+
+    if extractSecretCmd.Parsed() {
+        if *extractSecretContract == "" {
+            cli.usageError(extractSecretCmd)
+        }
+        cli.extractSecret(*extractSecretContract)
+    }
+
+    if notarizeCmd.Parsed() {
+        if *notarizeFile == "" || *notarizeFee < 0 {
+            cli.usageError(notarizeCmd)
+        }
+        cli.notarize(*notarizeFile, *notarizeFrom, *notarizeFee)
+    }
+
+    if verifyNotarizationCmd.Parsed() {
+        if *verifyNotarizationFile == "" {
+            cli.usageError(verifyNotarizationCmd)
+        }
+        cli.verifyNotarization(*verifyNotarizationFile)
+    }
+
+    cli.printResult()
+}
+
+func (cli *CLI) validateArgs() {
+    if len(os.Args) < 2 {
+        cli.printUsage()
+        cli.fail(ExitUsage, "ERROR: No command")
+    }
+}
+
+// 输出使用方法，-json 的输出格式和退出码见 json.go
+func (cli *CLI) printUsage() {
+    fmt.Print(usage)
+}
+
+// 打开区块链，utxo 在打开时被重建的提示输出到 stderr，不影响 JSON 输出
+func (cli *CLI) openBlockchain() *chain.Blockchain {
+    bc, err := chain.NewBlockchain()
+    cli.check(err)
+    if bc.UTXOSetRepaired() { fmt.Fprintln(os.Stderr, "UTXO set did not match the latest block and has been reindexed") }
+
+    return bc
+}
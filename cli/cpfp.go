@@ -0,0 +1,46 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 创建子交易为父交易支付手续费，父交易为交易池中 ID 为 txID 的交易，或者 hex 编码的 parent
+// 父交易不在交易池中时，与子交易一起加入
+func (cli *CLI) cpfp(txID, parent string, fee int) {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    mempool := bc.Mempool()
+
+    var parentTx types.Transaction
+    if txID != "" {
+        id, err := hex.DecodeString(txID)
+        cli.check(err)
+
+        entry, ok, err := mempool.Get(id)
+        cli.check(err)
+        if !ok { cli.fail(ExitError, wallet.ErrNotInMempool) }
+        parentTx = entry.Tx
+    } else {
+        data, err := hex.DecodeString(parent)
+        cli.check(err)
+        parentTx, err = types.DeserializeTransaction(data)
+        cli.check(err)
+    }
+
+    child, err := wallet.NewCPFPTransaction(&parentTx, fee, bc)
+    cli.check(err)
+
+    err = mempool.AddPackage([]*types.Transaction{&parentTx, child})
+    cli.check(err)
+
+    fmt.Printf("Transaction %x pays for %x\n", child.ID, parentTx.ID)
+    cli.setResult(struct {
+        Parent string `json:"parent"`
+        TxResultJSON
+    }{hex.EncodeToString(parentTx.ID), NewTxResultJSON(child, nil)})
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+func (cli *CLI) createChain(address string) {
+    bc, err := chain.CreateBlockchain(address)
+    cli.check(err)
+    defer bc.Close()
+
+    genesis, err := bc.GetBlock(bc.Tip())
+    cli.check(err)
+    cli.check(chainCreated(bc, genesis))
+
+    fmt.Println("Done!")
+    cli.setResult(struct {
+        Block BlockJSON `json:"block"`
+    }{NewBlockJSON(genesis)})
+}
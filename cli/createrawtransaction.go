@@ -0,0 +1,24 @@
+package cli
+
+import (
+    "strings"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 花费 inputs 中的输出，转账给 outputs，输出待签名交易的 hex 编码
+// inputs: TXID:VOUT,TXID:VOUT
+// outputs: ADDRESS:AMOUNT,ADDRESS:AMOUNT，输入与输出之差为手续费
+// relativeLock 不为 0 时为输入的相对时间锁，见 relativeLockSequence
+func (cli *CLI) createRawTransaction(inputs, outputs string, lockTime int64, relativeLock uint32, replaceable bool) {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    view, err := bc.Mempool().View()
+    cli.check(err)
+
+    tx, err := wallet.NewRawTransaction(strings.Split(inputs, ","), cli.parseRecipients(outputs), lockTime, relativeLock, replaceable, view)
+    cli.check(err)
+
+    cli.printUnsignedTransaction(tx)
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 创建新账号
+func (cli *CLI) createWallet() {
+    // 钱包文件不存在时创建新的钱包文件
+    wallets, err := wallet.NewWallets()
+    if err != wallet.ErrWalletNotFound { cli.check(err) }
+    address, err := wallets.CreateWallet()
+    cli.check(err)
+    cli.check(wallets.SaveToFile())
+
+    fmt.Printf("Your new address: %s\n", address)
+    cli.setResult(struct {
+        Address string `json:"address"`
+    }{address})
+}
@@ -0,0 +1,16 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 输出 hex 编码的交易的可读格式，不需要区块链
+func (cli *CLI) decodeRawTransaction(txHex string) {
+    tx, err := types.DecodeRawTransaction(txHex)
+    cli.check(err)
+
+    fmt.Println(tx)
+    cli.setResult(NewTransactionJSON(tx))
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 导出 address 的私钥
+func (cli *CLI) dumpPrivKey(address string) {
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    if _, ok := wallets.Wallets[address]; !ok {
+        cli.fail(ExitError, fmt.Errorf("%w: %s", wallet.ErrUnknownAddress, address))
+    }
+    wallet := wallets.GetWallet(address)
+
+    fmt.Println(wallet.ExportPrivateKey())
+    cli.setResult(struct {
+        Address string `json:"address"`
+        Key     string `json:"key"`
+    }{address, wallet.ExportPrivateKey()})
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+    "os"
+    "fmt"
+    "bufio"
+)
+
+// 将最新区块时的 utxo 写入快照文件
+func (cli *CLI) dumpUTXOSet(file string) {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    f, err := os.Create(file)
+    cli.check(err)
+    defer f.Close()
+
+    w := bufio.NewWriter(f)
+    snapshot, err := bc.WriteUTXOSnapshot(w)
+    cli.check(err)
+
+    err = w.Flush()
+    cli.check(err)
+
+    fmt.Printf("Dumped %d outputs at height %d, block %x\n", snapshot.Outputs, snapshot.Height, snapshot.BlockHash)
+    fmt.Printf("Hash: %x\n", snapshot.Hash)
+    cli.setResult(NewSnapshotJSON(snapshot))
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+    "fmt"
+    "bytes"
+    "time"
+    "io/ioutil"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 导出钱包全部私钥到 file
+// 每行一个私钥: WIF ADDRESS，# 开头的行为注释
+func (cli *CLI) dumpWallet(file string) {
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    var content bytes.Buffer
+    fmt.Fprintf(&content, "# Wallet dump created at %s\n", time.Now().Format(time.RFC3339))
+    fmt.Fprintf(&content, "# private key (WIF) and address\n")
+
+    for _, address := range wallets.GetAddresses() {
+        wallet := wallets.GetWallet(address)
+        fmt.Fprintf(&content, "%s %s\n", wallet.ExportPrivateKey(), address)
+    }
+
+    err = ioutil.WriteFile(file, content.Bytes(), 0600)
+    cli.check(err)
+
+    fmt.Printf("Dumped %d keys to %s\n", len(wallets.Wallets), file)
+    cli.setResult(struct {
+        File string `json:"file"`
+        Keys int    `json:"keys"`
+    }{file, len(wallets.Wallets)})
+}
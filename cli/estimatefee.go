@@ -0,0 +1,33 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 估计在 blocks 个区块内被确认需要的手续费率，数据不足时返回非零退出码
+func (cli *CLI) estimateFee(blocks int) {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    estimator, err := chain.LoadFeeEstimator()
+    cli.check(err)
+    entries, err := bc.Mempool().Entries()
+    cli.check(err)
+    height, err := bc.GetBestHeight()
+    cli.check(err)
+
+    rate, err := estimator.EstimateFee(blocks, entries, height)
+    if err != nil {
+        bc.Close()
+        cli.fail(ExitFailed, err)
+    }
+    if rate < chain.MinRelayFeeRate { rate = chain.MinRelayFeeRate }
+
+    fmt.Printf("Estimated fee for confirmation within %d blocks: %d per input and output\n", blocks, rate)
+    cli.setResult(struct {
+        Blocks  int `json:"blocks"`
+        FeeRate int `json:"fee_rate"`
+    }{blocks, rate})
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+    "os"
+    "fmt"
+    "bufio"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 将高度在 [from, to] 之间的区块写入文件，to 小于 0 时到最新区块
+func (cli *CLI) exportChain(file string, from, to int64) {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    if to < 0 {
+        best, err := bc.GetBestHeight()
+        cli.check(err)
+        to = best
+    }
+    if from > to { cli.fail(ExitUsage, "ERROR: Invalid height range") }
+
+    err := bc.CheckBlocksAvailable(from)
+    cli.check(err)
+
+    f, err := os.Create(file)
+    cli.check(err)
+    defer f.Close()
+
+    w := bufio.NewWriter(f)
+    hashes, err := bc.BlockHashes(from, to)
+    cli.check(err)
+    for _, hash := range hashes {
+        block, err := bc.GetBlock(hash)
+        cli.check(err)
+
+        err = chain.WriteBootstrapBlock(w, block)
+        cli.check(err)
+    }
+
+    err = w.Flush()
+    cli.check(err)
+
+    fmt.Printf("Exported %d blocks from height %d to %d\n", len(hashes), from, to)
+    cli.setResult(struct {
+        File   string `json:"file"`
+        Blocks int    `json:"blocks"`
+        From   int64  `json:"from"`
+        To     int64  `json:"to"`
+    }{file, len(hashes), from, to})
+}
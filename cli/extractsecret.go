@@ -0,0 +1,26 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 从合约的 redeem 交易中得到 secret
+func (cli *CLI) extractSecret(contract string) {
+    txID, vout, err := types.ParseOutpoint(contract)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    secret, err := bc.ExtractSecret(txID, vout)
+    cli.check(err)
+
+    fmt.Printf("Secret: %x\n", secret)
+    cli.setResult(struct {
+        Contract string `json:"contract"`
+        Secret   string `json:"secret"`
+    }{contract, hex.EncodeToString(secret)})
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// address 为空时，分别统计钱包内可花费地址和只读地址的余额
+func (cli *CLI) getBalance(address string) {
+    if address != "" && !crypto.ValidateAddress(address) { cli.fail(ExitUsage, crypto.ErrInvalidAddress) }
+
+    bc := cli.openBlockchain()
+    u := bc.UTXOSet()
+    defer bc.Close()
+
+    if address != "" {
+        balance, err := u.GetBalance(address)
+        cli.check(err)
+        fmt.Printf("getBalance of '%s': %d\n", address, balance)
+        cli.setResult(BalanceJSON{address, &balance, nil, nil})
+        return
+    }
+
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    spendable, watchOnly := 0, 0
+    for _, address := range wallets.GetAddresses() {
+        balance, err := u.GetBalance(address)
+        cli.check(err)
+        spendable += balance
+    }
+    for _, address := range wallets.GetWatchOnlyAddresses() {
+        balance, err := u.GetBalance(address)
+        cli.check(err)
+        watchOnly += balance
+    }
+
+    fmt.Printf("Spendable balance: %d\n", spendable)
+    fmt.Printf("Watch-only balance: %d\n", watchOnly)
+    cli.setResult(BalanceJSON{"", nil, &spendable, &watchOnly})
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+// 当前 utxo 中一个输出存在或不存在的证明，可与最新区块头中的 UTXORoot 比较
+func (cli *CLI) getUTXOProof(outpoint string) {
+    txID, vout, err := types.ParseOutpoint(outpoint)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    tip, err := bc.GetBlock(bc.Tip())
+    cli.check(err)
+    proof, err := bc.UTXOSet().ProveOutput(txID, vout)
+    cli.check(err)
+
+    root, err := proof.Root()
+    cli.check(err)
+
+    fmt.Printf("Block %d %x\n", tip.Number(), tip.Hash)
+    fmt.Printf("UTXO root: %x\n", root)
+    if (tip.UTXORoot() == common.Hash{}) {
+        fmt.Println("The latest block has no UTXO root, the proof can only be checked with -root")
+    }
+    if proof.Output != nil {
+        fmt.Printf("Output %s is unspent, value %d\n", outpoint, proof.Output.Value)
+    } else {
+        fmt.Printf("Output %s does not exist or is spent\n", outpoint)
+    }
+    fmt.Printf("Proof: %s\n", hex.EncodeToString(proof.Serialize()))
+
+    cli.setResult(UTXOProofJSON{NewBlockRefJSON(tip), hex.EncodeToString(root.Bytes()), newProofOutpointJSON(proof), hex.EncodeToString(proof.Serialize())})
+}
+
+// getutxoproof 和 verifyutxoproof 的结果，output 为空时证明输出不存在
+// getutxoproof 的 block 为生成证明时的最新区块，verifyutxoproof 的 valid 为校验结果
+type UTXOProofJSON struct {
+    Block    *BlockRefJSON `json:"block,omitempty"`
+    UTXORoot string        `json:"utxo_root"`
+    Outpoint OutpointJSON  `json:"outpoint"`
+    Proof    string        `json:"proof,omitempty"`
+}
+
+func newProofOutpointJSON(proof *utxo.UTXOProof) OutpointJSON {
+    outpoint := OutpointJSON{hex.EncodeToString(proof.TxID), proof.Vout, nil}
+    if proof.Output != nil {
+        out := NewOutputJSON(proof.Vout, *proof.Output)
+        outpoint.Output = &out
+    }
+    return outpoint
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+    "fmt"
+    "bytes"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 导入只读地址，不需要私钥，并重新扫描区块链
+// 只提供公钥时根据公钥计算地址；提供公钥后可以构造待签名的交易
+func (cli *CLI) importAddress(address, pubKeyHex string) {
+    var pubKey []byte
+    if pubKeyHex != "" {
+        var err error
+        pubKey, err = hex.DecodeString(pubKeyHex)
+        cli.check(err)
+
+        if address == "" { address = crypto.PubKeyHashToAddress(crypto.HashPubKey(pubKey)) }
+    }
+    if !crypto.ValidateAddress(address) { cli.fail(ExitUsage, crypto.ErrInvalidAddress) }
+
+    if pubKey != nil && bytes.Compare(crypto.AddressToPubKeyHash(address), crypto.HashPubKey(pubKey)) != 0 {
+        cli.fail(ExitUsage, "ERROR: Public key does not match address " + address)
+    }
+
+    wallets, err := wallet.NewWallets()
+    if err != wallet.ErrWalletNotFound { cli.check(err) }
+    if _, ok := wallets.Wallets[address]; ok {
+        cli.fail(ExitError, "ERROR: Address already has a private key in the wallet")
+    }
+    wallets.AddWatchOnly(address, pubKey)
+    cli.check(wallets.SaveToFile())
+
+    fmt.Printf("Imported watch-only address: %s\n", address)
+
+    if chain.DBExists() { cli.rescan(0) }
+    cli.setResult(struct {
+        Address   string `json:"address"`
+        WatchOnly bool   `json:"watch_only"`
+    }{address, true})
+}
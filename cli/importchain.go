@@ -0,0 +1,104 @@
+package cli
+
+import (
+    "io"
+    "os"
+    "fmt"
+    "bufio"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 从文件导入区块，每个区块都经过完整校验
+// 没有区块链时创建新的区块链，否则区块需连接在最新区块之后，已有的区块被跳过
+// 新的区块链在临时数据库中导入，见 importNewChain
+func (cli *CLI) importChain(file string) {
+    f, err := os.Open(file)
+    cli.check(err)
+    defer f.Close()
+
+    var tip *types.Block
+    var imported int
+    if !chain.DBExists() {
+        tip, imported, err = importNewChain(bufio.NewReader(f))
+    } else {
+        bc := cli.openBlockchain()
+        defer bc.Close()
+        tip, imported, err = importBlocks(bc, bufio.NewReader(f), blockConnected)
+    }
+    cli.check(err)
+
+    fmt.Printf("Imported %d blocks, best height %d\n", imported, tip.Number())
+    cli.setResult(struct {
+        Imported int           `json:"imported"`
+        Tip      *BlockRefJSON `json:"tip"`
+    }{imported, NewBlockRefJSON(tip)})
+}
+
+/*
+在临时数据库中导入新的区块链，全部区块导入成功之后才会创建，失败时不留下数据库
+    导入期间不更新手续费统计和钱包交易记录，否则失败之后它们记录的区块并不存在
+    创建之后由新的区块链重建，见 chainCreated
+*/
+func importNewChain(r io.Reader) (*types.Block, int, error) {
+    bc, err := chain.NewImportBlockchain()
+    if err != nil { return nil, 0, err }
+
+    tip, imported, err := importBlocks(bc, r, nil)
+    if err != nil {
+        bc.Remove()
+        return nil, 0, err
+    }
+    if err := bc.Commit(); err != nil { return nil, 0, err }
+
+    bc, err = chain.NewBlockchain()
+    if err != nil { return nil, 0, err }
+    defer bc.Close()
+
+    return tip, imported, chainCreated(bc, tip)
+}
+
+// 新的区块链创建或导入之后，重置手续费统计，并由全部区块重建钱包交易记录
+// 之前的统计和交易记录可能属于其他区块链
+func chainCreated(bc *chain.Blockchain, tip *types.Block) error {
+    if err := chain.ResetFeeEstimates(tip.Number().Int64()); err != nil { return err }
+    return wallet.RebuildWalletHistory(bc)
+}
+
+// 依次校验并连接 r 中的区块，返回最新区块和导入的区块数
+// 每个区块连接之后调用 connected，nil 时不调用
+func importBlocks(bc *chain.Blockchain, r io.Reader, connected func(*chain.Blockchain, *types.Block) error) (*types.Block, int, error) {
+    var tip *types.Block
+    if bc.Tip() != nil {
+        var err error
+        tip, err = bc.GetBlock(bc.Tip())
+        if err != nil { return nil, 0, err }
+    }
+
+    imported := 0
+    for {
+        block, err := chain.ReadBootstrapBlock(r)
+        if err == io.EOF { break }
+        if err != nil { return nil, 0, err }
+
+        found, err := bc.HasBlock(block.Hash.Bytes())
+        if err != nil { return nil, 0, err }
+        if found { continue }
+
+        err = bc.ValidateBlock(block, tip)
+        if err != nil { return nil, 0, fmt.Errorf("%w at height %d", err, block.Number()) }
+
+        if err := bc.ConnectBlock(block); err != nil { return nil, 0, err }
+        if connected != nil {
+            if err := connected(bc, block); err != nil { return nil, 0, err }
+        }
+
+        tip = block
+        imported++
+    }
+
+    if tip == nil { return nil, 0, chain.ErrInvalidBootstrap }
+    return tip, imported, nil
+}
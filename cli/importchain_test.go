@@ -0,0 +1,64 @@
+package cli
+
+import (
+    "os"
+    "bytes"
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 导出一条属于钱包地址的区块链，高度为 height，导出之后删除区块链
+func exportTestChain(t *testing.T, height int) []byte {
+    wallets, _ := wallet.NewWallets()
+    address, err := wallets.CreateWallet()
+    if err != nil { t.Fatal(err) }
+    if err := wallets.SaveToFile(); err != nil { t.Fatal(err) }
+
+    bc, err := chain.CreateBlockchain(address)
+    if err != nil { t.Fatal(err) }
+    for i := 0; i < height; i++ {
+        if _, err := bc.MineBlock(address, nil); err != nil { t.Fatal(err) }
+    }
+
+    var buf bytes.Buffer
+    hashes, err := bc.BlockHashes(0, int64(height))
+    if err != nil { t.Fatal(err) }
+    for _, hash := range hashes {
+        block, err := bc.GetBlock(hash)
+        if err != nil { t.Fatal(err) }
+        if err := chain.WriteBootstrapBlock(&buf, block); err != nil { t.Fatal(err) }
+    }
+
+    bc.Close()
+    if err := os.Remove("data/chain.db"); err != nil { t.Fatal(err) }
+    return buf.Bytes()
+}
+
+// 导入失败时不留下区块链，也不留下记录了这些区块的手续费统计和钱包交易记录
+func TestImportNewChainFailure(t *testing.T) {
+    t.Chdir(t.TempDir())
+    if err := os.Mkdir("data", 0755); err != nil { t.Fatal(err) }
+    data := exportTestChain(t, 2)
+
+    // 最后一个区块不完整，之前的区块已导入临时数据库
+    if _, _, err := importNewChain(bytes.NewReader(data[:len(data) - 1])); !errors.Is(err, chain.ErrInvalidBootstrap) { t.Fatalf("got %v, want %v", err, chain.ErrInvalidBootstrap) }
+    if chain.DBExists() { t.Fatal("failed import left a blockchain") }
+    for _, file := range []string{"data/chain.db.import", "data/wallet_history.dat", "data/fee_estimates.dat"} {
+        if _, err := os.Stat(file); !os.IsNotExist(err) { t.Errorf("failed import left %s", file) }
+    }
+
+    tip, imported, err := importNewChain(bytes.NewReader(data))
+    if err != nil { t.Fatal(err) }
+    if imported != 3 || tip.Number().Int64() != 2 { t.Fatalf("imported %d blocks to height %d, want 3 to height 2", imported, tip.Number()) }
+
+    history, err := wallet.NewWalletHistory()
+    if err != nil { t.Fatal(err) }
+    if history.ScannedHeight != 2 || len(history.Transactions) != 3 { t.Fatalf("history scanned to %d with %d transactions, want 2 with 3", history.ScannedHeight, len(history.Transactions)) }
+
+    estimator, err := chain.LoadFeeEstimator()
+    if err != nil { t.Fatal(err) }
+    if estimator.Height != 2 { t.Fatalf("fee estimates at height %d, want 2", estimator.Height) }
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 导入 WIF 格式的私钥，并重新扫描区块链
+func (cli *CLI) importPrivKey(key string) {
+    w, err := wallet.ImportPrivateKey(key)
+    cli.check(err)
+
+    wallets, err := wallet.NewWallets()
+    if err != wallet.ErrWalletNotFound { cli.check(err) }
+    address := wallets.ImportWallet(w)
+    cli.check(wallets.SaveToFile())
+
+    fmt.Printf("Imported address: %s\n", address)
+
+    if chain.DBExists() { cli.rescan(0) }
+    cli.setResult(struct {
+        Address string `json:"address"`
+    }{address})
+}
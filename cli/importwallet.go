@@ -0,0 +1,40 @@
+package cli
+
+import (
+    "fmt"
+    "strings"
+    "io/ioutil"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 从 dumpwallet 导出的文件导入全部私钥，并重新扫描区块链
+func (cli *CLI) importWallet(file string) {
+    content, err := ioutil.ReadFile(file)
+    cli.check(err)
+
+    wallets, err := wallet.NewWallets()
+    if err != wallet.ErrWalletNotFound { cli.check(err) }
+    imported := 0
+
+    for _, line := range strings.Split(string(content), "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, "#") { continue }
+
+        wallet, err := wallet.ImportPrivateKey(strings.Fields(line)[0])
+        cli.check(err)
+
+        wallets.ImportWallet(wallet)
+        imported++
+    }
+    cli.check(wallets.SaveToFile())
+
+    fmt.Printf("Imported %d keys from %s\n", imported, file)
+
+    if chain.DBExists() { cli.rescan(0) }
+    cli.setResult(struct {
+        File string `json:"file"`
+        Keys int    `json:"keys"`
+    }{file, imported})
+}
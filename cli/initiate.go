@@ -0,0 +1,70 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 原子交换的发起方，生成 secret，创建付给 to 的合约
+// timeout 个区块之后 from 可以取回
+func (cli *CLI) initiate(from, to string, amount, fee int, timeout int64) {
+    secret, secretHash := types.NewHTLCSecret()
+
+    fmt.Printf("Secret:      %x\n", secret)
+    fmt.Printf("Secret hash: %x\n", secretHash)
+
+    result := cli.createContract(from, to, amount, fee, timeout, secretHash)
+    result.Secret = hex.EncodeToString(secret)
+    cli.setResult(result)
+}
+
+// initiate 和 participate 的结果，participate 没有 secret
+type ContractJSON struct {
+    Secret       string `json:"secret,omitempty"`
+    SecretHash   string `json:"secret_hash"`
+    Contract     string `json:"contract"`
+    RefundHeight int64  `json:"refund_height"`
+    TxResultJSON
+}
+
+// 创建付给 to 的 HTLC 输出，并打包到新区块
+func (cli *CLI) createContract(from, to string, amount, fee int, timeout int64, secretHash []byte) ContractJSON {
+    if !crypto.ValidateAddress(from) || !crypto.ValidateAddress(to) { cli.fail(ExitUsage, crypto.ErrInvalidAddress) }
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    // 合约被写入下一个区块，从该区块起 timeout 个区块之后可以取回
+    height, err := bc.GetBestHeight()
+    cli.check(err)
+    lockTime := height + 1 + timeout
+    htlc := &types.HTLC{SecretHash: secretHash, RecipientPubKeyHash: crypto.AddressToPubKeyHash(to), RefundPubKeyHash: crypto.AddressToPubKeyHash(from), LockTime: lockTime}
+
+    selector, err := wallet.NewCoinSelector("")
+    cli.check(err)
+
+    recipients := []wallet.Recipient{{Address: to, Amount: amount, HTLC: htlc}}
+    tx, err := wallet.NewSendManyTransaction([]string{from}, recipients, from, fee, 0, 0, false, selector, bc)
+    cli.check(err)
+    block, err := commitTransaction(bc, from, tx, nil)
+    cli.check(err)
+
+    // 合约输出是交易的第一个输出
+    fmt.Printf("Contract:    %s\n", types.OutpointKey(tx.ID, 0))
+    fmt.Printf("Refundable after block %d\n", htlc.LockTime)
+
+    return ContractJSON{"", hex.EncodeToString(secretHash), types.OutpointKey(tx.ID, 0), htlc.LockTime, NewTxResultJSON(tx, block)}
+}
+
+// 解析 hex 编码的 secret hash
+func (cli *CLI) parseSecretHash(s string) []byte {
+    secretHash, err := hex.DecodeString(s)
+    cli.check(err)
+    if len(secretHash) != types.HTLCSecretLen { cli.fail(ExitUsage, "ERROR: Secret hash must be 32 bytes") }
+
+    return secretHash
+}
@@ -0,0 +1,314 @@
+package cli
+
+/*
+-json: 命令的结果以 JSON 输出，可以出现在命令行的任意位置
+    stdout 只有一个 JSON 对象，挖矿进度等其他输出被丢弃，日志不输出
+    成功时为各命令的结果，字段见下面的类型，只增加字段，不修改已有字段
+    失败时为 {"error": {"message": "...", "exit_code": N}}
+    hash、txid、公钥、签名等字节均为 hex 编码，时间为 unix 时间戳
+
+退出码（文本输出时相同）：
+    0 成功
+    1 执行失败，例如余额不足、交易无效、文件无法读取
+    2 参数错误，例如缺少参数、未知命令、地址无效、金额不是正数
+    3 校验未通过：verifychain、verifymessage、verifyutxoproof、verifyutxoset、
+      verifynotarization 没有找到，estimatefee 数据不足
+*/
+
+import (
+    "os"
+    "fmt"
+    "log"
+    "flag"
+    "errors"
+    "runtime"
+    "io/ioutil"
+    "encoding/hex"
+    "encoding/json"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/consensus"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+const (
+    ExitOK     = 0
+    ExitError  = 1
+    ExitUsage  = 2
+    ExitFailed = 3
+)
+
+// 交易的状态
+const (
+    TxStatusMined    = "mined"    // 已被写入区块
+    TxStatusMempool  = "mempool"  // 在交易池中，等待 mine 打包，包括时间锁尚未到期的交易
+    TxStatusSigned   = "signed"   // 签名后输出，没有发送
+    TxStatusUnsigned = "unsigned" // 没有签名
+)
+
+type ErrorJSON struct {
+    Message  string `json:"message"`
+    ExitCode int    `json:"exit_code"`
+}
+
+// 区块的位置
+type BlockRefJSON struct {
+    Hash   string `json:"hash"`
+    Height int64  `json:"height"`
+}
+
+type BlockJSON struct {
+    Hash         string            `json:"hash"`
+    Height       int64             `json:"height"`
+    ParentHash   string            `json:"parent_hash"`
+    Miner        string            `json:"miner"`
+    MerkleRoot   string            `json:"merkle_root"`
+    UTXORoot     string            `json:"utxo_root,omitempty"` // 没有 utxo 承诺的旧区块为空
+    Timestamp    int64             `json:"timestamp"`
+    Nonce        uint64            `json:"nonce"`
+    PoWValid     bool              `json:"pow_valid"`
+    Transactions []TransactionJSON `json:"transactions"`
+}
+
+type TransactionJSON struct {
+    TxID     string       `json:"txid"`
+    LockTime int64        `json:"locktime"`
+    Coinbase bool         `json:"coinbase"`
+    Inputs   []InputJSON  `json:"inputs"`
+    Outputs  []OutputJSON `json:"outputs"`
+}
+
+// 奖励交易的输入只有 pubkey，为矿工写入的数据
+type InputJSON struct {
+    TxID      string `json:"txid"`
+    Vout      int    `json:"vout"`
+    Signature string `json:"signature"`
+    PubKey    string `json:"pubkey"`
+    Sequence  uint32 `json:"sequence"`
+    Secret    string `json:"secret,omitempty"`
+}
+
+// address、htlc、data 只有一个不为空
+type OutputJSON struct {
+    N       int       `json:"n"`
+    Value   int       `json:"value"`
+    Address string    `json:"address,omitempty"`
+    HTLC    *HTLCJSON `json:"htlc,omitempty"`
+    Data    string    `json:"data,omitempty"`
+}
+
+type HTLCJSON struct {
+    SecretHash string `json:"secret_hash"`
+    Recipient  string `json:"recipient"`
+    Refund     string `json:"refund"`
+    LockTime   int64  `json:"locktime"`
+}
+
+// 一个地址的余额，或者钱包的余额
+type BalanceJSON struct {
+    Address   string `json:"address,omitempty"`
+    Balance   *int   `json:"balance,omitempty"`
+    Spendable *int   `json:"spendable,omitempty"`
+    WatchOnly *int   `json:"watch_only,omitempty"`
+}
+
+// 发送交易的命令的结果，block 只在 mined 时不为空，hex 只在 signed 和 unsigned 时不为空
+type TxResultJSON struct {
+    TxID   string        `json:"txid"`
+    Status string        `json:"status"`
+    Block  *BlockRefJSON `json:"block,omitempty"`
+    Hex    string        `json:"hex,omitempty"`
+}
+
+type OutpointJSON struct {
+    TxID   string      `json:"txid"`
+    Vout   int         `json:"vout"`
+    Output *OutputJSON `json:"output,omitempty"`
+}
+
+// dumputxoset、loadutxoset 和 verifyutxoset 的结果
+type SnapshotJSON struct {
+    Block    BlockRefJSON `json:"block"`
+    Outputs  int          `json:"outputs"`
+    Hash     string       `json:"hash"`
+    Verified bool         `json:"verified"`
+}
+
+func NewSnapshotJSON(snapshot *chain.UTXOSnapshot) SnapshotJSON {
+    return SnapshotJSON{
+        BlockRefJSON{hex.EncodeToString(snapshot.BlockHash), snapshot.Height},
+        snapshot.Outputs, hex.EncodeToString(snapshot.Hash), snapshot.Verified,
+    }
+}
+
+func NewBlockRefJSON(block *types.Block) *BlockRefJSON {
+    return &BlockRefJSON{hex.EncodeToString(block.Hash.Bytes()), block.Number().Int64()}
+}
+
+func NewBlockJSON(block *types.Block) BlockJSON {
+    result := BlockJSON{
+        hex.EncodeToString(block.Hash.Bytes()),
+        block.Number().Int64(),
+        hex.EncodeToString(block.ParentHash().Bytes()),
+        hex.EncodeToString(block.Header.Miner[:]),
+        hex.EncodeToString(block.TxHash().Bytes()),
+        "",
+        block.Timestamp().Int64(),
+        block.Nonce(),
+        consensus.NewProofOfWork(block).Validate(),
+        []TransactionJSON{},
+    }
+    if (block.UTXORoot() != common.Hash{}) { result.UTXORoot = hex.EncodeToString(block.UTXORoot().Bytes()) }
+
+    for _, tx := range block.Transactions {
+        result.Transactions = append(result.Transactions, NewTransactionJSON(tx))
+    }
+    return result
+}
+
+func NewTransactionJSON(tx *types.Transaction) TransactionJSON {
+    result := TransactionJSON{hex.EncodeToString(tx.ID), tx.LockTime, tx.IsCoinbase(), []InputJSON{}, []OutputJSON{}}
+
+    for _, vin := range tx.Vin {
+        result.Inputs = append(result.Inputs, InputJSON{
+            hex.EncodeToString(vin.Txid), vin.Vout,
+            hex.EncodeToString(vin.Signature), hex.EncodeToString(vin.PubKey),
+            vin.Sequence, hex.EncodeToString(vin.Secret),
+        })
+    }
+    for i, out := range tx.Vout {
+        result.Outputs = append(result.Outputs, NewOutputJSON(i, out))
+    }
+    return result
+}
+
+func NewOutputJSON(n int, out types.TXOutput) OutputJSON {
+    result := OutputJSON{N: n, Value: out.Value}
+
+    switch {
+    case out.IsUnspendable():
+        result.Data = hex.EncodeToString(out.Data)
+    case out.HTLC != nil:
+        result.HTLC = &HTLCJSON{
+            hex.EncodeToString(out.HTLC.SecretHash),
+            crypto.PubKeyHashToAddress(out.HTLC.RecipientPubKeyHash),
+            crypto.PubKeyHashToAddress(out.HTLC.RefundPubKeyHash),
+            out.HTLC.LockTime,
+        }
+    default:
+        result.Address = crypto.PubKeyHashToAddress(out.PubKeyHash)
+    }
+    return result
+}
+
+// block 为 nil 时交易在交易池中
+func NewTxResultJSON(tx *types.Transaction, block *types.Block) TxResultJSON {
+    if block == nil { return TxResultJSON{hex.EncodeToString(tx.ID), TxStatusMempool, nil, ""} }
+    return TxResultJSON{hex.EncodeToString(tx.ID), TxStatusMined, NewBlockRefJSON(block), ""}
+}
+
+/*
+从命令行参数中移除全局的 -json
+JSON 输出时，stdout 替换为空设备，只有 cli.result 写入原来的 stdout
+*/
+func (cli *CLI) parseGlobalFlags() {
+    var args []string
+    for _, arg := range os.Args {
+        if arg == "-json" || arg == "--json" {
+            cli.json = true
+            continue
+        }
+        args = append(args, arg)
+    }
+    os.Args = args
+
+    if !cli.json { return }
+
+    devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+    cli.check(err)
+
+    cli.stdout = os.Stdout
+    os.Stdout = devNull
+    log.SetOutput(ioutil.Discard)
+}
+
+// 设置命令的 JSON 结果，命令结束时输出
+func (cli *CLI) setResult(result interface{}) {
+    cli.result = result
+}
+
+// 输出 JSON 结果
+func (cli *CLI) printResult() {
+    if !cli.json { return }
+
+    result := cli.result
+    if result == nil { result = struct{}{} }
+
+    enc := json.NewEncoder(cli.stdout)
+    enc.SetIndent("", "  ")
+    if err := enc.Encode(result); err != nil { log.Panic(err) }
+}
+
+// 以 code 退出，JSON 输出时先输出已设置的结果
+func (cli *CLI) exit(code int) {
+    cli.printResult()
+    os.Exit(code)
+}
+
+// 以 code 退出并输出错误，JSON 输出时为 {"error": ...}
+func (cli *CLI) fail(code int, err interface{}) {
+    message := fmt.Sprint(err)
+    if !cli.json {
+        fmt.Fprintln(os.Stderr, message)
+        os.Exit(code)
+    }
+
+    cli.setResult(struct {
+        Error ErrorJSON `json:"error"`
+    }{ErrorJSON{message, code}})
+    cli.exit(code)
+}
+
+// err 不为 nil 时以对应的退出码退出，见 errorExitCode
+func (cli *CLI) check(err error) {
+    if err != nil { cli.fail(errorExitCode(err), err) }
+}
+
+// 错误对应的退出码，参数本身无效的错误为 ExitUsage
+func errorExitCode(err error) int {
+    if errors.Is(err, crypto.ErrInvalidAddress) || errors.Is(err, wallet.ErrInvalidAmount) { return ExitUsage }
+    return ExitError
+}
+
+// 参数错误，使用方法输出到 stderr
+func (cli *CLI) usageError(cmd *flag.FlagSet) {
+    cmd.Usage()
+    if !cli.json { os.Exit(ExitUsage) }
+
+    cli.fail(ExitUsage, "ERROR: Invalid arguments for " + cmd.Name())
+}
+
+// 解析参数失败，flag 已输出错误和使用方法，-h 时正常退出
+func (cli *CLI) parseError(err error) {
+    if err == flag.ErrHelp { os.Exit(ExitOK) }
+    if !cli.json { os.Exit(ExitUsage) }
+
+    cli.fail(ExitUsage, err)
+}
+
+/*
+log.Panic 的错误（例如输出 JSON 结果失败）以 ExitError 退出，JSON 输出时输出错误
+文本输出时错误已由 log 输出，不再输出调用栈
+运行时错误为程序的 bug，保留调用栈
+*/
+func (cli *CLI) recoverError() {
+    r := recover()
+    if r == nil { return }
+    if _, ok := r.(runtime.Error); ok { panic(r) }
+
+    if !cli.json { os.Exit(ExitError) }
+    cli.fail(ExitError, r)
+}
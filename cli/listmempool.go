@@ -0,0 +1,54 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 列出交易池中的交易，以及各自的祖先交易和后代交易
+func (cli *CLI) listMempool() {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    mempoolEntries, err := bc.Mempool().Entries()
+    cli.check(err)
+    graph := chain.NewMempoolGraph(mempoolEntries)
+
+    type entryJSON struct {
+        TxID           string `json:"txid"`
+        Fee            int    `json:"fee"`
+        Size           int    `json:"size"`
+        Time           int64  `json:"time"`
+        Ancestors      int    `json:"ancestors"`
+        AncestorFee    int    `json:"ancestor_fee"`
+        AncestorSize   int    `json:"ancestor_size"`
+        Descendants    int    `json:"descendants"`
+        DescendantFee  int    `json:"descendant_fee"`
+        DescendantSize int    `json:"descendant_size"`
+    }
+    entries := []entryJSON{}
+
+    for _, id := range graph.Order {
+        entry := graph.Entries[id]
+        ancestors := graph.Ancestors(id, nil)
+        descendants := graph.Descendants(id)
+
+        ancestorFee, ancestorSize := graph.PackageFee(append(ancestors, id))
+        descendantFee, descendantSize := graph.PackageFee(append(descendants, id))
+
+        fmt.Printf("%s  fee: %d  size: %d\n", id, entry.Fee, entry.Tx.Size())
+        fmt.Printf("    ancestors: %d, with ancestors fee: %d, size: %d\n", len(ancestors), ancestorFee, ancestorSize)
+        fmt.Printf("    descendants: %d, with descendants fee: %d, size: %d\n", len(descendants), descendantFee, descendantSize)
+
+        entries = append(entries, entryJSON{
+            id, entry.Fee, entry.Tx.Size(), entry.Time,
+            len(ancestors), ancestorFee, ancestorSize,
+            len(descendants), descendantFee, descendantSize,
+        })
+    }
+
+    cli.setResult(struct {
+        Transactions []entryJSON `json:"transactions"`
+    }{entries})
+}
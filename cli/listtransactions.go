@@ -0,0 +1,64 @@
+package cli
+
+import (
+    "fmt"
+    "time"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 列出钱包最近的 count 条交易记录
+// address 不为空时只列出转入该地址或者由该地址发出的记录
+func (cli *CLI) listTransactions(address string, count int) {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+    bestHeight, err := bc.GetBestHeight()
+    cli.check(err)
+
+    history, err := wallet.NewWalletHistory()
+    cli.check(err)
+
+    var transactions []wallet.WalletTx
+    for _, wtx := range history.Transactions {
+        if address == "" || wtx.Involves(address) {
+            transactions = append(transactions, wtx)
+        }
+    }
+    if count > 0 && len(transactions) > count {
+        transactions = transactions[len(transactions) - count:]
+    }
+
+    wallets, _ := wallet.NewWallets()
+
+    type walletTxJSON struct {
+        TxID          string   `json:"txid"`
+        Category      string   `json:"category"`
+        Address       string   `json:"address"`
+        From          []string `json:"from,omitempty"`
+        Amount        int      `json:"amount"`
+        WatchOnly     bool     `json:"watch_only"`
+        Height        int64    `json:"height"`
+        BlockHash     string   `json:"block_hash"`
+        Confirmations int64    `json:"confirmations"`
+        Time          int64    `json:"time"`
+    }
+    result := []walletTxJSON{}
+
+    for _, wtx := range transactions {
+        watchOnly := ""
+        if wallets.IsWatchOnly(wtx.Address) { watchOnly = "  (watch-only)" }
+        result = append(result, walletTxJSON{
+            hex.EncodeToString(wtx.TxID), wtx.Category, wtx.Address, wtx.From, wtx.Amount, watchOnly != "",
+            wtx.Height, hex.EncodeToString(wtx.BlockHash), bestHeight - wtx.Height + 1, wtx.Timestamp,
+        })
+
+        fmt.Printf("%x  %-8s  %s  %d%s\n", wtx.TxID, wtx.Category, wtx.Address, wtx.Amount, watchOnly)
+        fmt.Printf("    height: %d, confirmations: %d, time: %s\n",
+            wtx.Height, bestHeight - wtx.Height + 1, time.Unix(wtx.Timestamp, 0).Format(time.RFC3339))
+    }
+
+    cli.setResult(struct {
+        Transactions []walletTxJSON `json:"transactions"`
+    }{result})
+}
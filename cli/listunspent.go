@@ -0,0 +1,48 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 列出未花费输出，address 为空时列出钱包全部地址（包括只读地址）的输出
+func (cli *CLI) listUnspent(address string, minConf int64) {
+    var addresses []string
+    if address != "" {
+        if !crypto.ValidateAddress(address) { cli.fail(ExitUsage, crypto.ErrInvalidAddress) }
+        addresses = []string{address}
+    } else {
+        wallets, err := wallet.NewWallets()
+        cli.check(err)
+        addresses = append(wallets.GetAddresses(), wallets.GetWatchOnlyAddresses()...)
+    }
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    type unspentJSON struct {
+        TxID          string `json:"txid"`
+        Vout          int    `json:"vout"`
+        Address       string `json:"address"`
+        Value         int    `json:"value"`
+        Confirmations int64  `json:"confirmations"`
+    }
+    unspent := []unspentJSON{}
+
+    utxos, err := bc.ListUnspent(addresses, minConf)
+    cli.check(err)
+    for _, utxo := range utxos {
+        address := crypto.PubKeyHashToAddress(utxo.Output.PubKeyHash)
+        fmt.Printf("%s  %s  %d  confirmations: %d\n",
+            types.OutpointKey(utxo.TxID, utxo.Index), address, utxo.Output.Value, utxo.Confirmations)
+
+        unspent = append(unspent, unspentJSON{hex.EncodeToString(utxo.TxID), utxo.Index, address, utxo.Output.Value, utxo.Confirmations})
+    }
+    cli.setResult(struct {
+        Unspent []unspentJSON `json:"unspent"`
+    }{unspent})
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+    "os"
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 在新的数据目录中加载快照，expectedHash 不为空时快照 hash 需与其相同
+func (cli *CLI) loadUTXOSet(file, expectedHash string) {
+    var expected []byte
+    if expectedHash != "" {
+        var err error
+        expected, err = hex.DecodeString(expectedHash)
+        cli.check(err)
+    }
+
+    if chain.DBExists() { cli.fail(ExitError, chain.ErrChainExists) }
+
+    f, err := os.Open(file)
+    cli.check(err)
+    defer f.Close()
+
+    snapshot, err := chain.LoadUTXOSnapshot(f, expected)
+    cli.check(err)
+
+    fmt.Printf("Loaded %d outputs at height %d, block %x\n", snapshot.Outputs, snapshot.Height, snapshot.BlockHash)
+    fmt.Printf("Hash: %x\n", snapshot.Hash)
+    fmt.Println("Blocks before the snapshot are not verified yet, run verifyutxoset with blocks from exportchain")
+    cli.setResult(NewSnapshotJSON(snapshot))
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 挖出一个新区块，按手续费率打包交易池中可以打包的交易
+func (cli *CLI) mine(miner string) {
+    if !crypto.ValidateAddress(miner) { cli.fail(ExitUsage, crypto.ErrInvalidAddress) }
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    height, medianTime, err := bc.NextBlockLockContext()
+    cli.check(err)
+    transactions, err := bc.Mempool().BlockTemplate(height, medianTime)
+    cli.check(err)
+
+    newBlock, err := mineBlock(bc, miner, transactions)
+    cli.check(err)
+    fmt.Printf("Mined block %d with %d transactions\n", newBlock.Number(), len(transactions))
+    cli.setResult(struct {
+        Block BlockJSON `json:"block"`
+    }{NewBlockJSON(newBlock)})
+}
+
+// 交易可以被写入下一个区块时立即挖矿，同时打包交易池中其他可以打包的交易，返回新区块
+// 否则（时间锁尚未到期）放入交易池，返回 nil
+// change 为交易的新找零地址，交易被加入交易池或写入区块之后才保存，见 wallet.NewAccountTransaction
+func commitTransaction(bc *chain.Blockchain, miner string, tx *types.Transaction, change *wallet.Wallet) (*types.Block, error) {
+    mempool := bc.Mempool()
+    height, medianTime, err := bc.NextBlockLockContext()
+    if err != nil { return nil, err }
+
+    if bc.CheckTransactionLocks(tx, chain.NewTxView(bc), height, medianTime) != nil {
+        err := mempool.Add(tx)
+        if err != nil { return nil, err }
+        if err := wallet.SaveChangeWallet(change); err != nil { return nil, err }
+
+        fmt.Printf("Transaction %x is time locked, added to mempool\n", tx.ID)
+        return nil, nil
+    }
+
+    transactions, err := mempool.BlockTemplate(height, medianTime)
+    if err != nil { return nil, err }
+
+    newBlock, err := bc.MineBlock(miner, append([]*types.Transaction{tx}, transactions...))
+    if err != nil { return nil, err }
+
+    // 钱包交易记录需要找零地址，在更新之前保存
+    if err := wallet.SaveChangeWallet(change); err != nil { return nil, err }
+    if err := blockConnected(bc, newBlock); err != nil { return nil, err }
+    return newBlock, nil
+}
+
+// 只放入交易池，等待 mine 打包
+// change 见 commitTransaction
+func (cli *CLI) queueTransaction(bc *chain.Blockchain, tx *types.Transaction, change *wallet.Wallet) {
+    err := bc.Mempool().Add(tx)
+    cli.check(err)
+    cli.check(wallet.SaveChangeWallet(change))
+
+    fmt.Printf("Transaction %x added to mempool\n", tx.ID)
+    cli.setResult(NewTxResultJSON(tx, nil))
+}
+
+// 挖出包含 transactions 的新区块
+func mineBlock(bc *chain.Blockchain, miner string, transactions []*types.Transaction) (*types.Block, error) {
+    newBlock, err := bc.MineBlock(miner, transactions)
+    if err != nil { return nil, err }
+
+    if err := blockConnected(bc, newBlock); err != nil { return nil, err }
+    return newBlock, nil
+}
+
+// 新区块连接之后（区块和 utxo 已写入，见 Blockchain.ConnectBlock），更新手续费统计、交易池和钱包交易记录
+func blockConnected(bc *chain.Blockchain, block *types.Block) error {
+    mempool := bc.Mempool()
+    entries, err := mempool.Entries()
+    if err != nil { return err }
+
+    if err := chain.UpdateFeeEstimates(block, entries); err != nil { return err }
+    if err := mempool.RemoveBlock(block); err != nil { return err }
+    if err := wallet.UpdateWalletHistory(bc, block); err != nil { return err }
+
+    _, err = bc.Prune()
+    return err
+}
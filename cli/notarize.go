@@ -0,0 +1,46 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 将文件的 sha256 写入链上
+// from 为空时从钱包内全部地址支付手续费
+func (cli *CLI) notarize(file, from string, fee int) {
+    hash, err := chain.HashFile(file)
+    cli.check(err)
+
+    selector, err := wallet.NewCoinSelector("")
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    recipients := []wallet.Recipient{{Data: chain.NotarizationData(hash)}}
+
+    var tx *types.Transaction
+    var change *wallet.Wallet
+    if from == "" {
+        tx, change, err = wallet.NewAccountTransaction(recipients, fee, 0, 0, false, selector, bc)
+        cli.check(err)
+        from = cli.walletMiner()
+    } else {
+        tx, err = wallet.NewSendManyTransaction([]string{from}, recipients, from, fee, 0, 0, false, selector, bc)
+        cli.check(err)
+    }
+
+    block, err := commitTransaction(bc, from, tx, change)
+    cli.check(err)
+    if block != nil {
+        fmt.Printf("Notarized %x in transaction %x\n", hash, tx.ID)
+    }
+    cli.setResult(struct {
+        Hash string `json:"hash"`
+        TxResultJSON
+    }{hex.EncodeToString(hash), NewTxResultJSON(tx, block)})
+}
@@ -0,0 +1,7 @@
+package cli
+
+// 原子交换的参与方，使用发起方的 secret hash 在另一条链上创建付给 to 的合约
+// timeout 应小于发起方合约的超时，保证参与方在发起方取回之前得到 secret
+func (cli *CLI) participate(from, to string, amount, fee int, timeout int64, secretHash string) {
+    cli.setResult(cli.createContract(from, to, amount, fee, timeout, cli.parseSecretHash(secretHash)))
+}
@@ -1,22 +1,29 @@
-package main
+package cli
 
 import (
     "fmt"
     "strconv"
+
     "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/consensus"
 )
 
 // print each block and validate pow.
 func (cli *CLI) printChain() {
-    bc := NewBlockchain()
+    bc := cli.openBlockchain()
+    defer bc.Close()
     bci := bc.Iterator()
 
+    blocks := []BlockJSON{}
     for {
-        block := bci.Next()
+        block, err := bci.Next()
+        cli.check(err)
+        blocks = append(blocks, NewBlockJSON(block))
 
         fmt.Printf("============ Block %v %x ============\n", block.Number(), block.Hash)
         fmt.Printf("Parent hash: %x\n", block.ParentHash())
-        pow := NewProofOfWork(block)
+        if (block.UTXORoot() != common.Hash{}) { fmt.Printf("UTXO root: %x\n", block.UTXORoot()) }
+        pow := consensus.NewProofOfWork(block)
         fmt.Printf("PoW: %s\n", strconv.FormatBool(pow.Validate()))
         fmt.Printf("Transactions: ")
         for _, tx := range block.Transactions {
@@ -27,4 +34,8 @@ func (cli *CLI) printChain() {
 
         if (block.ParentHash() == common.Hash{}) { break }
     }
+
+    cli.setResult(struct {
+        Blocks []BlockJSON `json:"blocks"`
+    }{blocks})
 }
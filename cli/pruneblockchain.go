@@ -0,0 +1,37 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 设置修剪目标并立即修剪
+func (cli *CLI) pruneBlockchain(prune string) {
+    target, err := chain.ParsePruneTarget(prune)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    type pruneJSON struct {
+        Target       string `json:"target,omitempty"` // 为空时不修剪
+        PrunedHeight int64  `json:"pruned_height"`
+    }
+
+    err = bc.SetPruneTarget(target)
+    cli.check(err)
+    if target == nil {
+        height, err := bc.PruneHeight()
+        cli.check(err)
+
+        fmt.Printf("Pruning turned off, pruned height %d\n", height)
+        cli.setResult(pruneJSON{"", height})
+        return
+    }
+
+    height, err := bc.Prune()
+    cli.check(err)
+    fmt.Printf("Pruning to %s, pruned height %d\n", target, height)
+    cli.setResult(pruneJSON{target.String(), height})
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+    "os"
+    "errors"
+    "testing"
+    "math/big"
+    "crypto/sha256"
+    "encoding/json"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 创建高度为 height 的区块链，区块没有工作量证明
+func newPruneTestChain(t *testing.T, height int) {
+    wallets, _ := wallet.NewWallets()
+    address, err := wallets.CreateWallet()
+    if err != nil { t.Fatal(err) }
+
+    bc, err := chain.CreateBlockchain(address)
+    if err != nil { t.Fatal(err) }
+    defer bc.Close()
+
+    parent, err := bc.GetBlock(bc.Tip())
+    if err != nil { t.Fatal(err) }
+    for i := 1; i <= height; i++ {
+        number := big.NewInt(int64(i))
+        header := &types.Header{ParentHash: parent.Hash, Number: number, Timestamp: big.NewInt(int64(i))}
+        block := &types.Block{Header: header, Transactions: []*types.Transaction{types.NewRewardTx(address, "", 0)}}
+        block.HashTransactions()
+        block.Hash = sha256.Sum256(append(parent.Hash.Bytes(), number.Bytes()...))
+
+        if err := bc.ConnectBlock(block); err != nil { t.Fatal(err) }
+        parent = block
+    }
+}
+
+func TestPruneBlockchain(t *testing.T) {
+    t.Chdir(t.TempDir())
+    if err := os.Mkdir("data", 0755); err != nil { t.Fatal(err) }
+    newPruneTestChain(t, chain.MinPruneDepth + 4)
+
+    tests := []struct {
+        prune string
+        want  string
+    }{
+        // 修剪到重组窗口之前，关闭修剪之后修剪高度不变
+        {"6", `{"target":"depth 6","pruned_height":4}`},
+        {"8", `{"target":"depth 8","pruned_height":4}`},
+        {"1KB", `{"target":"size 1024 bytes","pruned_height":4}`},
+        {"0", `{"pruned_height":4}`},
+    }
+    for _, test := range tests {
+        cli := &CLI{}
+        cli.pruneBlockchain(test.prune)
+
+        data, err := json.Marshal(cli.result)
+        if err != nil { t.Fatal(err) }
+        if string(data) != test.want { t.Errorf("pruneblockchain -prune %s: got %s, want %s", test.prune, data, test.want) }
+    }
+
+    bc, err := chain.NewBlockchain()
+    if err != nil { t.Fatal(err) }
+    defer bc.Close()
+
+    if err := bc.CheckBlocksAvailable(4); !errors.Is(err, chain.ErrBlockPruned) { t.Errorf("got %v, want %v", err, chain.ErrBlockPruned) }
+    if target, err := bc.PruneTarget(); err != nil || target != nil { t.Errorf("PruneTarget() = %v, %v, want nil", target, err) }
+}
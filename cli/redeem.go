@@ -0,0 +1,34 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 提供 secret 取走合约的币
+func (cli *CLI) redeem(contract, secret string, fee int) {
+    txID, vout, err := types.ParseOutpoint(contract)
+    cli.check(err)
+
+    secretBytes, err := hex.DecodeString(secret)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    tx, err := wallet.NewHTLCSpendTransaction(txID, vout, secretBytes, fee, bc)
+    cli.check(err)
+    block, err := commitTransaction(bc, crypto.PubKeyHashToAddress(tx.Vout[0].PubKeyHash), tx, nil)
+    cli.check(err)
+    if block != nil {
+        fmt.Printf("Redeemed contract %s in transaction %x\n", contract, tx.ID)
+    }
+    cli.setResult(struct {
+        Contract string `json:"contract"`
+        TxResultJSON
+    }{contract, NewTxResultJSON(tx, block)})
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 超时之后取回合约的币
+// 超时之前交易保存在交易池中，超时之后由 mine 打包
+func (cli *CLI) refund(contract string, fee int) {
+    txID, vout, err := types.ParseOutpoint(contract)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    tx, err := wallet.NewHTLCSpendTransaction(txID, vout, nil, fee, bc)
+    cli.check(err)
+    block, err := commitTransaction(bc, crypto.PubKeyHashToAddress(tx.Vout[0].PubKeyHash), tx, nil)
+    cli.check(err)
+    if block != nil {
+        fmt.Printf("Refunded contract %s in transaction %x\n", contract, tx.ID)
+    }
+    cli.setResult(struct {
+        Contract string `json:"contract"`
+        TxResultJSON
+    }{contract, NewTxResultJSON(tx, block)})
+}
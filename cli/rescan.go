@@ -0,0 +1,36 @@
+package cli
+
+import (
+    "fmt"
+    "errors"
+
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 从 height 开始重新扫描区块链，重建钱包交易记录
+func (cli *CLI) rescan(height int64) {
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    err := bc.CheckBlocksAvailable(height)
+    cli.check(err)
+
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    // 没有交易记录文件时从空的记录开始
+    history, err := wallet.NewWalletHistory()
+    if !errors.Is(err, wallet.ErrWalletHistoryNotFound) { cli.check(err) }
+
+    err = history.Rescan(bc, wallets, height)
+    cli.check(err)
+    cli.check(history.SaveToFile())
+
+    fmt.Printf("Rescanned from height %d to %d, %d wallet transactions\n",
+        height, history.ScannedHeight, len(history.Transactions))
+    cli.setResult(struct {
+        From         int64 `json:"from"`
+        To           int64 `json:"to"`
+        Transactions int   `json:"transactions"`
+    }{height, history.ScannedHeight, len(history.Transactions)})
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// from 为空时从钱包内全部地址转账
+// fee 小于 0 时使用 DefaultConfirmTarget 个区块内确认的估计手续费率
+// lockTime 不为 0 时，交易在 lockTime 之后才能被写入区块，在此之前保存在交易池中
+// relativeLock 不为 0 时为输入的相对时间锁，见 relativeLockSequence
+// replaceable 时交易可以被 bumpfee 替换，queue 时只放入交易池，由 mine 打包
+// raw 时只输出签名后的交易，不打包
+// unsigned 时只构造交易并输出，不签名也不打包，from 可以是只读地址
+func (cli *CLI) send(from, to string, amount, fee int, lockTime int64, relativeLock uint32, strategy string, replaceable, queue, raw, unsigned bool) {
+    selector, err := wallet.NewCoinSelector(strategy)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    if fee < 0 { fee = cli.estimatedFee(bc) }
+
+    recipients := []wallet.Recipient{{Address: to, Amount: amount}}
+
+    if unsigned {
+        tx, err := wallet.NewUnsignedTransaction([]string{from}, recipients, from, fee, lockTime, relativeLock, replaceable, selector, bc)
+        cli.check(err)
+        cli.printUnsignedTransaction(tx)
+        return
+    }
+
+    // 账户级别转账的新找零地址，交易发送成功之后才保存
+    var tx *types.Transaction
+    var change *wallet.Wallet
+    if from == "" {
+        tx, change, err = wallet.NewAccountTransaction(recipients, fee, lockTime, relativeLock, replaceable, selector, bc)
+        cli.check(err)
+        from = cli.walletMiner()
+    } else {
+        tx, err = wallet.NewSendManyTransaction([]string{from}, recipients, from, fee, lockTime, relativeLock, replaceable, selector, bc)
+        cli.check(err)
+    }
+
+    if raw {
+        // 输出的交易可能在其他地方发送，找零地址需要保存
+        cli.check(wallet.SaveChangeWallet(change))
+        cli.printSignedTransaction(tx)
+        return
+    }
+    if queue {
+        cli.queueTransaction(bc, tx, change)
+        return
+    }
+    block, err := commitTransaction(bc, from, tx, change)
+    cli.check(err)
+    cli.setResult(NewTxResultJSON(tx, block))
+    if block != nil { fmt.Println("success!") }
+}
+
+// 没有指定手续费时使用估计的手续费率
+func (cli *CLI) estimatedFee(bc *chain.Blockchain) int {
+    fee, err := chain.EstimateFeeRate(bc, chain.DefaultConfirmTarget)
+    cli.check(err)
+    fmt.Printf("Using estimated fee %d per input and output\n", fee)
+
+    return fee
+}
+
+// 账户级别转账时没有指定的发送方，由钱包的第一个地址挖矿
+func (cli *CLI) walletMiner() string {
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    return wallets.GetAddresses()[0]
+}
+
+// -relativelock 和 -relativetime 对应的输入 Sequence，见 types.RelativeLockSequence
+// 两者最多设置一个，seconds 向上取整为 512 秒的整数倍，0 表示没有相对时间锁，超出范围时返回 false
+func relativeLockSequence(blocks, seconds int64) (uint32, bool) {
+    limit := int64(types.SequenceLockTimeMask)
+    granularity := int64(1) << types.SequenceLockTimeGranularity
+
+    switch {
+    case blocks < 0 || seconds < 0 || (blocks > 0 && seconds > 0):
+        return 0, false
+    case seconds > 0:
+        units := (seconds + granularity - 1) / granularity
+        if units > limit { return 0, false }
+        return types.RelativeLockSequence(uint32(units), true), true
+    default:
+        if blocks > limit { return 0, false }
+        return types.RelativeLockSequence(uint32(blocks), false), true
+    }
+}
+
+// 输出待签名交易的 hex 编码
+func (cli *CLI) printUnsignedTransaction(tx *types.Transaction) {
+    fmt.Printf("Unsigned transaction %x:\n", tx.ID)
+    fmt.Printf("%x\n", tx.Serialize())
+    cli.setResult(TxResultJSON{hex.EncodeToString(tx.ID), TxStatusUnsigned, nil, hex.EncodeToString(tx.Serialize())})
+}
+
+// 输出签名后交易的 hex 编码，可以由 submitpackage 或 cpfp 加入交易池
+func (cli *CLI) printSignedTransaction(tx *types.Transaction) {
+    fmt.Printf("Signed transaction %x:\n", tx.ID)
+    fmt.Printf("%x\n", tx.Serialize())
+    cli.setResult(TxResultJSON{hex.EncodeToString(tx.ID), TxStatusSigned, nil, hex.EncodeToString(tx.Serialize())})
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+    "os"
+    "fmt"
+    "strings"
+    "strconv"
+    "io/ioutil"
+    "encoding/csv"
+    "encoding/json"
+    "path/filepath"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 向多个收款方转账，只产生一笔交易
+// from: 以逗号分隔的钱包地址，为空时从钱包内全部地址转账
+// to: ADDRESS:AMOUNT,ADDRESS:AMOUNT 或者 file: CSV/JSON 文件
+// fee 小于 0 时使用估计的手续费率
+// lockTime 不为 0 时，交易在 lockTime 之后才能被写入区块
+// relativeLock 不为 0 时为输入的相对时间锁，见 relativeLockSequence
+// replaceable 时交易可以被 bumpfee 替换，queue 时只放入交易池，由 mine 打包
+// raw 时只输出签名后的交易，不打包
+// unsigned 时只构造交易并输出，不签名也不打包
+func (cli *CLI) sendMany(from, to, file string, fee int, lockTime int64, relativeLock uint32, strategy string, replaceable, queue, raw, unsigned bool) {
+    selector, err := wallet.NewCoinSelector(strategy)
+    cli.check(err)
+
+    var recipients []wallet.Recipient
+    if file != "" {
+        recipients = cli.loadRecipients(file)
+    } else {
+        recipients = cli.parseRecipients(to)
+    }
+    if len(recipients) == 0 { cli.fail(ExitUsage, "ERROR: No recipients") }
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    if fee < 0 { fee = cli.estimatedFee(bc) }
+
+    if unsigned {
+        addresses := strings.Split(from, ",")
+        tx, err := wallet.NewUnsignedTransaction(addresses, recipients, addresses[0], fee, lockTime, relativeLock, replaceable, selector, bc)
+        cli.check(err)
+        cli.printUnsignedTransaction(tx)
+        return
+    }
+
+    // 账户级别转账的新找零地址，交易发送成功之后才保存
+    var tx *types.Transaction
+    var change *wallet.Wallet
+    var miner string
+    if from == "" {
+        tx, change, err = wallet.NewAccountTransaction(recipients, fee, lockTime, relativeLock, replaceable, selector, bc)
+        cli.check(err)
+        miner = cli.walletMiner()
+    } else {
+        addresses := strings.Split(from, ",")
+        tx, err = wallet.NewSendManyTransaction(addresses, recipients, addresses[0], fee, lockTime, relativeLock, replaceable, selector, bc)
+        cli.check(err)
+        miner = addresses[0]
+    }
+
+    if raw {
+        // 输出的交易可能在其他地方发送，找零地址需要保存
+        cli.check(wallet.SaveChangeWallet(change))
+        cli.printSignedTransaction(tx)
+        return
+    }
+    if queue {
+        cli.queueTransaction(bc, tx, change)
+        return
+    }
+    block, err := commitTransaction(bc, miner, tx, change)
+    cli.check(err)
+    cli.setResult(NewTxResultJSON(tx, block))
+    if block != nil { fmt.Printf("success! %x\n", tx.ID) }
+}
+
+// 解析 ADDRESS:AMOUNT,ADDRESS:AMOUNT
+func (cli *CLI) parseRecipients(to string) []wallet.Recipient {
+    var recipients []wallet.Recipient
+
+    for _, pair := range strings.Split(to, ",") {
+        fields := strings.Split(pair, ":")
+        if len(fields) != 2 { cli.fail(ExitUsage, "ERROR: Invalid recipient: " + pair) }
+
+        recipients = append(recipients, cli.newRecipient(fields[0], fields[1]))
+    }
+    return recipients
+}
+
+/*
+从文件读取收款方
+    .json: [{"address": "...", "amount": 10}, ...]
+    其他: 每行 address,amount 的 CSV，可以有 address,amount 表头
+*/
+func (cli *CLI) loadRecipients(file string) []wallet.Recipient {
+    var recipients []wallet.Recipient
+
+    if strings.ToLower(filepath.Ext(file)) == ".json" {
+        content, err := ioutil.ReadFile(file)
+        cli.check(err)
+
+        var entries []struct {
+            Address string `json:"address"`
+            Amount  int    `json:"amount"`
+        }
+        err = json.Unmarshal(content, &entries)
+        cli.check(err)
+
+        for _, entry := range entries {
+            recipients = append(recipients, wallet.Recipient{Address: entry.Address, Amount: entry.Amount})
+        }
+        return recipients
+    }
+
+    f, err := os.Open(file)
+    cli.check(err)
+    defer f.Close()
+
+    reader := csv.NewReader(f)
+    reader.TrimLeadingSpace = true
+    records, err := reader.ReadAll()
+    cli.check(err)
+
+    for i, record := range records {
+        if len(record) != 2 { cli.fail(ExitUsage, "ERROR: Invalid recipient: " + strings.Join(record, ",")) }
+        if i == 0 && strings.EqualFold(record[0], "address") { continue }
+
+        recipients = append(recipients, cli.newRecipient(record[0], record[1]))
+    }
+    return recipients
+}
+
+func (cli *CLI) newRecipient(address, amount string) wallet.Recipient {
+    value, err := strconv.Atoi(strings.TrimSpace(amount))
+    if err != nil { cli.fail(ExitUsage, "ERROR: Invalid amount: " + amount) }
+
+    return wallet.Recipient{Address: strings.TrimSpace(address), Amount: value}
+}
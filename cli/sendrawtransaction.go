@@ -0,0 +1,16 @@
+package cli
+
+import (
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 校验签名后的交易并加入交易池，由 mine 打包
+func (cli *CLI) sendRawTransaction(txHex string) {
+    tx, err := types.DecodeRawTransaction(txHex)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    cli.queueTransaction(bc, tx, nil)
+}
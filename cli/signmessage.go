@@ -0,0 +1,29 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/base64"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 用 address 的私钥对 message 签名，输出 base64 编码的签名
+func (cli *CLI) signMessage(address, message string) {
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    if _, ok := wallets.Wallets[address]; !ok {
+        cli.fail(ExitError, fmt.Errorf("%w: %s has no private key", wallet.ErrUnknownAddress, address))
+    }
+    wallet := wallets.GetWallet(address)
+
+    signature, err := crypto.SignMessage(wallet.PrivateKey, message)
+    cli.check(err)
+
+    fmt.Println(base64.StdEncoding.EncodeToString(signature))
+    cli.setResult(struct {
+        Address   string `json:"address"`
+        Signature string `json:"signature"` // base64
+    }{address, base64.StdEncoding.EncodeToString(signature)})
+}
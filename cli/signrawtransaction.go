@@ -0,0 +1,26 @@
+package cli
+
+import (
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/wallet"
+)
+
+// 使用钱包内的私钥签名 hex 编码的交易，输出签名后交易的 hex 编码
+func (cli *CLI) signRawTransaction(txHex string) {
+    tx, err := types.DecodeRawTransaction(txHex)
+    cli.check(err)
+
+    wallets, err := wallet.NewWallets()
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    view, err := bc.Mempool().View()
+    cli.check(err)
+
+    err = wallet.SignRawTransaction(tx, wallets, view)
+    cli.check(err)
+
+    cli.printSignedTransaction(tx)
+}
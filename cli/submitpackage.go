@@ -0,0 +1,45 @@
+package cli
+
+import (
+    "fmt"
+    "strings"
+    "io/ioutil"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 将文件中 hex 编码的一组交易加入交易池，每行一笔，父交易在前
+func (cli *CLI) submitPackage(file string) {
+    content, err := ioutil.ReadFile(file)
+    cli.check(err)
+
+    var txs []*types.Transaction
+    for _, line := range strings.Split(string(content), "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" { continue }
+
+        data, err := hex.DecodeString(line)
+        cli.check(err)
+
+        tx, err := types.DeserializeTransaction(data)
+        cli.check(err)
+        txs = append(txs, &tx)
+    }
+    if len(txs) == 0 { cli.fail(ExitUsage, "ERROR: No transactions in the package") }
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    err = bc.Mempool().AddPackage(txs)
+    cli.check(err)
+
+    var results []TxResultJSON
+    for _, tx := range txs {
+        fmt.Printf("Transaction %x added to mempool\n", tx.ID)
+        results = append(results, NewTxResultJSON(tx, nil))
+    }
+    cli.setResult(struct {
+        Transactions []TxResultJSON `json:"transactions"`
+    }{results})
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 校验区块链的完整性，发现错误时以非 0 退出
+// 不在打开时重建 utxo，utxo 与区块不一致时由 level 3 报告
+func (cli *CLI) verifyChain(level int, depth int64) {
+    bc, err := chain.NewBlockchainWithoutRepair()
+    cli.check(err)
+
+    checked, err := bc.VerifyChain(level, depth)
+    bc.Close()
+
+    type verifyJSON struct {
+        Valid  bool   `json:"valid"`
+        Level  int    `json:"level"`
+        Blocks int    `json:"blocks"`
+        Error  string `json:"error,omitempty"`
+    }
+    if err != nil {
+        fmt.Println(err)
+        cli.setResult(verifyJSON{false, level, checked, err.Error()})
+        cli.exit(ExitFailed)
+    }
+
+    fmt.Printf("Verified %d blocks at level %d\n", checked, level)
+    cli.setResult(verifyJSON{true, level, checked, ""})
+}
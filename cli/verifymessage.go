@@ -0,0 +1,29 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/base64"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+// 验证 signature 是否由 address 对 message 签名，验证失败时返回非零退出码
+func (cli *CLI) verifyMessage(address, signature, message string) {
+    if !crypto.ValidateAddress(address) { cli.fail(ExitUsage, crypto.ErrInvalidAddress) }
+
+    sig, err := base64.StdEncoding.DecodeString(signature)
+    cli.check(err)
+
+    type verifyJSON struct {
+        Address string `json:"address"`
+        Valid   bool   `json:"valid"`
+    }
+
+    if !crypto.VerifyMessage(address, sig, message) {
+        fmt.Println("Signature is invalid")
+        cli.setResult(verifyJSON{address, false})
+        cli.exit(ExitFailed)
+    }
+    fmt.Println("Signature is valid")
+    cli.setResult(verifyJSON{address, true})
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+    "fmt"
+    "time"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 找到文件被公证的区块和时间，没有被公证时返回非零退出码
+func (cli *CLI) verifyNotarization(file string) {
+    hash, err := chain.HashFile(file)
+    cli.check(err)
+
+    bc := cli.openBlockchain()
+    defer bc.Close()
+
+    type notarizationJSON struct {
+        Hash      string        `json:"hash"`
+        Notarized bool          `json:"notarized"`
+        TxID      string        `json:"txid,omitempty"`
+        Block     *BlockRefJSON `json:"block,omitempty"`
+        Time      int64         `json:"time,omitempty"`
+    }
+
+    tx, block, err := bc.FindNotarization(hash)
+    if err == chain.ErrNotarizationNotFound {
+        fmt.Printf("Document %x has not been notarized\n", hash)
+        bc.Close()
+        cli.setResult(notarizationJSON{hex.EncodeToString(hash), false, "", nil, 0})
+        cli.exit(ExitFailed)
+    }
+    if err != nil {
+        bc.Close()
+        cli.check(err)
+    }
+
+    fmt.Printf("Document %x\n", hash)
+    fmt.Printf("Transaction: %x\n", tx.ID)
+    fmt.Printf("Block:       %d %x\n", block.Number(), block.Hash)
+    fmt.Printf("Time:        %s\n", time.Unix(block.Timestamp().Int64(), 0).UTC().Format(time.RFC3339))
+    cli.setResult(notarizationJSON{hex.EncodeToString(hash), true, hex.EncodeToString(tx.ID), NewBlockRefJSON(block), block.Timestamp().Int64()})
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+    "fmt"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+// 校验 getutxoproof 生成的证明，rootHex 为空时使用最新区块头中的 UTXORoot
+func (cli *CLI) verifyUTXOProof(proofHex, rootHex string) {
+    data, err := hex.DecodeString(proofHex)
+    cli.check(err)
+
+    proof, err := utxo.DeserializeUTXOProof(data)
+    cli.check(err)
+
+    var expected common.Hash
+    if rootHex != "" {
+        b, err := hex.DecodeString(rootHex)
+        cli.check(err)
+        if len(b) != common.HashLength { cli.fail(ExitUsage, "ERROR: Root must be a 32 byte hash") }
+        expected.SetBytes(b)
+    } else {
+        bc := cli.openBlockchain()
+        tip, err := bc.GetBlock(bc.Tip())
+        bc.Close()
+        cli.check(err)
+        expected = tip.UTXORoot()
+
+        if (expected == common.Hash{}) { cli.fail(ExitError, "ERROR: The latest block has no UTXO root, use -root") }
+    }
+
+    type verifyJSON struct {
+        Valid bool `json:"valid"`
+        UTXOProofJSON
+    }
+    result := verifyJSON{false, UTXOProofJSON{nil, hex.EncodeToString(expected.Bytes()), newProofOutpointJSON(proof), ""}}
+
+    root, err := proof.Root()
+    if err != nil || root != expected {
+        fmt.Printf("Invalid proof for root %x\n", expected)
+        cli.setResult(result)
+        cli.exit(ExitFailed)
+    }
+    result.Valid = true
+    cli.setResult(result)
+
+    outpoint := types.OutpointKey(proof.TxID, proof.Vout)
+    if proof.Output != nil {
+        fmt.Printf("Valid: output %s is unspent, value %d, locked to %s\n", outpoint, proof.Output.Value, crypto.PubKeyHashToAddress(proof.Output.PubKeyHash))
+    } else {
+        fmt.Printf("Valid: output %s does not exist or is spent\n", outpoint)
+    }
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+    "os"
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+)
+
+// 校验已加载的快照，blocksFile 为 exportchain 导出的区块
+// 重放区块期间不打开数据库，其他命令可以同时运行
+func (cli *CLI) verifyUTXOSet(blocksFile string) {
+    bc := cli.openBlockchain()
+    snapshot, err := bc.LoadedSnapshot()
+    bc.Close()
+    cli.check(err)
+
+    if snapshot == nil { cli.fail(ExitError, "ERROR: No UTXO snapshot has been loaded") }
+    if snapshot.Verified {
+        fmt.Printf("Snapshot at height %d is already verified\n", snapshot.Height)
+        cli.setResult(NewSnapshotJSON(snapshot))
+        return
+    }
+
+    f, err := os.Open(blocksFile)
+    cli.check(err)
+    defer f.Close()
+
+    err = chain.VerifyUTXOSnapshot(snapshot, f)
+    if err != nil {
+        fmt.Println(err)
+        cli.setResult(struct {
+            SnapshotJSON
+            Error string `json:"error"`
+        }{NewSnapshotJSON(snapshot), err.Error()})
+        cli.exit(ExitFailed)
+    }
+
+    bc = cli.openBlockchain()
+    err = bc.MarkSnapshotVerified()
+    bc.Close()
+    cli.check(err)
+
+    fmt.Printf("Snapshot at height %d verified, hash %x\n", snapshot.Height, snapshot.Hash)
+    snapshot.Verified = true
+    cli.setResult(NewSnapshotJSON(snapshot))
+}
@@ -1,19 +1,11 @@
-package main
+package common
 
 import (
-    "bytes"
     "encoding/binary"
-    "log"
 )
 
 func IntToHex(num int64) []byte {
-    buff := new(bytes.Buffer)
-    err := binary.Write(buff, binary.BigEndian, num)
-    if err != nil {
-        log.Panic(err)
-    }
-
-    return buff.Bytes()
+    return Int64ToBytes(num)
 }
 
 func ReverseBytes(data []byte) {
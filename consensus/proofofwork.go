@@ -0,0 +1,127 @@
+package consensus
+
+import (
+    "math"
+    "math/big"
+    "bytes"
+    "crypto/sha256"
+    "fmt"
+    "time"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+const targetBits = 22
+
+type ProofOfWork struct {
+    block *types.Block
+    target *big.Int
+}
+
+func NewProofOfWork(b *types.Block) *ProofOfWork {
+    // big.NewInt(1) 左移 256 - targetBits 位 (即 2 的 256 - targetBits - 1 次方)
+    target := big.NewInt(1)
+    target.Lsh(target, uint(256 - targetBits))
+
+    pow := &ProofOfWork{b, target}
+
+    return pow
+}
+
+// 没有 UTXORoot 的旧区块不包含该字段，工作量证明保持不变
+func (pow *ProofOfWork) prepareData(nonce uint64) []byte {
+    fields := [][]byte{pow.block.ParentHash().Bytes(), pow.block.TxHash().Bytes()}
+    if (pow.block.UTXORoot() != common.Hash{}) { fields = append(fields, pow.block.UTXORoot().Bytes()) }
+
+    // bytes.Join f func(s [][]byte, sep []byte) []byte
+    data := bytes.Join(
+        append(fields,
+            pow.block.Timestamp().Bytes(),
+            common.IntToHex(int64(targetBits)),
+            common.IntToHex(int64(nonce)),
+        ),
+        []byte{},
+    )
+    return data
+}
+
+func (pow *ProofOfWork) Run() (uint64, []byte) {
+    var hashInt big.Int
+    var hash [32]byte
+    nonce := uint64(0)
+
+    fmt.Print("Mining the block containing ")
+    for _, tx := range pow.block.Transactions {
+        fmt.Printf("%x, ", tx.ID)
+    }
+
+    for nonce < math.MaxInt64 {
+        // 从0开始累加nonce，反复计算直至区块hash值小于目标值
+        data := pow.prepareData(nonce)
+        hash = sha256.Sum256(data)
+        hashInt.SetBytes(hash[:])
+
+        // big.Int.Cmp    f func(y *big.Int) (r int)
+        if hashInt.Cmp(pow.target) == -1 {
+            fmt.Printf("\r%x\n", hash)
+            break
+        } else {
+            nonce ++
+        }
+    }
+    fmt.Printf("\n\n")
+
+    return nonce, hash[:]
+}
+
+// 根据区块头和 nonce 计算的区块 hash
+func (pow *ProofOfWork) Hash() []byte {
+    hash := sha256.Sum256(pow.prepareData(pow.block.Nonce()))
+    return hash[:]
+}
+
+// Validate block's Pow
+func (pow *ProofOfWork) Validate() bool {
+	var hashInt big.Int
+
+	data := pow.prepareData(pow.block.Nonce())
+	hash := sha256.Sum256(data)
+	hashInt.SetBytes(hash[:])
+
+	isValid := hashInt.Cmp(pow.target) == -1
+
+	return isValid
+}
+
+// 获取一个新区块
+// @param: miner: []byte: 挖出区块的矿工
+// @param: parent: *Block: 上一个区块
+// @param: transactions: []*Transaction: 待写入的交易
+// @param: utxoRoot: common.Hash: 写入交易之后的 utxo 承诺
+// @param: medianTime: int64: 父区块的 MedianTimePast，区块时间戳为当前时间，但至少为 medianTime + 1
+// @return: *Block
+func NewBlock(miner string, parent *types.Block, transactions []*types.Transaction, utxoRoot common.Hash, medianTime int64) *types.Block {
+    var parentHash common.Hash
+    var blockNumber big.Int
+    if parent != nil {
+        parentHash = parent.Hash
+        blockNumber = *new(big.Int).Add(parent.Number(), big.NewInt(1))
+    }
+
+    timestamp := time.Now().Unix()
+    if parent != nil && timestamp <= medianTime { timestamp = medianTime + 1 }
+
+    header := &types.Header{ParentHash: parentHash, Miner: common.HexToAddress(miner), UTXORoot: utxoRoot, Number: &blockNumber, Timestamp: big.NewInt(timestamp)}
+    block := &types.Block{Header: header, Transactions: transactions}
+
+    if len(transactions) > 0 {
+        block.HashTransactions()
+    }
+    pow := NewProofOfWork(block)
+    nonce, hash := pow.Run()
+    block.Header.Nonce = types.EncodeNonce(uint64(nonce))
+    block.Hash.SetBytes(hash)
+
+    return block
+}
@@ -0,0 +1,31 @@
+package consensus
+
+import (
+    "time"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/common"
+)
+
+// 新区块的时间戳为当前时间，但至少为父区块的 MedianTimePast + 1
+func TestNewBlockTimestamp(t *testing.T) {
+    genesis := NewBlock(testAddress, nil, newTestTransactions()[:1], common.Hash{}, 0)
+    now := time.Now().Unix()
+
+    tests := []struct {
+        name       string
+        medianTime int64
+        min, max   int64
+    }{
+        {"median time past is earlier", now - 1000, now, now + 60},
+        {"median time past is now", now, now + 1, now + 60},
+        {"median time past is later", now + 1000, now + 1001, now + 1001},
+    }
+
+    for _, test := range tests {
+        block := NewBlock(testAddress, genesis, newTestTransactions()[:1], common.Hash{}, test.medianTime)
+        timestamp := block.Timestamp().Int64()
+        if timestamp < test.min || timestamp > test.max { t.Errorf("%s: timestamp %d, want between %d and %d", test.name, timestamp, test.min, test.max) }
+        if err := CheckBlockHeader(block, genesis, test.medianTime); err != nil { t.Errorf("%s: %v", test.name, err) }
+    }
+}
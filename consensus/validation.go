@@ -0,0 +1,64 @@
+package consensus
+
+import (
+    "bytes"
+    "errors"
+    "math/big"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+var ErrInvalidBlockHeader = errors.New("ERROR: Invalid block: header does not follow its parent")
+var ErrInvalidProofOfWork = errors.New("ERROR: Invalid block: proof of work")
+var ErrInvalidMerkleRoot = errors.New("ERROR: Invalid block: merkle root does not match transactions")
+var ErrInvalidCoinbase = errors.New("ERROR: Invalid block: coinbase transaction")
+var ErrDuplicateTransaction = errors.New("ERROR: Invalid block: duplicate transaction")
+var ErrMissingUTXORoot = errors.New("ERROR: Invalid block: UTXO root is missing")
+var ErrMissingTxHash = errors.New("ERROR: Invalid block: merkle root is missing")
+var ErrInvalidBlockTime = errors.New("ERROR: Invalid block: timestamp is not after the median time past")
+
+// 校验区块头：父区块 hash 和高度，时间戳，merkle root，工作量证明
+// medianTime 为父区块的 MedianTimePast，区块的时间戳必须大于它，创世区块不校验
+// 父区块有 merkle root 时不能为空，否则工作量证明不包含交易，挖出之后可以替换其中的交易
+func CheckBlockHeader(block *types.Block, parent *types.Block, medianTime int64) error {
+    if parent == nil {
+        if (block.ParentHash() != common.Hash{}) || block.Number().Sign() != 0 { return ErrInvalidBlockHeader }
+    } else {
+        if block.ParentHash() != parent.Hash { return ErrInvalidBlockHeader }
+        if block.Number().Cmp(new(big.Int).Add(parent.Number(), big.NewInt(1))) != 0 { return ErrInvalidBlockHeader }
+        if block.Timestamp().Int64() <= medianTime { return ErrInvalidBlockTime }
+        if (block.TxHash() == common.Hash{}) && (parent.TxHash() != common.Hash{}) { return ErrMissingTxHash }
+    }
+
+    pow := NewProofOfWork(block)
+    if !pow.Validate() || bytes.Compare(pow.Hash(), block.Hash.Bytes()) != 0 { return ErrInvalidProofOfWork }
+
+    return nil
+}
+
+// 校验区块内交易的结构，不需要 utxo：
+//     第一笔且只有第一笔为奖励交易，交易 ID 与交易内容一致且不重复，merkle root 与交易一致
+// TxHash 为空的区块为写入 merkle root 之前产生的区块，不校验 merkle root
+// 这样的区块只能出现在区块链的开头，见 CheckBlockHeader
+func CheckBlockTransactions(block *types.Block) error {
+    if len(block.Transactions) == 0 || !block.Transactions[0].IsCoinbase() { return ErrInvalidCoinbase }
+
+    seen := make(map[string]bool)
+    for i, tx := range block.Transactions {
+        if i > 0 && tx.IsCoinbase() { return ErrInvalidCoinbase }
+        // 写入 merkle root 之前产生的区块，交易 ID 按旧的交易结构计算，无法校验
+        if (block.TxHash() != common.Hash{}) {
+            if err := tx.CheckID(); err != nil { return err }
+        }
+
+        id := hex.EncodeToString(tx.ID)
+        if seen[id] { return ErrDuplicateTransaction }
+        seen[id] = true
+    }
+
+    if (block.TxHash() != common.Hash{}) && block.TxHash() != block.MerkleRoot() { return ErrInvalidMerkleRoot }
+
+    return nil
+}
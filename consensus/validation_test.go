@@ -0,0 +1,114 @@
+package consensus
+
+import (
+    "bytes"
+    "errors"
+    "math/big"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+var testAddress = crypto.PubKeyHashToAddress(bytes.Repeat([]byte{0x11}, 20))
+
+// 奖励交易和一笔花费其输出的交易，ID 与内容一致，不需要签名
+func newTestTransactions() []*types.Transaction {
+    coinbase := types.NewRewardTx(testAddress, "test coinbase", 0)
+
+    in := types.TXInput{Txid: coinbase.ID, Vout: 0, Sequence: types.SequenceFinal}
+    spend := &types.Transaction{Vin: []types.TXInput{in}, Vout: []types.TXOutput{*types.NewTXOutput(types.Subsidy, testAddress)}}
+    spend.ID = spend.UnsignedHash()
+
+    return []*types.Transaction{coinbase, spend}
+}
+
+func newTestBlock(transactions []*types.Transaction) *types.Block {
+    header := &types.Header{Number: big.NewInt(1), Timestamp: big.NewInt(0)}
+    block := &types.Block{Header: header, Transactions: transactions}
+    block.HashTransactions()
+    return block
+}
+
+func TestCheckBlockTransactions(t *testing.T) {
+    if err := CheckBlockTransactions(newTestBlock(newTestTransactions())); err != nil { t.Fatal(err) }
+
+    txs := newTestTransactions()
+    if err := CheckBlockTransactions(newTestBlock(txs[1:])); !errors.Is(err, ErrInvalidCoinbase) { t.Errorf("missing coinbase: got %v, want %v", err, ErrInvalidCoinbase) }
+
+    txs = newTestTransactions()
+    txs = append(txs, types.NewRewardTx(testAddress, "second coinbase", 0))
+    if err := CheckBlockTransactions(newTestBlock(txs)); !errors.Is(err, ErrInvalidCoinbase) { t.Errorf("second coinbase: got %v, want %v", err, ErrInvalidCoinbase) }
+
+    txs = newTestTransactions()
+    txs = append(txs, txs[1])
+    if err := CheckBlockTransactions(newTestBlock(txs)); !errors.Is(err, ErrDuplicateTransaction) { t.Errorf("duplicate transaction: got %v, want %v", err, ErrDuplicateTransaction) }
+
+    // 冒用奖励交易的 ID
+    txs = newTestTransactions()
+    txs[1].ID = txs[0].ID
+    if err := CheckBlockTransactions(newTestBlock(txs)); !errors.Is(err, types.ErrInvalidTxID) { t.Errorf("forged ID: got %v, want %v", err, types.ErrInvalidTxID) }
+
+    // 写入 merkle root 之后修改交易
+    block := newTestBlock(newTestTransactions())
+    block.Transactions[1].Vin[0].Signature = []byte{1}
+    if err := CheckBlockTransactions(block); !errors.Is(err, ErrInvalidMerkleRoot) { t.Errorf("modified transaction: got %v, want %v", err, ErrInvalidMerkleRoot) }
+
+    // 没有 merkle root 的旧区块不校验交易 ID
+    txs = newTestTransactions()
+    txs[1].ID = []byte("legacy")
+    block = newTestBlock(txs)
+    block.Header.TxHash = common.Hash{}
+    if err := CheckBlockTransactions(block); err != nil { t.Errorf("legacy block: %v", err) }
+}
+
+func TestCheckBlockHeader(t *testing.T) {
+    genesis := NewBlock(testAddress, nil, newTestTransactions()[:1], common.Hash{}, 0)
+    if err := CheckBlockHeader(genesis, nil, 0); err != nil { t.Fatal(err) }
+
+    // 父区块的 MedianTimePast 晚于当前时间时，时间戳为其后一秒
+    medianTime := genesis.Timestamp().Int64() + 1000
+    block := NewBlock(testAddress, genesis, newTestTransactions()[:1], common.Hash{}, medianTime)
+    if block.Timestamp().Int64() != medianTime + 1 { t.Fatalf("timestamp %d, want %d", block.Timestamp(), medianTime + 1) }
+
+    if err := CheckBlockHeader(block, genesis, medianTime); err != nil { t.Fatal(err) }
+    if err := CheckBlockHeader(block, block, medianTime); !errors.Is(err, ErrInvalidBlockHeader) { t.Errorf("wrong parent: got %v, want %v", err, ErrInvalidBlockHeader) }
+    if err := CheckBlockHeader(block, nil, 0); !errors.Is(err, ErrInvalidBlockHeader) { t.Errorf("missing parent: got %v, want %v", err, ErrInvalidBlockHeader) }
+    if err := CheckBlockHeader(block, genesis, medianTime + 1); !errors.Is(err, ErrInvalidBlockTime) { t.Errorf("timestamp at median time past: got %v, want %v", err, ErrInvalidBlockTime) }
+
+    // 挖出时没有 merkle root 的区块不能连接在有 merkle root 的区块之后
+    stripped := NewBlock(testAddress, genesis, nil, common.Hash{}, medianTime)
+    stripped.Transactions = newTestTransactions()[:1]
+    if err := CheckBlockHeader(stripped, genesis, medianTime); !errors.Is(err, ErrMissingTxHash) { t.Errorf("stripped merkle root: got %v, want %v", err, ErrMissingTxHash) }
+
+    // 写入 merkle root 之前的区块链开头可以没有 merkle root
+    legacyGenesis := NewBlock(testAddress, nil, nil, common.Hash{}, 0)
+    legacy := NewBlock(testAddress, legacyGenesis, nil, common.Hash{}, 0)
+    if err := CheckBlockHeader(legacy, legacyGenesis, 0); err != nil { t.Errorf("legacy block: %v", err) }
+
+    // 修改区块头之后工作量证明失效
+    block.Header.UTXORoot = common.Hash{1}
+    if err := CheckBlockHeader(block, genesis, medianTime); !errors.Is(err, ErrInvalidProofOfWork) { t.Errorf("modified header: got %v, want %v", err, ErrInvalidProofOfWork) }
+}
+
+// 区块时间戳必须大于父区块的 MedianTimePast，相等时无效
+func TestCheckBlockHeaderTime(t *testing.T) {
+    genesis := NewBlock(testAddress, nil, newTestTransactions()[:1], common.Hash{}, 0)
+    block := NewBlock(testAddress, genesis, newTestTransactions()[:1], common.Hash{}, 0)
+    timestamp := block.Timestamp().Int64()
+
+    tests := []struct {
+        medianTime int64
+        want       error
+    }{
+        {timestamp - 1000, nil},
+        {timestamp - 1, nil},
+        {timestamp, ErrInvalidBlockTime},
+        {timestamp + 1, ErrInvalidBlockTime},
+    }
+
+    for _, test := range tests {
+        if err := CheckBlockHeader(block, genesis, test.medianTime); !errors.Is(err, test.want) { t.Errorf("timestamp %d, median time past %d: got %v, want %v", timestamp, test.medianTime, err, test.want) }
+    }
+}
@@ -1,18 +1,20 @@
-package main
+package types
 
 import (
     "bytes"
-    "time"
-    "log"
+    "errors"
     "encoding/gob"
     "math/big"
     "encoding/binary"
 
     "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/crypto"
 )
 
 type BlockNonce [8]byte
 
+var ErrInvalidBlockData = errors.New("ERROR: Block data is corrupted")
+
 func EncodeNonce(i uint64) BlockNonce {
     var n BlockNonce
     binary.BigEndian.PutUint64(n[:], i)
@@ -23,6 +25,7 @@ type Header struct {
     ParentHash    common.Hash
     Miner         common.Address
     TxHash        common.Hash
+    UTXORoot      common.Hash // 写入区块之后的 utxo 承诺，见 utxo/commitment.go
     Number        *big.Int
     Timestamp     *big.Int
     Nonce         BlockNonce
@@ -38,80 +41,65 @@ type Block struct {
 func (block *Block) ParentHash() common.Hash      { return block.Header.ParentHash }
 func (block *Block) Miner() common.Address        { return block.Header.Miner }
 func (block *Block) TxHash() common.Hash          { return block.Header.TxHash }
+func (block *Block) UTXORoot() common.Hash        { return block.Header.UTXORoot }
 func (block *Block) Number() *big.Int             { return new(big.Int).Set(block.Header.Number) }
 func (block *Block) Timestamp() *big.Int          { return new(big.Int).Set(block.Header.Timestamp) }
 func (block *Block) Nonce() uint64                { return binary.BigEndian.Uint64(block.Header.Nonce[:]) }
 // func (block *Block) Transactions() []*Transaction { return block.transactions }
 // func (block *Block) Hash() common.Hash            { return block.hash }
 
-// 获取一个新区块
-// @param: miner: []byte: 挖出区块的矿工
-// @param: parent: *Block: 上一个区块
-// @param: transactions: []*Transaction: 待写入的交易
-// @return: *Block
-func NewBlock(miner string, parent *Block, transactions []*Transaction) *Block {
-    var parentHash common.Hash
-    var blockNumber big.Int
-    if parent != nil {
-        parentHash = parent.Hash
-        blockNumber = *new(big.Int).Add(parent.Number(), big.NewInt(1))
-    }
-
-    header := &Header{parentHash, common.HexToAddress(miner), common.Hash{}, &blockNumber, big.NewInt(time.Now().Unix()), BlockNonce{}}
-    block := &Block{header, transactions, common.Hash{}}
-
-    if len(transactions) > 0 {
-        block.HashTransactions()
-    }
-    pow := NewProofOfWork(block)
-    nonce, hash := pow.Run()
-    block.Header.Nonce = EncodeNonce(uint64(nonce))
-    block.Hash.SetBytes(hash)
-
-    return block
-}
-
-// 获取创世块
-// rewardTx 矿工的奖励交易，不需要引用之前交易。
-// @return: *Block
-// func NewGenesisBlock(miner common.Address, rewardTx *Transaction) *Block {
-func NewGenesisBlock(miner string, rewardTx *Transaction) *Block {
-    // return NewBlock(miner, nil, []*Transaction{})
-    return NewBlock(miner, nil, []*Transaction{rewardTx})
-}
-
 // 将一个区块序列化
 // @param: b: *Block: 区块
-// @return: []byte
-func (b *Block) Serialize() []byte {
+// @return: []byte, error
+func (b *Block) Serialize() ([]byte, error) {
     var result bytes.Buffer
     // encodind/gob.NewEncoder(w io.Writer)
     encoder := gob.NewEncoder(&result)
 
     err := encoder.Encode(b)
-    if err != nil { log.Panic(err) }
+    if err != nil { return nil, err }
 
-    return result.Bytes()
+    return result.Bytes(), nil
 }
 
-func DeserializeBlock(d []byte) *Block {
+// 反序列化一个区块，数据损坏时返回 ErrInvalidBlockData
+func DeserializeBlock(d []byte) (*Block, error) {
     var block Block
 
     decoder := gob.NewDecoder(bytes.NewReader(d))
     err := decoder.Decode(&block)
-    if err != nil { log.Panic(err) }
+    if err != nil || block.Header == nil || block.Header.Number == nil || block.Header.Timestamp == nil {
+        return nil, ErrInvalidBlockData
+    }
 
-    return &block
+    return &block, nil
 }
 
-// 一个区块所有交易的hash
-func (b *Block) HashTransactions() {
+// 一个区块所有交易的 merkle root
+func (b *Block) MerkleRoot() common.Hash {
     var transactions [][]byte
 
     for _, tx := range b.Transactions {
         transactions = append(transactions, tx.Serialize())
     }
-    mTree := NewMerkleTree(transactions)
+    mTree := crypto.NewMerkleTree(transactions)
+
+    var root common.Hash
+    root.SetBytes(mTree.RootNode.Data)
+    return root
+}
+
+// 将交易的 merkle root 写入区块头，工作量证明因此包含全部交易
+func (b *Block) HashTransactions() {
+    b.Header.TxHash = b.MerkleRoot()
+}
+
+// 只保留区块头和交易 ID 的区块
+func (b *Block) PrunedCopy() *Block {
+    var transactions []*Transaction
+    for _, tx := range b.Transactions {
+        transactions = append(transactions, &Transaction{tx.ID, nil, nil, 0})
+    }
 
-    b.Hash.SetBytes(mTree.RootNode.Data)
+    return &Block{b.Header, transactions, b.Hash}
 }
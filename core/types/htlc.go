@@ -0,0 +1,94 @@
+package types
+
+/*
+哈希时间锁合约 (HTLC)，用于两条链之间的原子交换：
+    输出锁定在 SecretHash 上，
+    在超时之前，收款方提供 sha256(secret) == SecretHash 的 secret 并签名即可花费（redeem），
+    超时之后，付款方可以取回（refund），refund 交易的 LockTime 不能小于 HTLC.LockTime
+
+原子交换：
+    1. A 生成 secret，在链 1 上创建付给 B 的 HTLC（initiate），超时较长
+    2. B 确认后，在链 2 上以相同的 SecretHash 创建付给 A 的 HTLC（participate），超时较短
+    3. A 在链 2 上用 secret 取走 B 的币（redeem），secret 因此公开
+    4. B 从链 2 的 redeem 交易中得到 secret（extractsecret），在链 1 上取走 A 的币
+    任何一方中止时，双方都可以在超时后取回（refund）
+*/
+
+import (
+    "bytes"
+    "errors"
+    "strings"
+    "strconv"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/common"
+)
+
+const HTLCSecretLen = 32
+
+var ErrInvalidContract = errors.New("ERROR: Output is not a hash time locked contract")
+
+type HTLC struct {
+    SecretHash          []byte // sha256(secret)
+    RecipientPubKeyHash []byte // 提供 secret 时可以花费
+    RefundPubKeyHash    []byte // 超时之后可以花费
+    LockTime            int64  // 超时时间，区块高度或 unix 时间戳，与 Transaction.LockTime 相同
+}
+
+// 生成一个随机的 secret 及其 hash
+func NewHTLCSecret() ([]byte, []byte) {
+    // crypto/rand.Read 不会返回错误
+    secret := make([]byte, HTLCSecretLen)
+    rand.Read(secret)
+
+    hash := sha256.Sum256(secret)
+    return secret, hash[:]
+}
+
+// 签名时代替 PubKeyHash 的合约摘要
+func (h *HTLC) Hash() []byte {
+    hash := sha256.Sum256(bytes.Join(
+        [][]byte{h.SecretHash, h.RecipientPubKeyHash, h.RefundPubKeyHash, common.IntToHex(h.LockTime)},
+        []byte{},
+    ))
+    return hash[:]
+}
+
+// 花费合约的输入需要使用的私钥对应的 pubKeyHash
+// 输入带有 secret 时为收款方，否则为付款方
+func (h *HTLC) SpenderPubKeyHash(in TXInput) []byte {
+    if in.Secret != nil { return h.RecipientPubKeyHash }
+    return h.RefundPubKeyHash
+}
+
+// 输入是否满足合约的条件（签名另行校验）
+func (h *HTLC) CanBeSpentBy(tx *Transaction, in TXInput) bool {
+    if !in.UsesKey(h.SpenderPubKeyHash(in)) { return false }
+
+    // redeem: secret 必须正确
+    if in.Secret != nil {
+        hash := sha256.Sum256(in.Secret)
+        return bytes.Compare(hash[:], h.SecretHash) == 0
+    }
+
+    // refund: 交易的 LockTime 必须与合约超时类型相同且不早于合约超时
+    // 输入的 Sequence 为 SequenceFinal 时 LockTime 不生效
+    sameType := (tx.LockTime < LockTimeThreshold) == (h.LockTime < LockTimeThreshold)
+    return sameType && tx.LockTime >= h.LockTime && in.Sequence != SequenceFinal
+}
+
+// 解析 TXID:VOUT 格式的合约位置
+func ParseOutpoint(s string) ([]byte, int, error) {
+    fields := strings.Split(s, ":")
+    if len(fields) != 2 { return nil, 0, errors.New("ERROR: Outpoint must be TXID:VOUT") }
+
+    txID, err := hex.DecodeString(fields[0])
+    if err != nil { return nil, 0, err }
+
+    vout, err := strconv.Atoi(fields[1])
+    if err != nil { return nil, 0, err }
+
+    return txID, vout, nil
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+// 合约的花费条件，签名另行校验
+func TestHTLCCanBeSpentBy(t *testing.T) {
+    recipientKey, refundKey := bytes.Repeat([]byte{0x01}, 64), bytes.Repeat([]byte{0x02}, 64)
+    secret, secretHash := NewHTLCSecret()
+    wrongSecret, _ := NewHTLCSecret()
+
+    tests := []struct {
+        name         string
+        htlcLockTime int64
+        pubKey       []byte
+        secret       []byte
+        sequence     uint32
+        lockTime     int64
+        want         bool
+    }{
+        {"redeem", 10, recipientKey, secret, SequenceFinal, 0, true},
+        {"redeem with wrong secret", 10, recipientKey, wrongSecret, SequenceFinal, 0, false},
+        {"redeem with refund key", 10, refundKey, secret, SequenceFinal, 0, false},
+        {"refund", 10, refundKey, nil, SequenceFinal - 1, 10, true},
+        {"refund before lock time", 10, refundKey, nil, SequenceFinal - 1, 9, false},
+        {"refund with final sequence", 10, refundKey, nil, SequenceFinal, 10, false},
+        {"refund with recipient key", 10, recipientKey, nil, SequenceFinal - 1, 10, false},
+        {"refund by time", LockTimeThreshold + 100, refundKey, nil, SequenceFinal - 1, LockTimeThreshold + 100, true},
+        {"refund by height for a time lock", LockTimeThreshold + 100, refundKey, nil, SequenceFinal - 1, 10, false},
+    }
+
+    for _, test := range tests {
+        h := &HTLC{secretHash, crypto.HashPubKey(recipientKey), crypto.HashPubKey(refundKey), test.htlcLockTime}
+        in := TXInput{PubKey: test.pubKey, Sequence: test.sequence, Secret: test.secret}
+        tx := &Transaction{Vin: []TXInput{in}, LockTime: test.lockTime}
+
+        if got := h.CanBeSpentBy(tx, in); got != test.want { t.Errorf("%s: got %v, want %v", test.name, got, test.want) }
+    }
+}
@@ -0,0 +1,66 @@
+package types
+
+/*
+时间锁，与比特币的 nLockTime (BIP65) 和 nSequence (BIP68) 相同：
+    Transaction.LockTime: 绝对时间锁
+        0 表示没有时间锁
+        小于 LockTimeThreshold 时为区块高度，交易只能被写入高度大于 LockTime 的区块
+        否则为 unix 时间戳，交易只能被写入 MedianTimePast 大于 LockTime 的区块
+        全部输入的 Sequence 均为 SequenceFinal 时不生效
+    TXInput.Sequence: 相对时间锁，相对于所花费输出被写入的区块
+        设置 SequenceLockTimeDisableFlag 时不生效
+        设置 SequenceLockTimeTypeFlag 时低 16 位以 512 秒为单位，否则为区块数
+*/
+
+import (
+    "errors"
+)
+
+const (
+    SequenceFinal               = uint32(0xffffffff)
+    SequenceLockTimeDisableFlag = uint32(1 << 31)
+    SequenceLockTimeTypeFlag    = uint32(1 << 22)
+    SequenceLockTimeMask        = uint32(0x0000ffff)
+    SequenceLockTimeGranularity = 9 // 2^9 = 512 秒
+
+    LockTimeThreshold = int64(500000000)
+
+    // 计算 MedianTimePast 使用的区块数
+    MedianTimeBlocks = 11
+)
+
+var ErrTransactionNotFinal = errors.New("ERROR: Transaction is not final")
+var ErrSequenceLockNotMet = errors.New("ERROR: Transaction input is still relative locked")
+
+// 交易能否被写入高度为 height，MedianTimePast 为 medianTime 的区块
+func (tx *Transaction) IsFinal(height, medianTime int64) bool {
+    if tx.LockTime == 0 { return true }
+
+    limit := height
+    if tx.LockTime >= LockTimeThreshold { limit = medianTime }
+    if tx.LockTime < limit { return true }
+
+    for _, vin := range tx.Vin {
+        if vin.Sequence != SequenceFinal { return false }
+    }
+    return true
+}
+
+// 相对时间锁的 Sequence
+// seconds 为 true 时 value 以 512 秒为单位，否则为区块数
+func RelativeLockSequence(value uint32, seconds bool) uint32 {
+    sequence := value & SequenceLockTimeMask
+    if seconds { sequence |= SequenceLockTimeTypeFlag }
+
+    return sequence
+}
+
+const SequenceMaxReplaceable = SequenceFinal - 2
+
+// 交易是否声明可以被替换
+func (tx *Transaction) IsReplaceable() bool {
+    for _, vin := range tx.Vin {
+        if vin.Sequence <= SequenceMaxReplaceable { return true }
+    }
+    return false
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+    "testing"
+)
+
+// 绝对时间锁：小于 LockTimeThreshold 时为区块高度，否则为 MedianTimePast
+func TestIsFinal(t *testing.T) {
+    const medianTime = LockTimeThreshold + 1000
+
+    tests := []struct {
+        lockTime int64
+        sequence uint32
+        height   int64
+        want     bool
+    }{
+        {0, 0, 0, true},
+        {10, 0, 10, false},
+        {10, 0, 11, true},
+        {10, SequenceFinal, 10, true},
+        {medianTime, 0, 1 << 30, false},
+        {medianTime - 1, 0, 0, true},
+    }
+
+    for _, test := range tests {
+        tx := &Transaction{Vin: []TXInput{{Sequence: test.sequence}}, LockTime: test.lockTime}
+        if got := tx.IsFinal(test.height, medianTime); got != test.want { t.Errorf("lock time %d, sequence %x at height %d: got %v, want %v", test.lockTime, test.sequence, test.height, got, test.want) }
+    }
+}
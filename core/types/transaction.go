@@ -0,0 +1,304 @@
+package types
+
+import (
+    "fmt"
+    "bytes"
+    "errors"
+    "time"
+    "math"
+    "math/big"
+    "encoding/gob"
+    "encoding/hex"
+    "encoding/binary"
+    "crypto/sha256"
+    "crypto/ecdsa"
+    "crypto/rand"
+    "crypto/elliptic"
+    "strconv"
+    "strings"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+const Subsidy = 26
+
+type Transaction struct {
+    ID       []byte
+    Vin      []TXInput
+    Vout     []TXOutput
+    LockTime int64 // 绝对时间锁，见 timelock.go
+}
+
+// 即区块的奖励交易
+// 矿工获得固定奖励 subsidy 以及区块内全部交易的手续费 fees
+func NewRewardTx(to, data string, fees int) *Transaction {
+    // 奖励交易没有输入 也不会被校验
+    // 因此 TXInput.Signature = nil, TXInput.PubKey 随机生成
+    // 根据 当前时间 和 随机数 生成 PubKey
+    if data == "" {
+        var ts bytes.Buffer
+        binary.Write(&ts, binary.BigEndian, time.Now().UnixNano())
+
+        // crypto/rand.Read 不会返回错误
+        randData := make([]byte, 20)
+        rand.Read(randData)
+
+        randData = append(ts.Bytes(), randData...)
+        data = fmt.Sprintf("%v", randData)
+    }
+
+    txin := TXInput{[]byte{}, -1, nil, []byte(data), SequenceFinal, nil}
+
+    txout := NewTXOutput(Subsidy + fees, to)
+    tx := Transaction{nil, []TXInput{txin}, []TXOutput{*txout}, 0}
+    tx.ID = tx.Hash()
+
+    return &tx
+}
+
+// if the transaction is rewared to miner.
+func (tx *Transaction) IsCoinbase() bool {
+	return len(tx.Vin) == 1 && len(tx.Vin[0].Txid) == 0 && tx.Vin[0].Vout == -1
+}
+
+// 签名
+// 一个私钥和一个之前交易的 map
+func (tx *Transaction) Sign(privKey ecdsa.PrivateKey, prevTXs map[string]Transaction) error {
+    pubKey := append(privKey.PublicKey.X.Bytes(), privKey.PublicKey.Y.Bytes()...)
+    privKeys := map[string]ecdsa.PrivateKey{hex.EncodeToString(crypto.HashPubKey(pubKey)): privKey}
+
+    return tx.SignWithKeys(privKeys, prevTXs)
+}
+
+// 使用多个私钥签名
+// privKeys 以 hex(pubKeyHash) 索引，每个输入使用其引用输出的 PubKeyHash 对应的私钥
+// 缺少引用的交易时返回 ErrMissingPrevTransaction，缺少私钥时返回 ErrMissingPrivateKey
+func (tx *Transaction) SignWithKeys(privKeys map[string]ecdsa.PrivateKey, prevTXs map[string]Transaction) error {
+    if tx.IsCoinbase() {
+        return nil
+    }
+
+    txCopy := tx.TrimmedCopy()
+
+    // 遍历输入
+    for inID, vin := range txCopy.Vin {
+
+        // 获取 当前交易输入 对应的上一笔交易
+        // HTLC 输出由 redeem / refund 决定使用哪一方的私钥
+        prevTx, ok := prevTXs[hex.EncodeToString(vin.Txid)]
+        if !ok || vin.Vout < 0 || vin.Vout >= len(prevTx.Vout) { return fmt.Errorf("%w: input %d", ErrMissingPrevTransaction, inID) }
+        prevOut := prevTx.Vout[vin.Vout]
+
+        privKey, ok := privKeys[hex.EncodeToString(prevOut.SpenderPubKeyHash(tx.Vin[inID]))]
+        if !ok { return fmt.Errorf("%w: input %d", ErrMissingPrivateKey, inID) }
+
+        // 仅仅是一个双重检验
+        txCopy.Vin[inID].Signature = nil
+        txCopy.Vin[inID].PubKey = prevOut.ScriptHash()
+        txCopy.ID = txCopy.Hash()
+
+        // 重置 PubKey 不影响后面的遍历
+        txCopy.Vin[inID].PubKey = nil
+
+        // 用privKey 对 txCopy.ID 进行签名
+        // ecdsa.Sign f func(rand io.Reader, priv *ecdsa.PrivateKey, hash []byte) (r *big.Int, s *big.Int, err error)
+        r, s, err := ecdsa.Sign(rand.Reader, &privKey, txCopy.ID)
+        if err != nil { return err }
+
+        // r, s 补齐到相同长度，Verify 按一半切分
+        signature := make([]byte, 64)
+        r.FillBytes(signature[:32])
+        s.FillBytes(signature[32:])
+
+        tx.Vin[inID].Signature = signature
+    }
+
+    return nil
+}
+
+// Verify verifies signatures of Transaction inputs
+func (tx *Transaction) Verify(prevTXs map[string]Transaction) bool {
+    txCopy := tx.TrimmedCopy()
+
+    for inID, vin := range tx.Vin {
+        prevTx := prevTXs[hex.EncodeToString(vin.Txid)]
+        if vin.Vout < 0 || vin.Vout >= len(prevTx.Vout) { return false }
+
+        // 输入的公钥必须有权花费引用的输出
+        prevOut := prevTx.Vout[vin.Vout]
+        if !prevOut.CanBeSpentBy(tx, vin) { return false }
+
+        txCopy.Vin[inID].Signature = nil
+        txCopy.Vin[inID].PubKey = prevOut.ScriptHash()
+        txCopy.ID = txCopy.Hash()
+        txCopy.Vin[inID].PubKey = nil
+
+        var r, s big.Int
+        sigLen := len(vin.Signature)
+
+        // big.Int.SetBytes f func(buf []byte) *big.Int
+        r.SetBytes(vin.Signature[:(sigLen / 2)])
+        s.SetBytes(vin.Signature[(sigLen / 2):])
+
+        var x, y big.Int
+        keyLen := len(vin.PubKey)
+        x.SetBytes(vin.PubKey[:(keyLen / 2)])
+        y.SetBytes(vin.PubKey[(keyLen / 2):])
+
+        // elliptic.P256  f func() elliptic.Curve
+        rawPubKey := ecdsa.PublicKey{elliptic.P256(), &x, &y}
+        if ecdsa.Verify(&rawPubKey, txCopy.ID, &r, &s) == false {
+            return false
+        }
+    }
+    return true
+}
+
+// 返回 input.Signature 和 input.PubKey 被设置为nil 的交易副本
+// 因为不需要对存储在输入里面的公钥签名
+// 即不需要校验当前交易输入的发送方
+func (tx *Transaction) TrimmedCopy() Transaction {
+    var inputs []TXInput
+    var outputs []TXOutput
+
+    for _, vin := range tx.Vin {
+        inputs = append(inputs, TXInput{vin.Txid, vin.Vout, nil, nil, vin.Sequence, nil})
+    }
+
+    for _, vout := range tx.Vout {
+        outputs = append(outputs, TXOutput{vout.Value, vout.PubKeyHash, vout.HTLC, vout.Data})
+    }
+
+    txCopy := Transaction{tx.ID, inputs, outputs, tx.LockTime}
+    return txCopy
+}
+
+// Hash returns the hash of the Transaction
+func (tx *Transaction) Hash() []byte {
+    var hash [32]byte
+
+    txCopy := *tx
+    txCopy.ID = []byte{}
+
+    hash = sha256.Sum256(txCopy.Serialize())
+
+    return hash[:]
+}
+
+// 交易 ID：清空全部输入的签名之后的 Hash，交易在签名之前确定 ID
+// 签名不覆盖 ID，因此校验交易时需检查 ID 与交易内容一致，见 CheckID
+func (tx *Transaction) UnsignedHash() []byte {
+    txCopy := *tx
+    txCopy.Vin = make([]TXInput, len(tx.Vin))
+    for i, vin := range tx.Vin {
+        vin.Signature = nil
+        txCopy.Vin[i] = vin
+    }
+
+    return txCopy.Hash()
+}
+
+// 交易 ID 需与交易内容一致，否则一笔交易可以冒用另一笔交易的 ID，覆盖其 utxo
+func (tx *Transaction) CheckID() error {
+    if !bytes.Equal(tx.ID, tx.UnsignedHash()) { return fmt.Errorf("%w: %x", ErrInvalidTxID, tx.ID) }
+    return nil
+}
+
+// Serialize returns a serialized Transaction
+// 编码见 transaction_encoding.go，可以用 gob 解码
+func (tx Transaction) Serialize() []byte {
+    return tx.encodeV1()
+}
+
+// DeserializeTransaction deserializes a Transaction
+func DeserializeTransaction(data []byte) (Transaction, error) {
+    var transaction Transaction
+
+    dec := gob.NewDecoder(bytes.NewReader(data))
+    err := dec.Decode(&transaction)
+
+    return transaction, err
+}
+
+var ErrInvalidTransaction = errors.New("ERROR: Invalid transaction")
+var ErrMissingPrevTransaction = errors.New("ERROR: Transaction input references an unknown output")
+var ErrMissingPrivateKey = errors.New("ERROR: No private key for input")
+var ErrInvalidTxID = errors.New("ERROR: Invalid transaction: ID does not match its content")
+
+// 输出的位置 txID:vout，作为 map 的 key
+func OutpointKey(txID []byte, vout int) string {
+    return hex.EncodeToString(txID) + ":" + strconv.Itoa(vout)
+}
+
+// 交易的大小，即输入与输出的个数，手续费按大小计算
+func (tx *Transaction) Size() int {
+    return len(tx.Vin) + len(tx.Vout)
+}
+
+// 全部输出的金额之和
+// 有负数金额时返回 ErrInvalidOutputValue，溢出时返回 ErrOutputValueOverflow
+func (tx *Transaction) OutputValue() (int, error) {
+    total := 0
+    for _, out := range tx.Vout {
+        if out.Value < 0 { return 0, ErrInvalidOutputValue }
+        if total > math.MaxInt - out.Value { return 0, ErrOutputValueOverflow }
+        total += out.Value
+    }
+
+    return total, nil
+}
+
+// 交易的可读格式
+func (tx Transaction) String() string {
+    var lines []string
+
+    lines = append(lines, fmt.Sprintf("--- Transaction %x:", tx.ID))
+    if tx.LockTime != 0 { lines = append(lines, fmt.Sprintf("    LockTime:   %d", tx.LockTime)) }
+
+    for i, input := range tx.Vin {
+        lines = append(lines, fmt.Sprintf("    Input %d:", i))
+        if tx.IsCoinbase() {
+            lines = append(lines, fmt.Sprintf("      Coinbase:  %x", input.PubKey))
+            continue
+        }
+        lines = append(lines, fmt.Sprintf("      TXID:      %x", input.Txid))
+        lines = append(lines, fmt.Sprintf("      Out:       %d", input.Vout))
+        lines = append(lines, fmt.Sprintf("      Signature: %x", input.Signature))
+        lines = append(lines, fmt.Sprintf("      PubKey:    %x", input.PubKey))
+        lines = append(lines, fmt.Sprintf("      Sequence:  %#x", input.Sequence))
+        if input.Secret != nil { lines = append(lines, fmt.Sprintf("      Secret:    %x", input.Secret)) }
+    }
+
+    for i, output := range tx.Vout {
+        lines = append(lines, fmt.Sprintf("    Output %d:", i))
+        lines = append(lines, fmt.Sprintf("      Value:     %d", output.Value))
+        switch {
+        case output.IsUnspendable():
+            lines = append(lines, fmt.Sprintf("      Data:      %x", output.Data))
+        case output.HTLC != nil:
+            lines = append(lines, fmt.Sprintf("      HTLC:      secret hash %x, recipient %s, refund %s after %d",
+                output.HTLC.SecretHash,
+                crypto.PubKeyHashToAddress(output.HTLC.RecipientPubKeyHash),
+                crypto.PubKeyHashToAddress(output.HTLC.RefundPubKeyHash),
+                output.HTLC.LockTime))
+        default:
+            lines = append(lines, fmt.Sprintf("      Address:   %s", crypto.PubKeyHashToAddress(output.PubKeyHash)))
+        }
+    }
+
+    return strings.Join(lines, "\n")
+}
+
+// 解析 hex 编码的交易
+func DecodeRawTransaction(s string) (*Transaction, error) {
+    data, err := hex.DecodeString(strings.TrimSpace(s))
+    if err != nil { return nil, err }
+    if len(data) == 0 { return nil, ErrInvalidTransaction }
+
+    var tx Transaction
+    err = gob.NewDecoder(bytes.NewReader(data)).Decode(&tx)
+    if err != nil { return nil, err }
+    if err := tx.CheckID(); err != nil { return nil, err }
+
+    return &tx, nil
+}
@@ -0,0 +1,156 @@
+package types
+
+/*
+交易的编码（版本 1），交易 ID、签名和 merkle root 都基于这个编码
+    与交易移入 types 包之前 gob 编码 Transaction 的结果逐字节相同，因此仍然可以用 gob 解码，
+    但不再经过 gob 编码：gob 在进程内按类型第一次出现的顺序分配类型 ID，
+    并以带包名的名字描述 []TXInput 等类型，二者都会改变编码，从而改变交易 ID
+
+编码为一系列消息：uint 长度 | int 类型 ID | 内容
+    前面是固定的类型定义消息，见 txTypeDefsV1，最后是交易本身的值消息
+    结构体的每个非零字段写为 uint 字段序号之差 | 值，以 0 结尾
+    字节串和切片之前写入 uint 长度，空的字节串和切片与零值一样省略
+    切片中的结构体依次写入，不为 nil 的指针即使指向零值也写入
+
+修改交易的结构时不能修改这里，否则已有交易的 ID 全部改变，需要增加新的版本
+*/
+
+import (
+    "encoding/hex"
+)
+
+// 交易的 gob 类型 ID
+const txTypeIDV1 = 64
+
+// 类型定义消息，依次为 Transaction, []main.TXInput, TXInput, []main.TXOutput, TXOutput, HTLC
+var txTypeDefsV1, _ = hex.DecodeString(
+    "3f7f0301010b5472616e73616374696f6e01ff8000010401024944010a00010356696e01ff84000104566f757401ff8a0001084c6f636b54696d650104000000" +
+    "1dff830201010e5b5d6d61696e2e5458496e70757401ff840001ff820000" +
+    "58ff81030101075458496e70757401ff82000106010454786964010a000104566f757401040001095369676e6174757265010a0001065075624b6579010a00010853657175656e63650106000106536563726574010a000000" +
+    "1eff890201010f5b5d6d61696e2e54584f757470757401ff8a0001ff860000" +
+    "42ff850301010854584f757470757401ff86000104010556616c7565010400010a5075624b657948617368010a00010448544c4301ff8800010444617461010a000000" +
+    "5bff870301010448544c4301ff88000104010a53656372657448617368010a000113526563697069656e745075624b657948617368010a000110526566756e645075624b657948617368010a0001084c6f636b54696d650104000000")
+
+// 交易的编码
+func (tx Transaction) encodeV1() []byte {
+    s := newGobStruct()
+    s.putBytes(0, tx.ID)
+
+    var inputs [][]byte
+    for _, in := range tx.Vin {
+        inputs = append(inputs, in.encodeV1())
+    }
+    s.putStructs(1, inputs)
+
+    var outputs [][]byte
+    for _, out := range tx.Vout {
+        outputs = append(outputs, out.encodeV1())
+    }
+    s.putStructs(2, outputs)
+
+    s.putInt(3, tx.LockTime)
+
+    msg := append(encodeGobInt(txTypeIDV1), s.end()...)
+
+    result := append([]byte{}, txTypeDefsV1...)
+    result = append(result, encodeGobUint(uint64(len(msg)))...)
+    return append(result, msg...)
+}
+
+func (in TXInput) encodeV1() []byte {
+    s := newGobStruct()
+    s.putBytes(0, in.Txid)
+    s.putInt(1, int64(in.Vout))
+    s.putBytes(2, in.Signature)
+    s.putBytes(3, in.PubKey)
+    s.putUint(4, uint64(in.Sequence))
+    s.putBytes(5, in.Secret)
+    return s.end()
+}
+
+func (out TXOutput) encodeV1() []byte {
+    s := newGobStruct()
+    s.putInt(0, int64(out.Value))
+    s.putBytes(1, out.PubKeyHash)
+    if out.HTLC != nil { s.putStruct(2, out.HTLC.encodeV1()) }
+    s.putBytes(3, out.Data)
+    return s.end()
+}
+
+func (contract HTLC) encodeV1() []byte {
+    s := newGobStruct()
+    s.putBytes(0, contract.SecretHash)
+    s.putBytes(1, contract.RecipientPubKeyHash)
+    s.putBytes(2, contract.RefundPubKeyHash)
+    s.putInt(3, contract.LockTime)
+    return s.end()
+}
+
+// gob 编码的结构体，last 为上一个写入的字段序号
+type gobStruct struct {
+    buf  []byte
+    last int
+}
+
+func newGobStruct() *gobStruct {
+    return &gobStruct{nil, -1}
+}
+
+func (s *gobStruct) field(i int) {
+    s.buf = append(s.buf, encodeGobUint(uint64(i - s.last))...)
+    s.last = i
+}
+
+func (s *gobStruct) putBytes(i int, b []byte) {
+    if len(b) == 0 { return }
+    s.field(i)
+    s.buf = append(s.buf, encodeGobUint(uint64(len(b)))...)
+    s.buf = append(s.buf, b...)
+}
+
+func (s *gobStruct) putInt(i int, x int64) {
+    if x == 0 { return }
+    s.field(i)
+    s.buf = append(s.buf, encodeGobInt(x)...)
+}
+
+func (s *gobStruct) putUint(i int, x uint64) {
+    if x == 0 { return }
+    s.field(i)
+    s.buf = append(s.buf, encodeGobUint(x)...)
+}
+
+func (s *gobStruct) putStruct(i int, encoded []byte) {
+    s.field(i)
+    s.buf = append(s.buf, encoded...)
+}
+
+func (s *gobStruct) putStructs(i int, encoded [][]byte) {
+    if len(encoded) == 0 { return }
+    s.field(i)
+    s.buf = append(s.buf, encodeGobUint(uint64(len(encoded)))...)
+    for _, e := range encoded {
+        s.buf = append(s.buf, e...)
+    }
+}
+
+func (s *gobStruct) end() []byte {
+    return append(s.buf, 0)
+}
+
+// gob 的 uint：小于 128 时为一个字节，否则为字节数的相反数及大端序的字节
+func encodeGobUint(x uint64) []byte {
+    if x < 0x80 { return []byte{byte(x)} }
+
+    var buf []byte
+    for ; x > 0; x >>= 8 {
+        buf = append([]byte{byte(x)}, buf...)
+    }
+    return append([]byte{byte(256 - len(buf))}, buf...)
+}
+
+// gob 的 int 以最低位为符号位，负数取反
+func encodeGobInt(x int64) []byte {
+    if x < 0 { return encodeGobUint(uint64(^x) << 1 | 1) }
+    return encodeGobUint(uint64(x) << 1)
+}
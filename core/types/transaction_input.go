@@ -1,7 +1,9 @@
-package main
+package types
 
 import (
     "bytes"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
 )
 
 type TXInput struct {
@@ -9,10 +11,12 @@ type TXInput struct {
     Vout      int
     Signature []byte
     PubKey    []byte // PubKey origin
+    Sequence  uint32 // 相对时间锁，见 timelock.go
+    Secret    []byte // 花费 HTLC 输出时提供的 secret，见 htlc.go
 }
 
 func (in *TXInput) UsesKey(pubKeyHash []byte) bool {
-    lockingHash := HashPubKey(in.PubKey)
+    lockingHash := crypto.HashPubKey(in.PubKey)
 
     return bytes.Compare(lockingHash, pubKeyHash) == 0
 }
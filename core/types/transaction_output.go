@@ -0,0 +1,141 @@
+package types
+
+import (
+    "sort"
+    "bytes"
+    "errors"
+    "encoding/gob"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+// 数据输出最多可以携带的字节数
+const MaxDataCarrierSize = 80
+
+var ErrInvalidDataOutput = errors.New("ERROR: Data output must have zero value and at most 80 bytes of data")
+var ErrInvalidOutputValue = errors.New("ERROR: Invalid transaction: output value must be positive")
+var ErrOutputValueOverflow = errors.New("ERROR: Invalid transaction: total output value overflows")
+
+type TXOutput struct {
+    Value      int
+    PubKeyHash []byte // Pubkey hash
+    HTLC       *HTLC  // 不为 nil 时为哈希时间锁合约，PubKeyHash 为 nil
+    Data       []byte // 不为 nil 时为不可花费的数据输出，与比特币的 OP_RETURN 相同
+}
+
+// 根据address 设置 out.pubKeyHash
+func (out *TXOutput) Lock(address []byte) {
+    out.PubKeyHash = crypto.AddressToPubKeyHash(string(address))
+}
+
+// 是否和 out.PubKeyHash 相等
+func (out *TXOutput) IsLockedWithKey(pubKeyHash []byte) bool {
+    return bytes.Compare(out.PubKeyHash, pubKeyHash) == 0
+}
+
+// 数据输出不能被花费，也不会被加入 utxo
+func (out *TXOutput) IsUnspendable() bool {
+    return out.Data != nil
+}
+
+// 签名时代替输入公钥的输出摘要
+func (out *TXOutput) ScriptHash() []byte {
+    if out.HTLC != nil { return out.HTLC.Hash() }
+    return out.PubKeyHash
+}
+
+// 花费该输出的输入需要使用的私钥对应的 pubKeyHash
+func (out *TXOutput) SpenderPubKeyHash(in TXInput) []byte {
+    if out.HTLC != nil { return out.HTLC.SpenderPubKeyHash(in) }
+    return out.PubKeyHash
+}
+
+// 输入是否有权花费该输出（签名另行校验）
+func (out *TXOutput) CanBeSpentBy(tx *Transaction, in TXInput) bool {
+    if out.IsUnspendable() { return false }
+    if out.HTLC != nil { return out.HTLC.CanBeSpentBy(tx, in) }
+    return in.UsesKey(out.PubKeyHash)
+}
+
+// NewTXOutput create a new TXOutput
+func NewTXOutput(value int, address string) *TXOutput {
+    txo := &TXOutput{ value, nil, nil, nil }
+    txo.Lock([]byte(address))
+
+    return txo
+}
+
+// 创建一个哈希时间锁合约输出
+func NewHTLCOutput(value int, htlc *HTLC) *TXOutput {
+    return &TXOutput{ value, nil, htlc, nil }
+}
+
+// 数据输出的金额必须为 0，携带的数据不能超过 MaxDataCarrierSize
+func (out *TXOutput) CheckData() error {
+    if !out.IsUnspendable() { return nil }
+    if out.Value != 0 || out.PubKeyHash != nil || out.HTLC != nil { return ErrInvalidDataOutput }
+    if len(out.Data) > MaxDataCarrierSize { return ErrInvalidDataOutput }
+
+    return nil
+}
+
+// 可花费输出的金额必须大于 0，数据输出见 CheckData
+// 负数金额的输出会抵消其他输出，使交易凭空产生金额
+func (out *TXOutput) CheckValue() error {
+    if err := out.CheckData(); err != nil { return err }
+    if !out.IsUnspendable() && out.Value <= 0 { return ErrInvalidOutputValue }
+
+    return nil
+}
+
+// 创建一个携带 data 的数据输出，data 不能为空
+func NewDataOutput(data []byte) (*TXOutput, error) {
+    if len(data) == 0 || len(data) > MaxDataCarrierSize { return nil, ErrInvalidDataOutput }
+
+    return &TXOutput{ 0, nil, nil, data }, nil
+}
+
+// 一笔交易中尚未花费的输出
+// key 为输出在 tx.Vout 中的序号，花费部分输出后序号保持不变
+type TXOutputs struct {
+    Outputs map[int]TXOutput
+}
+
+// 按序号从小到大返回全部输出的序号
+func (outs TXOutputs) Indexes() []int {
+    var indexes []int
+    for outIdx := range outs.Outputs {
+        indexes = append(indexes, outIdx)
+    }
+    sort.Ints(indexes)
+
+    return indexes
+}
+
+// Serialize serializes TXOutputs
+func (outs TXOutputs) Serialize() ([]byte, error) {
+    var buff bytes.Buffer
+
+    enc := gob.NewEncoder(&buff)
+    err := enc.Encode(outs)
+    if err != nil { return nil, err }
+
+    return buff.Bytes(), nil
+}
+
+// DeserializeOutputs deserializes TXOutputs
+func DeserializeOutputs(data []byte) (TXOutputs, error) {
+    var outputs TXOutputs
+
+    dec := gob.NewDecoder(bytes.NewReader(data))
+    err := dec.Decode(&outputs)
+
+    return outputs, err
+}
+
+// 一个未花费输出及其位置
+type UTXO struct {
+    TxID   []byte
+    Index  int
+    Output TXOutput
+}
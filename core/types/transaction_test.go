@@ -0,0 +1,79 @@
+package types
+
+import (
+    "bytes"
+    "errors"
+    "reflect"
+    "testing"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+// 固定内容的交易，覆盖全部字段：普通输出，HTLC 输出，数据输出，负数和较大的整数
+func pinnedTransactions() []Transaction {
+    address := crypto.PubKeyHashToAddress(bytes.Repeat([]byte{0x11}, 20))
+
+    coinbase := Transaction{nil, []TXInput{{[]byte{}, -1, nil, []byte("pinned coinbase"), SequenceFinal, nil}}, []TXOutput{*NewTXOutput(Subsidy, address)}, 0}
+
+    contract := &HTLC{bytes.Repeat([]byte{0x22}, 32), bytes.Repeat([]byte{0x33}, 20), bytes.Repeat([]byte{0x44}, 20), 1700000000}
+    data, _ := NewDataOutput([]byte("pinned data"))
+    spend := Transaction{
+        nil,
+        []TXInput{
+            {bytes.Repeat([]byte{0xaa}, 32), 1, bytes.Repeat([]byte{0x01}, 64), bytes.Repeat([]byte{0x02}, 64), RelativeLockSequence(10, false), nil},
+            {bytes.Repeat([]byte{0xbb}, 32), 0, nil, nil, SequenceMaxReplaceable, bytes.Repeat([]byte{0x55}, 32)},
+        },
+        []TXOutput{*NewTXOutput(1000, address), *NewHTLCOutput(250, contract), *data, {}},
+        500000,
+    }
+
+    return []Transaction{coinbase, spend}
+}
+
+/*
+交易 ID 写入区块和 utxo 数据库，编码的任何变化都会使已有的交易无法通过校验
+这里的 ID 由交易移入 types 包之前的 gob 编码得到，不能修改
+*/
+func TestTransactionIDPinned(t *testing.T) {
+    want := []struct{ hash, id string }{
+        {"bf2772fabd19cf185961a06ffc9011f8207a8e77b68dc7b5f433618345e59673", "bf2772fabd19cf185961a06ffc9011f8207a8e77b68dc7b5f433618345e59673"},
+        {"1e2376c37e78790f5f85b232771fd0a759cd388544e73e8724816eeff86f51a0", "c4cf8ded3e95d975da9aeefca792e4ffd6857e675e913f6321db7b4a001c8620"},
+    }
+
+    for i, tx := range pinnedTransactions() {
+        if got := hex.EncodeToString(tx.Hash()); got != want[i].hash { t.Errorf("transaction %d: hash %s, want %s", i, got, want[i].hash) }
+        if got := hex.EncodeToString(tx.UnsignedHash()); got != want[i].id { t.Errorf("transaction %d: ID %s, want %s", i, got, want[i].id) }
+    }
+}
+
+// 交易的编码可以用 gob 解码
+func TestSerializeDecodesWithGob(t *testing.T) {
+    for i, tx := range pinnedTransactions() {
+        tx.ID = tx.UnsignedHash()
+
+        decoded, err := DeserializeTransaction(tx.Serialize())
+        if err != nil { t.Fatalf("transaction %d: %v", i, err) }
+        if !bytes.Equal(decoded.Serialize(), tx.Serialize()) { t.Errorf("transaction %d: decoded transaction encodes differently", i) }
+        if !reflect.DeepEqual(decoded.Vout[0], tx.Vout[0]) { t.Errorf("transaction %d: output %+v, want %+v", i, decoded.Vout[0], tx.Vout[0]) }
+    }
+}
+
+// 签名不影响 ID，修改交易内容或冒用其他交易的 ID 不能通过校验
+func TestCheckID(t *testing.T) {
+    txs := pinnedTransactions()
+    coinbase, spend := txs[0], txs[1]
+    coinbase.ID = coinbase.UnsignedHash()
+    spend.ID = spend.UnsignedHash()
+
+    if err := spend.CheckID(); err != nil { t.Fatal(err) }
+
+    spend.Vin[0].Signature = bytes.Repeat([]byte{0x03}, 64)
+    if err := spend.CheckID(); err != nil { t.Errorf("signature changed the ID: %v", err) }
+
+    spend.Vout[0].Value++
+    if err := spend.CheckID(); !errors.Is(err, ErrInvalidTxID) { t.Errorf("modified transaction: got %v, want %v", err, ErrInvalidTxID) }
+
+    coinbase.ID = spend.ID
+    if err := coinbase.CheckID(); !errors.Is(err, ErrInvalidTxID) { t.Errorf("forged ID: got %v, want %v", err, ErrInvalidTxID) }
+}
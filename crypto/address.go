@@ -0,0 +1,68 @@
+package crypto
+
+import (
+    "bytes"
+    "errors"
+    "crypto/sha256"
+
+    "golang.org/x/crypto/ripemd160"
+)
+
+const AddressChecksumLen = 4
+const version = byte(0x00)
+
+var ErrInvalidAddress = errors.New("ERROR: Address is not valid")
+
+// 将pubKeyHash []byte 转换成address string
+func PubKeyHashToAddress(pubKeyHash []byte) string {
+    versionedPayload := append([]byte{ version }, pubKeyHash...)
+    checksum := Checksum(versionedPayload)
+
+    fullPayload := append(versionedPayload, checksum...)
+    address := Base58Encode(fullPayload)
+
+    return string(address)
+}
+
+// 将address string 转换成pubKeyHash []byte
+// address 需已经通过 ValidateAddress 校验，无法解码时返回 nil
+func AddressToPubKeyHash(address string) []byte {
+    payload, err := Base58Decode([]byte(address))
+    if err != nil || len(payload) <= AddressChecksumLen { return nil }
+
+    return payload[1 : len(payload) - AddressChecksumLen]
+}
+
+func ValidateAddress(address string) bool {
+    pubKeyHash, err := Base58Decode([]byte(address))
+    if err != nil || len(pubKeyHash) <= AddressChecksumLen { return false }
+
+    actualChecksum := pubKeyHash[len(pubKeyHash) - AddressChecksumLen:]
+    version := pubKeyHash[0]
+    pubKeyHash = pubKeyHash[1:len(pubKeyHash) - AddressChecksumLen]
+
+    targetChecksum := Checksum(append([]byte{version}, pubKeyHash...))
+
+    return bytes.Compare(actualChecksum, targetChecksum) == 0
+}
+
+// hash PublicKey
+func HashPubKey(pubKey []byte) []byte {
+    publicSHA256 := sha256.Sum256(pubKey)
+
+    RIPEMD160Hasher := ripemd160.New()
+    // hash.Hash 的 Write 不会返回错误
+    RIPEMD160Hasher.Write(publicSHA256[:])
+
+    publicRIPEMD160 := RIPEMD160Hasher.Sum(nil)
+
+	return publicRIPEMD160
+}
+
+// just sha256.Sum256 twice
+func Checksum(payload []byte) []byte {
+    firstSHA := sha256.Sum256(payload)
+    secondSHA := sha256.Sum256(firstSHA[:])
+
+    return secondSHA[:AddressChecksumLen]
+}
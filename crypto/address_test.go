@@ -0,0 +1,39 @@
+package crypto
+
+import (
+    "bytes"
+    "testing"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+)
+
+func TestAddress(t *testing.T) {
+    // 全 0 的 pubKeyHash 对应的比特币地址
+    pubKeyHash := make([]byte, 20)
+    address := PubKeyHashToAddress(pubKeyHash)
+    if address != "1111111111111111111114oLvT2" { t.Fatalf("address %s, want 1111111111111111111114oLvT2", address) }
+
+    if !ValidateAddress(address) { t.Errorf("%s is not valid", address) }
+    if got := AddressToPubKeyHash(address); !bytes.Equal(got, pubKeyHash) { t.Errorf("pubKeyHash %x, want %x", got, pubKeyHash) }
+
+    // 校验和不符
+    if ValidateAddress("1111111111111111111114oLvT3") { t.Errorf("address with a wrong checksum is valid") }
+    if ValidateAddress("") { t.Errorf("empty address is valid") }
+    if ValidateAddress("1111111111111111111114oLvT0") { t.Errorf("address with an invalid character is valid") }
+    if AddressToPubKeyHash("0OIl") != nil { t.Errorf("invalid address has a public key hash") }
+}
+
+func TestSignMessage(t *testing.T) {
+    privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil { t.Fatal(err) }
+    pubKey := append(privKey.PublicKey.X.Bytes(), privKey.PublicKey.Y.Bytes()...)
+    address := PubKeyHashToAddress(HashPubKey(pubKey))
+
+    signature, err := SignMessage(*privKey, "hello")
+    if err != nil { t.Fatal(err) }
+
+    if !VerifyMessage(address, signature, "hello") { t.Errorf("signature is not valid") }
+    if VerifyMessage(address, signature, "hello!") { t.Errorf("signature is valid for another message") }
+    if VerifyMessage(PubKeyHashToAddress(make([]byte, 20)), signature, "hello") { t.Errorf("signature is valid for another address") }
+}
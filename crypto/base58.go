@@ -1,12 +1,17 @@
-package main
+package crypto
 
 import (
-	"bytes"
-	"math/big"
+    "bytes"
+    "errors"
+    "math/big"
+
+    "github.com/guoxingx/simple-blockchain/common"
 )
 
 var b58Alphabet = []byte("123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
 
+var ErrInvalidBase58 = errors.New("ERROR: Invalid base58 string")
+
 // Base58Encode encodes a byte array to Base58
 func Base58Encode(input []byte) []byte {
     var result []byte
@@ -22,8 +27,8 @@ func Base58Encode(input []byte) []byte {
         result = append(result, b58Alphabet[mod.Int64()])
     }
 
-    ReverseBytes(result)
-    for b := range input {
+    common.ReverseBytes(result)
+    for _, b := range input {
         if b == 0x00 {
             result = append([]byte{ b58Alphabet[0] }, result...)
         } else { break }
@@ -33,17 +38,22 @@ func Base58Encode(input []byte) []byte {
 }
 
 // Base58Decode decodes Base58-encoded data
-func Base58Decode(input []byte) []byte {
+// 包含字母表之外的字符时返回 ErrInvalidBase58
+func Base58Decode(input []byte) ([]byte, error) {
     result := big.NewInt(0)
     zeroBytes := 0
 
-    for b := range input {
-        if b == 0x00 { zeroBytes++ }
+    // 每个前导的 '1' 对应一个 0x00 字节
+    for _, b := range input {
+        if b == b58Alphabet[0] {
+            zeroBytes++
+        } else { break }
     }
 
     payload := input[zeroBytes:]
     for _, b := range payload {
         charIndex := bytes.IndexByte(b58Alphabet, b)
+        if charIndex < 0 { return nil, ErrInvalidBase58 }
         result.Mul(result, big.NewInt(58))
         result.Add(result, big.NewInt(int64(charIndex)))
     }
@@ -51,5 +61,5 @@ func Base58Decode(input []byte) []byte {
     decoded := result.Bytes()
     decoded = append(bytes.Repeat([]byte{ byte(0x00) }, zeroBytes), decoded...)
 
-    return decoded
+    return decoded, nil
 }
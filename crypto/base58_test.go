@@ -0,0 +1,32 @@
+package crypto
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+)
+
+func TestBase58Encode(t *testing.T) {
+    tests := []struct {
+        input []byte
+        want  string
+    }{
+        {[]byte("Hello World!"), "2NEpo7TZRRrLZSi2U"},
+        {[]byte{0x00, 0x00, 0x28, 0x7f, 0xb4, 0xcd}, "11233QC4"},
+        {[]byte{0x00}, "1"},
+    }
+
+    for _, test := range tests {
+        if got := string(Base58Encode(test.input)); got != test.want { t.Errorf("Base58Encode(%x) = %s, want %s", test.input, got, test.want) }
+        got, err := Base58Decode([]byte(test.want))
+        if err != nil { t.Errorf("Base58Decode(%s): %v", test.want, err); continue }
+        if !bytes.Equal(got, test.input) { t.Errorf("Base58Decode(%s) = %x, want %x", test.want, got, test.input) }
+    }
+}
+
+// 字母表不包含 0, O, I, l
+func TestBase58DecodeInvalid(t *testing.T) {
+    for _, input := range []string{"0", "O", "I", "l", "2NEpo7TZRRrLZSi2U0", "11 2"} {
+        if _, err := Base58Decode([]byte(input)); !errors.Is(err, ErrInvalidBase58) { t.Errorf("Base58Decode(%q): got %v, want %v", input, err, ErrInvalidBase58) }
+    }
+}
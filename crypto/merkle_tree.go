@@ -1,7 +1,6 @@
-package main
+package crypto
 
 import (
-    "log"
     "crypto/sha256"
 )
 
@@ -52,8 +51,9 @@ func NewMerkleTree(data [][]byte) *MerkleTree {
 
 // generate a root node by given nodes.
 func NewRootMerkleNode(nodes []*MerkleNode) *MerkleNode {
+    // 空树的根为空数据的 hash
     if len(nodes) == 0 {
-        log.Panic("there is no merkle node.")
+        return NewMerkleNode(nil, nil, nil)
     }
 
     if len(nodes) == 1 {
@@ -0,0 +1,135 @@
+package crypto
+
+/*
+消息签名，与比特币的 signmessage 类似：
+    对 sha256(sha256(magic + message)) 签名，magic 使签名不能被当作交易签名使用
+    签名为 65 字节: 1 字节的恢复标识 + 32 字节 r + 32 字节 s
+    验证时由签名和消息恢复出公钥，只需要地址即可验证
+*/
+
+import (
+    "bytes"
+    "errors"
+    "math/big"
+    "crypto/rand"
+    "crypto/ecdsa"
+    "crypto/sha256"
+    "crypto/elliptic"
+    "encoding/binary"
+)
+
+const messageMagic = "Simple Blockchain Signed Message:\n"
+const compactSignatureLen = 65
+const compactSignatureHeader = byte(27)
+
+var ErrInvalidMessageSignature = errors.New("ERROR: Invalid message signature")
+
+// 消息的 hash，magic 和消息前均写入 varint 长度
+func messageHash(message string) []byte {
+    var buf bytes.Buffer
+    lenBuf := make([]byte, binary.MaxVarintLen64)
+
+    n := binary.PutUvarint(lenBuf, uint64(len(messageMagic)))
+    buf.Write(lenBuf[:n])
+    buf.WriteString(messageMagic)
+
+    n = binary.PutUvarint(lenBuf, uint64(len(message)))
+    buf.Write(lenBuf[:n])
+    buf.WriteString(message)
+
+    first := sha256.Sum256(buf.Bytes())
+    second := sha256.Sum256(first[:])
+
+    return second[:]
+}
+
+// 用私钥对消息签名，返回 65 字节的签名
+func SignMessage(privKey ecdsa.PrivateKey, message string) ([]byte, error) {
+    hash := messageHash(message)
+    pubKey := append(privKey.PublicKey.X.Bytes(), privKey.PublicKey.Y.Bytes()...)
+
+    r, s, err := ecdsa.Sign(rand.Reader, &privKey, hash)
+    if err != nil { return nil, err }
+
+    signature := make([]byte, compactSignatureLen)
+    r.FillBytes(signature[1:33])
+    s.FillBytes(signature[33:])
+
+    // 找到能恢复出该公钥的恢复标识
+    for recID := byte(0); recID < 4; recID++ {
+        signature[0] = compactSignatureHeader + recID
+
+        recovered, err := RecoverPubKey(signature, message)
+        if err == nil && bytes.Compare(recovered, pubKey) == 0 {
+            return signature, nil
+        }
+    }
+    return nil, ErrInvalidMessageSignature
+}
+
+/*
+由签名恢复公钥
+    R.x = r + (recID / 2) * N，R.y 的奇偶由 recID % 2 决定
+    Q = r^-1 * (s * R - e * G)
+*/
+func RecoverPubKey(signature []byte, message string) ([]byte, error) {
+    if len(signature) != compactSignatureLen { return nil, ErrInvalidMessageSignature }
+
+    curve := elliptic.P256()
+    params := curve.Params()
+
+    recID := int(signature[0]) - int(compactSignatureHeader)
+    if recID < 0 || recID > 3 { return nil, ErrInvalidMessageSignature }
+
+    r := new(big.Int).SetBytes(signature[1:33])
+    s := new(big.Int).SetBytes(signature[33:])
+    if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(params.N) >= 0 || s.Cmp(params.N) >= 0 {
+        return nil, ErrInvalidMessageSignature
+    }
+
+    // R.x
+    x := new(big.Int).Mul(params.N, big.NewInt(int64(recID / 2)))
+    x.Add(x, r)
+    if x.Cmp(params.P) >= 0 { return nil, ErrInvalidMessageSignature }
+
+    // R.y: y^2 = x^3 - 3x + b
+    y2 := new(big.Int).Exp(x, big.NewInt(3), params.P)
+    threeX := new(big.Int).Mul(x, big.NewInt(3))
+    y2.Sub(y2, threeX)
+    y2.Add(y2, params.B)
+    y2.Mod(y2, params.P)
+
+    y := new(big.Int).ModSqrt(y2, params.P)
+    if y == nil { return nil, ErrInvalidMessageSignature }
+    if int(y.Bit(0)) != recID % 2 { y.Sub(params.P, y) }
+
+    // s * R
+    sRx, sRy := curve.ScalarMult(x, y, s.Bytes())
+
+    // -e * G
+    e := new(big.Int).SetBytes(messageHash(message))
+    negE := new(big.Int).Neg(e)
+    negE.Mod(negE, params.N)
+    eGx, eGy := curve.ScalarBaseMult(negE.Bytes())
+
+    // r^-1 * (s * R - e * G)
+    sumX, sumY := curve.Add(sRx, sRy, eGx, eGy)
+    if sumX.Sign() == 0 && sumY.Sign() == 0 { return nil, ErrInvalidMessageSignature }
+
+    rInv := new(big.Int).ModInverse(r, params.N)
+    qx, qy := curve.ScalarMult(sumX, sumY, rInv.Bytes())
+
+    // 使用公钥验证签名，防止恢复出错误的公钥
+    pub := ecdsa.PublicKey{Curve: curve, X: qx, Y: qy}
+    if !ecdsa.Verify(&pub, messageHash(message), r, s) { return nil, ErrInvalidMessageSignature }
+
+    return append(qx.Bytes(), qy.Bytes()...), nil
+}
+
+// 验证签名是否由 address 的私钥对 message 做出
+func VerifyMessage(address string, signature []byte, message string) bool {
+    pubKey, err := RecoverPubKey(signature, message)
+    if err != nil { return false }
+
+    return bytes.Compare(HashPubKey(pubKey), AddressToPubKeyHash(address)) == 0
+}
@@ -1,6 +1,10 @@
 package main
 
+import (
+    "github.com/guoxingx/simple-blockchain/cli"
+)
+
 func main() {
-    cli := CLI{}
-    cli.Run()
+    c := cli.CLI{}
+    c.Run()
 }
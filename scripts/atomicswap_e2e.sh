@@ -0,0 +1,82 @@
+#!/usr/bin/env bash
+#
+# 在两条本地链之间完成一次原子交换，以及一次超时取回
+#   chain1: A 发起 (initiate)，B 取走
+#   chain2: B 参与 (participate)，A 取走
+# 为了简化，每条链的钱包同时保存双方的地址
+#
+# usage: scripts/atomicswap_e2e.sh
+#   BIN=./blockchain scripts/atomicswap_e2e.sh  使用已编译的程序
+
+set -euo pipefail
+
+ROOT=$(cd "$(dirname "$0")/.." && pwd)
+WORK=$(mktemp -d)
+trap 'rm -rf "$WORK"' EXIT
+
+if [ -z "${BIN:-}" ]; then
+    BIN="$WORK/blockchain"
+    (cd "$ROOT" && go build -o "$BIN")
+fi
+BIN=$(cd "$(dirname "$BIN")" && pwd)/$(basename "$BIN")
+
+mkdir -p "$WORK/chain1/data" "$WORK/chain2/data"
+
+chain1() { (cd "$WORK/chain1" && "$BIN" "$@"); }
+chain2() { (cd "$WORK/chain2" && "$BIN" "$@"); }
+
+# 取出 "Key: value" 格式输出中的 value
+field() { sed -n "s/^$1: *//p"; }
+
+fail() { echo "FAIL: $*" >&2; exit 1; }
+
+expect_balance() {
+    local chain=$1 address=$2 expected=$3
+    local balance
+    balance=$($chain getbalance -account "$address" | sed -n 's/.*: //p')
+    [ "$balance" = "$expected" ] || fail "$chain $address balance $balance, expected $expected"
+}
+
+echo "==> Setting up chains"
+A1=$(chain1 createwallet | field "Your new address")
+B1=$(chain1 createwallet | field "Your new address")
+A2=$(chain2 createwallet | field "Your new address")
+B2=$(chain2 createwallet | field "Your new address")
+chain1 createchain -account "$A1" > /dev/null
+chain2 createchain -account "$B2" > /dev/null
+
+echo "==> A initiates on chain1"
+OUT=$(chain1 initiate -from "$A1" -to "$B1" -amount 10 -timeout 20)
+SECRET=$(echo "$OUT" | field "Secret")
+SECRET_HASH=$(echo "$OUT" | field "Secret hash")
+CONTRACT1=$(echo "$OUT" | field "Contract")
+
+echo "==> B participates on chain2"
+CONTRACT2=$(chain2 participate -from "$B2" -to "$A2" -amount 10 -secrethash "$SECRET_HASH" -timeout 10 | field "Contract")
+
+echo "==> B can not redeem without the secret"
+if chain2 redeem -contract "$CONTRACT2" -secret 00 > /dev/null 2>&1; then fail "redeemed with a wrong secret"; fi
+
+echo "==> A redeems on chain2"
+chain2 redeem -contract "$CONTRACT2" -secret "$SECRET" > /dev/null
+
+echo "==> B extracts the secret and redeems on chain1"
+EXTRACTED=$(chain2 extractsecret -contract "$CONTRACT2" | field "Secret")
+[ "$EXTRACTED" = "$SECRET" ] || fail "extracted secret $EXTRACTED, expected $SECRET"
+chain1 redeem -contract "$CONTRACT1" -secret "$EXTRACTED" > /dev/null
+
+# 每个区块奖励 26，由打包交易的一方获得
+expect_balance chain1 "$A1" 42 # 创世区块 26 - 10 + initiate 区块 26
+expect_balance chain1 "$B1" 36 # redeem 区块 26 + 10
+expect_balance chain2 "$B2" 42
+expect_balance chain2 "$A2" 36
+
+echo "==> A initiates on chain1 and refunds after the timeout"
+CONTRACT3=$(chain1 initiate -from "$A1" -to "$B1" -amount 5 -timeout 1 | field "Contract")
+chain1 refund -contract "$CONTRACT3" | grep -q "added to mempool" || fail "refund was not time locked"
+chain1 mine -miner "$B1" > /dev/null
+chain1 mine -miner "$B1" > /dev/null
+expect_balance chain1 "$A1" 66 # 42 - 5 + initiate 区块 26 + 5 - refund 手续费 2
+expect_balance chain1 "$B1" 90
+
+echo "PASS"
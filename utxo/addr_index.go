@@ -0,0 +1,101 @@
+package utxo
+
+/*
+地址索引：以 pubKeyHash 为前缀索引全部未花费输出
+    key 为 len(pubKeyHash) | pubKeyHash | txID | vout，value 为空，输出本身仍从 chainstate 读取
+    HTLC 输出的 pubKeyHash 为空，索引在长度为 0 的前缀下
+与 chainstate 在同一个数据库事务中更新，查找一个地址的输出只需要遍历该地址的索引
+*/
+
+import (
+    "fmt"
+    "bytes"
+    "errors"
+    "encoding/binary"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+const AddrIndexBucket = "addrindex"
+
+var ErrCorruptIndex = errors.New("ERROR: Address index entry is not in the UTXO set")
+
+func addrIndexPrefix(pubKeyHash []byte) []byte {
+    return append([]byte{byte(len(pubKeyHash))}, pubKeyHash...)
+}
+
+func addrIndexKey(pubKeyHash, txID []byte, vout int) []byte {
+    key := append(addrIndexPrefix(pubKeyHash), txID...)
+
+    voutBytes := make([]byte, 4)
+    binary.BigEndian.PutUint32(voutBytes, uint32(vout))
+    return append(key, voutBytes...)
+}
+
+// 索引中一个输出的位置
+func parseAddrIndexKey(key []byte) ([]byte, int) {
+    txID := key[1 + int(key[0]) : len(key) - 4]
+    vout := int(binary.BigEndian.Uint32(key[len(key) - 4:]))
+
+    return append([]byte{}, txID...), vout
+}
+
+func indexOutput(b *bolt.Bucket, txID []byte, vout int, out types.TXOutput) error {
+    return b.Put(addrIndexKey(out.PubKeyHash, txID, vout), []byte{})
+}
+
+func unindexOutput(b *bolt.Bucket, txID []byte, vout int, out types.TXOutput) error {
+    return b.Delete(addrIndexKey(out.PubKeyHash, txID, vout))
+}
+
+// 由 chainstate 重建地址索引
+func rebuildAddrIndex(tx *bolt.Tx) error {
+    if tx.Bucket([]byte(AddrIndexBucket)) != nil {
+        if err := tx.DeleteBucket([]byte(AddrIndexBucket)); err != nil { return err }
+    }
+    index, err := tx.CreateBucket([]byte(AddrIndexBucket))
+    if err != nil { return err }
+
+    return tx.Bucket([]byte(Bucket)).ForEach(func(k, v []byte) error {
+        outs, err := types.DeserializeOutputs(v)
+        if err != nil { return err }
+        for outIdx, out := range outs.Outputs {
+            if err := indexOutput(index, k, outIdx, out); err != nil { return err }
+        }
+        return nil
+    })
+}
+
+// 没有地址索引的旧数据库，打开时建立索引
+func (u Set) EnsureAddrIndex() error {
+    return u.db.Update(func(tx *bolt.Tx) error {
+        if tx.Bucket([]byte(AddrIndexBucket)) != nil || tx.Bucket([]byte(Bucket)) == nil { return nil }
+        return rebuildAddrIndex(tx)
+    })
+}
+
+// 遍历 pubKeyHash 的全部未花费输出，按 txID 和 vout 排列
+func (u Set) forEachAddressOutput(pubKeyHash []byte, fn func(txID []byte, vout int, out types.TXOutput)) error {
+    prefix := addrIndexPrefix(pubKeyHash)
+
+    return u.db.View(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(Bucket))
+        c := tx.Bucket([]byte(AddrIndexBucket)).Cursor()
+
+        for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+            txID, vout := parseAddrIndexKey(k)
+
+            var out types.TXOutput
+            ok := false
+            if outsBytes := b.Get(txID); outsBytes != nil {
+                outs, err := types.DeserializeOutputs(outsBytes)
+                if err != nil { return err }
+                out, ok = outs.Outputs[vout]
+            }
+            if !ok { return fmt.Errorf("%w: %s", ErrCorruptIndex, types.OutpointKey(txID, vout)) }
+            fn(txID, vout, out)
+        }
+        return nil
+    })
+}
@@ -0,0 +1,341 @@
+package utxo
+
+/*
+utxo 承诺：全部未花费输出组成的稀疏 merkle 树，树根写入区块头 (Header.UTXORoot)
+    每个输出是一个叶子，位置为 sha256(txID | vout) 的 256 位，从高位开始，0 向左 1 向右
+    叶子为 sha256(0x00 | 位置 | 输出)，空叶子为 32 字节 0
+    内部节点为 sha256(0x01 | 左 | 右)，空子树的 hash 预先计算，见 emptyStateNodes
+    只保存非空的节点，UTXOSet.Update 时逐个更新输出所在的路径
+轻客户端只需要区块头，即可用证明确认一个输出未被花费（包含证明）或者不存在（排除证明）
+*/
+
+import (
+    "bytes"
+    "errors"
+    "crypto/sha256"
+    "encoding/binary"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 稀疏 merkle 树的节点，以 层数 | 路径前缀 索引
+const StateTreeBucket = "utxotree"
+const stateTreeDepth = 256
+
+var ErrInvalidUTXORoot = errors.New("ERROR: Invalid block: UTXO root does not match the UTXO set")
+var ErrInvalidUTXOProof = errors.New("ERROR: Invalid UTXO proof")
+
+// 每一层空子树的 hash，第 stateTreeDepth 层为叶子
+var emptyStateNodes [stateTreeDepth + 1][]byte
+
+func init() {
+    emptyStateNodes[stateTreeDepth] = make([]byte, common.HashLength)
+    for level := stateTreeDepth - 1; level >= 0; level-- {
+        emptyStateNodes[level] = hashStateNodes(emptyStateNodes[level + 1], emptyStateNodes[level + 1])
+    }
+}
+
+// 树节点的存储，*bolt.Bucket 和 stateOverlay 都满足
+type stateNodes interface {
+    Get(key []byte) []byte
+    Put(key, value []byte) error
+    Delete(key []byte) error
+}
+
+// 只读的 bucket 之上的修改，用于计算尚未写入的区块之后的树根
+type stateOverlay struct {
+    base    *bolt.Bucket // 可以为 nil
+    changes map[string][]byte // 被删除的节点为 nil
+}
+
+func newStateOverlay(base *bolt.Bucket) *stateOverlay {
+    return &stateOverlay{base, make(map[string][]byte)}
+}
+
+func (o *stateOverlay) Get(key []byte) []byte {
+    if value, ok := o.changes[string(key)]; ok { return value }
+    if o.base == nil { return nil }
+    return o.base.Get(key)
+}
+
+func (o *stateOverlay) Put(key, value []byte) error {
+    o.changes[string(key)] = value
+    return nil
+}
+
+func (o *stateOverlay) Delete(key []byte) error {
+    o.changes[string(key)] = nil
+    return nil
+}
+
+func hashStateNodes(left, right []byte) []byte {
+    hash := sha256.Sum256(bytes.Join([][]byte{{0x01}, left, right}, []byte{}))
+    return hash[:]
+}
+
+// 输出在树中的位置
+func statePath(txID []byte, vout int) []byte {
+    buf := make([]byte, 4)
+    binary.BigEndian.PutUint32(buf, uint32(vout))
+
+    hash := sha256.Sum256(append(append([]byte{}, txID...), buf...))
+    return hash[:]
+}
+
+// 输出的叶子
+func stateLeaf(path []byte, out types.TXOutput) []byte {
+    var buff bytes.Buffer
+    sw := NewSnapshotWriter(&buff)
+    sw.WriteSnapshotOutput(out)
+
+    hash := sha256.Sum256(bytes.Join([][]byte{{0x00}, path, buff.Bytes()}, []byte{}))
+    return hash[:]
+}
+
+// path 的第 i 位
+func pathBit(path []byte, i int) byte {
+    return (path[i / 8] >> uint(7 - i % 8)) & 1
+}
+
+// 第 level 层，路径前 level 位为 path 的节点的 key
+func stateNodeKey(level int, path []byte) []byte {
+    key := make([]byte, 2 + (level + 7) / 8)
+    binary.BigEndian.PutUint16(key, uint16(level))
+    copy(key[2:], path)
+
+    if level % 8 != 0 { key[len(key) - 1] &= byte(0xff) << uint(8 - level % 8) }
+    return key
+}
+
+func getStateNode(nodes stateNodes, level int, path []byte) []byte {
+    if node := nodes.Get(stateNodeKey(level, path)); node != nil { return node }
+    return emptyStateNodes[level]
+}
+
+func putStateNode(nodes stateNodes, level int, path []byte, node []byte) error {
+    if bytes.Compare(node, emptyStateNodes[level]) == 0 { return nodes.Delete(stateNodeKey(level, path)) }
+    return nodes.Put(stateNodeKey(level, path), node)
+}
+
+// 将 path 的叶子设置为 leaf，并更新到树根的路径
+func setStateLeaf(nodes stateNodes, path []byte, leaf []byte) error {
+    node := leaf
+    sibling := make([]byte, len(path))
+
+    for level := stateTreeDepth; level > 0; level-- {
+        if err := putStateNode(nodes, level, path, node); err != nil { return err }
+
+        copy(sibling, path)
+        sibling[(level - 1) / 8] ^= 1 << uint(7 - (level - 1) % 8)
+        siblingNode := getStateNode(nodes, level, sibling)
+
+        if pathBit(path, level - 1) == 0 {
+            node = hashStateNodes(node, siblingNode)
+        } else {
+            node = hashStateNodes(siblingNode, node)
+        }
+    }
+
+    return putStateNode(nodes, 0, path, node)
+}
+
+// 按区块内交易的顺序移除被花费的输出，加入新的输出
+func applyStateTransactions(nodes stateNodes, transactions []*types.Transaction) error {
+    for _, tx := range transactions {
+        if !tx.IsCoinbase() {
+            for _, vin := range tx.Vin {
+                err := setStateLeaf(nodes, statePath(vin.Txid, vin.Vout), emptyStateNodes[stateTreeDepth])
+                if err != nil { return err }
+            }
+        }
+
+        for outIdx, out := range tx.Vout {
+            if out.IsUnspendable() { continue }
+
+            path := statePath(tx.ID, outIdx)
+            if err := setStateLeaf(nodes, path, stateLeaf(path, out)); err != nil { return err }
+        }
+    }
+    return nil
+}
+
+// 根据 chainstate 重建树
+func rebuildStateTree(tx *bolt.Tx) error {
+    if tx.Bucket([]byte(StateTreeBucket)) != nil {
+        if err := tx.DeleteBucket([]byte(StateTreeBucket)); err != nil { return err }
+    }
+    nodes, err := tx.CreateBucket([]byte(StateTreeBucket))
+    if err != nil { return err }
+
+    return tx.Bucket([]byte(Bucket)).ForEach(func(k, v []byte) error {
+        outs, err := types.DeserializeOutputs(v)
+        if err != nil { return err }
+        for _, outIdx := range outs.Indexes() {
+            path := statePath(k, outIdx)
+            if err := setStateLeaf(nodes, path, stateLeaf(path, outs.Outputs[outIdx])); err != nil { return err }
+        }
+        return nil
+    })
+}
+
+func stateRoot(nodes stateNodes) common.Hash {
+    var root common.Hash
+    root.SetBytes(getStateNode(nodes, 0, nil))
+    return root
+}
+
+// 只包含 transactions 的输出的 utxo 承诺的树根，用于创世区块
+func GenesisStateRoot(transactions []*types.Transaction) (common.Hash, error) {
+    overlay := newStateOverlay(nil)
+    if err := applyStateTransactions(overlay, transactions); err != nil { return common.Hash{}, err }
+
+    return stateRoot(overlay), nil
+}
+
+// 当前 utxo 的树根
+func (u Set) StateRoot() (common.Hash, error) {
+    var root common.Hash
+
+    err := u.db.View(func(tx *bolt.Tx) error {
+        root = stateRoot(newStateOverlay(tx.Bucket([]byte(StateTreeBucket))))
+        return nil
+    })
+
+    return root, err
+}
+
+// 写入 transactions 之后的树根，不修改数据库
+func (u Set) StateRootAfter(transactions []*types.Transaction) (common.Hash, error) {
+    var root common.Hash
+
+    err := u.db.View(func(tx *bolt.Tx) error {
+        overlay := newStateOverlay(tx.Bucket([]byte(StateTreeBucket)))
+        if err := applyStateTransactions(overlay, transactions); err != nil { return err }
+
+        root = stateRoot(overlay)
+        return nil
+    })
+
+    return root, err
+}
+
+// 旧的数据库没有 utxo 树时，根据 chainstate 建立
+func (u Set) EnsureStateTree() error {
+    return u.db.Update(func(tx *bolt.Tx) error {
+        if tx.Bucket([]byte(StateTreeBucket)) != nil || tx.Bucket([]byte(Bucket)) == nil { return nil }
+        return rebuildStateTree(tx)
+    })
+}
+
+// 一个输出的包含或排除证明
+type UTXOProof struct {
+    TxID     []byte
+    Vout     int
+    Output   *types.TXOutput // 为 nil 时证明输出不存在
+    Siblings [][]byte  // 从叶子到树根每一层的兄弟节点，空子树为 nil
+}
+
+// 根据当前 utxo 生成证明，输出和树在同一个只读事务中读取
+func (u Set) ProveOutput(txID []byte, vout int) (*UTXOProof, error) {
+    proof := &UTXOProof{txID, vout, nil, nil}
+
+    path := statePath(txID, vout)
+    sibling := make([]byte, len(path))
+
+    err := u.db.View(func(tx *bolt.Tx) error {
+        if outsBytes := tx.Bucket([]byte(Bucket)).Get(txID); outsBytes != nil {
+            outs, err := types.DeserializeOutputs(outsBytes)
+            if err != nil { return err }
+            if out, ok := outs.Outputs[vout]; ok { proof.Output = &out }
+        }
+
+        nodes := newStateOverlay(tx.Bucket([]byte(StateTreeBucket)))
+
+        for level := stateTreeDepth; level > 0; level-- {
+            copy(sibling, path)
+            sibling[(level - 1) / 8] ^= 1 << uint(7 - (level - 1) % 8)
+
+            var node []byte
+            if stored := nodes.Get(stateNodeKey(level, sibling)); stored != nil { node = append([]byte{}, stored...) }
+            proof.Siblings = append(proof.Siblings, node)
+        }
+        return nil
+    })
+    if err != nil { return nil, err }
+
+    return proof, nil
+}
+
+// 由证明计算树根，与区块头中的 UTXORoot 比较即可验证
+func (p *UTXOProof) Root() (common.Hash, error) {
+    if len(p.Siblings) != stateTreeDepth { return common.Hash{}, ErrInvalidUTXOProof }
+
+    path := statePath(p.TxID, p.Vout)
+    node := emptyStateNodes[stateTreeDepth]
+    if p.Output != nil { node = stateLeaf(path, *p.Output) }
+
+    for i, level := 0, stateTreeDepth; level > 0; i, level = i + 1, level - 1 {
+        sibling := p.Siblings[i]
+        if sibling == nil { sibling = emptyStateNodes[level] }
+
+        if pathBit(path, level - 1) == 0 {
+            node = hashStateNodes(node, sibling)
+        } else {
+            node = hashStateNodes(sibling, node)
+        }
+    }
+
+    var root common.Hash
+    root.SetBytes(node)
+    return root, nil
+}
+
+// 序列化：txID | vout | 是否包含 | 输出 | 非空兄弟节点的位图 | 非空兄弟节点
+func (p *UTXOProof) Serialize() []byte {
+    var buff bytes.Buffer
+    sw := NewSnapshotWriter(&buff)
+
+    sw.WriteBytes(p.TxID)
+    sw.WriteUvarint(uint64(p.Vout))
+    if p.Output == nil {
+        sw.Write([]byte{0})
+    } else {
+        sw.Write([]byte{1})
+        sw.WriteSnapshotOutput(*p.Output)
+    }
+
+    bitmap := make([]byte, stateTreeDepth / 8)
+    for i, sibling := range p.Siblings {
+        if sibling != nil { bitmap[i / 8] |= 1 << uint(7 - i % 8) }
+    }
+    sw.Write(bitmap)
+    for _, sibling := range p.Siblings {
+        if sibling != nil { sw.Write(sibling) }
+    }
+
+    return buff.Bytes()
+}
+
+func DeserializeUTXOProof(data []byte) (*UTXOProof, error) {
+    sr := NewSnapshotReader(bytes.NewReader(data))
+
+    p := &UTXOProof{}
+    p.TxID = sr.ReadBytes()
+    p.Vout = int(sr.ReadUvarint())
+    if included := sr.Read(1); included != nil && included[0] == 1 {
+        out := sr.ReadSnapshotOutput()
+        p.Output = &out
+    }
+
+    bitmap := sr.Read(stateTreeDepth / 8)
+    for i := 0; i < stateTreeDepth && sr.Err == nil; i++ {
+        var sibling []byte
+        if pathBit(bitmap, i) == 1 { sibling = sr.Read(common.HashLength) }
+        p.Siblings = append(p.Siblings, sibling)
+    }
+    if sr.Err != nil { return nil, ErrInvalidUTXOProof }
+
+    return p, nil
+}
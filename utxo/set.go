@@ -0,0 +1,256 @@
+package utxo
+
+import (
+    "fmt"
+    "encoding/hex"
+    "errors"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+const Bucket = "chainstate"
+
+type Set struct {
+    db *bolt.DB
+}
+
+func NewSet(db *bolt.DB) Set {
+    return Set{db}
+}
+
+// 以 utxos 重新加载 utxo bucket，同时重建地址索引和 utxo 承诺
+// utxos 为 map[txID]TXOutputs，见 Blockchain.FindUTXO
+func (u Set) Reindex(utxos map[string]types.TXOutputs) error {
+    return u.db.Update(func(tx *bolt.Tx) error {
+        _, err := ReindexTx(tx, utxos)
+        return err
+    })
+}
+
+// 在数据库事务 tx 中完成 Reindex，返回新的 utxo 承诺的树根
+func ReindexTx(tx *bolt.Tx, utxos map[string]types.TXOutputs) (common.Hash, error) {
+    bucketName := []byte(Bucket)
+    if tx.Bucket(bucketName) != nil {
+        if err := tx.DeleteBucket(bucketName); err != nil { return common.Hash{}, err }
+    }
+    b, err := tx.CreateBucket(bucketName)
+    if err != nil { return common.Hash{}, err }
+
+    for txID, outs := range utxos {
+        key, err := hex.DecodeString(txID)
+        if err != nil { return common.Hash{}, err }
+
+        data, err := outs.Serialize()
+        if err != nil { return common.Hash{}, err }
+
+        err = b.Put(key, data)
+        if err != nil { return common.Hash{}, err }
+    }
+    if err := rebuildAddrIndex(tx); err != nil { return common.Hash{}, err }
+    if err := rebuildStateTree(tx); err != nil { return common.Hash{}, err }
+
+    return stateRoot(tx.Bucket([]byte(StateTreeBucket))), nil
+}
+
+// 在数据库事务 tx 中创建空的 utxo、地址索引和 utxo 承诺的 bucket
+func CreateBuckets(tx *bolt.Tx) error {
+    if _, err := tx.CreateBucket([]byte(StateTreeBucket)); err != nil { return err }
+    if _, err := tx.CreateBucket([]byte(AddrIndexBucket)); err != nil { return err }
+    _, err := tx.CreateBucket([]byte(Bucket))
+    return err
+}
+
+/*
+chainstate 是否为旧的格式，需要由区块重建
+    TXOutputs.Outputs 曾经是 slice，改为以输出序号索引的 map 之后，旧的数据无法解码
+*/
+func (u Set) IsOutdated() (bool, error) {
+    outdated := false
+
+    err := u.db.View(func(tx *bolt.Tx) error {
+        b := tx.Bucket([]byte(Bucket))
+        if b == nil { return nil }
+
+        _, v := b.Cursor().First()
+        if v == nil { return nil }
+
+        _, err := types.DeserializeOutputs(v)
+        outdated = err != nil
+        return nil
+    })
+
+    return outdated, err
+}
+
+// 找到 pubKeyHash 的全部未花费输出及其位置，作为选币的候选
+// 使用地址索引，见 addr_index.go
+func (u Set) FindUnspentOutputs(pubKeyHash []byte) ([]types.UTXO, error) {
+    var UTXOs []types.UTXO
+
+    err := u.forEachAddressOutput(pubKeyHash, func(txID []byte, vout int, out types.TXOutput) {
+        UTXOs = append(UTXOs, types.UTXO{TxID: txID, Index: vout, Output: out})
+    })
+
+    return UTXOs, err
+}
+
+// 找到 pubKeyHash 的所有未花费输出
+func (u Set) FindUTXO(pubKeyHash []byte) ([]types.TXOutput, error) {
+    var UTXOs []types.TXOutput
+
+    err := u.forEachAddressOutput(pubKeyHash, func(txID []byte, vout int, out types.TXOutput) {
+        UTXOs = append(UTXOs, out)
+    })
+
+    return UTXOs, err
+}
+
+// 根据位置找到一个未花费输出，不存在或已被花费时返回 ErrMissingInput
+func (u Set) FindOutput(txID []byte, vout int) (types.TXOutput, error) {
+    outs, err := u.findOutputs(txID)
+    if err != nil { return types.TXOutput{}, err }
+
+    out, ok := outs.Outputs[vout]
+    if !ok { return types.TXOutput{}, fmt.Errorf("%w: %s", ErrMissingInput, types.OutpointKey(txID, vout)) }
+
+    return out, nil
+}
+
+// 交易的全部未花费输出，没有时 Outputs 为空
+func (u Set) findOutputs(txID []byte) (types.TXOutputs, error) {
+    var outs types.TXOutputs
+
+    err := u.db.View(func(tx *bolt.Tx) error {
+        outsBytes := tx.Bucket([]byte(Bucket)).Get(txID)
+        if outsBytes == nil { return nil }
+
+        var err error
+        outs, err = types.DeserializeOutputs(outsBytes)
+        return err
+    })
+
+    return outs, err
+}
+
+// address 的余额，即其全部未花费输出之和
+func (u Set) GetBalance(address string) (int, error) {
+    if !crypto.ValidateAddress(address) { return 0, crypto.ErrInvalidAddress }
+
+    outs, err := u.FindUTXO(crypto.AddressToPubKeyHash(address))
+    if err != nil { return 0, err }
+
+    balance := 0
+    for _, out := range outs {
+        balance += out.Value
+    }
+
+    return balance, nil
+}
+
+// 由未花费输出构造的交易，只有 ID 和输出，已花费的输出为空
+// 签名和校验只需要所花费的输出，因此不需要读取区块，区块被修剪后也可以使用
+// 交易没有未花费输出时返回 ErrMissingInput
+func (u Set) PrevTransaction(txID []byte) (types.Transaction, error) {
+    outs, err := u.findOutputs(txID)
+    if err != nil { return types.Transaction{}, err }
+    if len(outs.Outputs) == 0 { return types.Transaction{}, fmt.Errorf("%w: %x", ErrMissingInput, txID) }
+
+    indexes := outs.Indexes()
+    vout := make([]types.TXOutput, indexes[len(indexes) - 1] + 1)
+    for outIdx, out := range outs.Outputs {
+        vout[outIdx] = out
+    }
+
+    return types.Transaction{ID: txID, Vout: vout}, nil
+}
+
+// 当挖出一个新块时，更新 utxo Bucket
+// 移除已花费输出，并从新挖出来的交易中加入未花费输出
+// 被花费的输出作为区块的 undo 数据保存，见 undo.go
+// 同时更新地址索引和 utxo 承诺，见 addr_index.go 和 commitment.go
+// 区块花费了不存在的输出时返回 ErrMissingInput，utxo 不变
+func (u Set) Update(block *types.Block) error {
+    return u.db.Update(func(tx *bolt.Tx) error {
+        return UpdateTx(tx, block)
+    })
+}
+
+// 在事务 tx 中执行 Update，可以与区块的写入在同一个事务中完成
+func UpdateTx(tx *bolt.Tx, block *types.Block) error {
+    b := tx.Bucket([]byte(Bucket))
+    index, err := tx.CreateBucketIfNotExists([]byte(AddrIndexBucket))
+    if err != nil { return err }
+    var undo BlockUndo
+
+    // 遍历区块中的交易
+    for _, tx := range block.Transactions {
+        if tx.IsCoinbase() == false {
+
+            // 遍历交易的输入
+            for _, vin := range tx.Vin {
+                // 当前交易输入的上一笔输出
+                outsBytes := b.Get(vin.Txid)
+                if outsBytes == nil { return fmt.Errorf("%w: %s", ErrMissingInput, types.OutpointKey(vin.Txid, vin.Vout)) }
+                updatedOut, err := types.DeserializeOutputs(outsBytes)
+                if err != nil { return err }
+                if _, ok := updatedOut.Outputs[vin.Vout]; !ok { return fmt.Errorf("%w: %s", ErrMissingInput, types.OutpointKey(vin.Txid, vin.Vout)) }
+                undo.Spent = append(undo.Spent, SpentOutput{vin.Txid, vin.Vout, updatedOut.Outputs[vin.Vout]})
+                if err := unindexOutput(index, vin.Txid, vin.Vout, updatedOut.Outputs[vin.Vout]); err != nil { return err }
+                delete(updatedOut.Outputs, vin.Vout)
+
+                if len(updatedOut.Outputs) == 0 {
+                    err := b.Delete(vin.Txid)
+                    if err != nil { return err }
+                } else {
+                    data, err := updatedOut.Serialize()
+                    if err != nil { return err }
+
+                    err = b.Put(vin.Txid, data)
+                    if err != nil { return err }
+                }
+            }
+        }
+
+        // 数据输出不可花费，不加入 utxo
+        newOutputs := types.TXOutputs{Outputs: make(map[int]types.TXOutput)}
+        for outIdx, out := range tx.Vout {
+            if out.IsUnspendable() { continue }
+            newOutputs.Outputs[outIdx] = out
+            if err := indexOutput(index, tx.ID, outIdx, out); err != nil { return err }
+        }
+        if len(newOutputs.Outputs) == 0 { continue }
+        // 不能覆盖尚未花费完的同一 ID 的输出
+        if b.Get(tx.ID) != nil { return fmt.Errorf("%w: %x", ErrOutputsExist, tx.ID) }
+
+        data, err := newOutputs.Serialize()
+        if err != nil { return err }
+
+        err = b.Put(tx.ID, data)
+        if err != nil { return err }
+    }
+
+    undoB, err := tx.CreateBucketIfNotExists([]byte(UndoBucket))
+    if err != nil { return err }
+    data, err := undo.Serialize()
+    if err != nil { return err }
+    if err := undoB.Put(block.Hash.Bytes(), data); err != nil { return err }
+
+    nodes, err := tx.CreateBucketIfNotExists([]byte(StateTreeBucket))
+    if err != nil { return err }
+    return applyStateTransactions(nodes, block.Transactions)
+}
+
+var ErrMissingInput = errors.New("ERROR: Transaction input is spent or does not exist")
+var ErrOutputsExist = errors.New("ERROR: Transaction outputs already exist in the UTXO set")
+
+// 找到未花费的合约输出
+func (u Set) FindContract(txID []byte, vout int) (*types.HTLC, int, error) {
+    out, err := u.FindOutput(txID, vout)
+    if err != nil { return nil, 0, err }
+    if out.HTLC == nil { return nil, 0, types.ErrInvalidContract }
+
+    return out.HTLC, out.Value, nil
+}
@@ -0,0 +1,120 @@
+package utxo
+
+import (
+    "bytes"
+    "errors"
+    "testing"
+    "path/filepath"
+
+    "github.com/boltdb/bolt"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+var (
+    addressA = crypto.PubKeyHashToAddress(bytes.Repeat([]byte{0x11}, 20))
+    addressB = crypto.PubKeyHashToAddress(bytes.Repeat([]byte{0x22}, 20))
+)
+
+func newTestSet(t *testing.T) Set {
+    db, err := bolt.Open(filepath.Join(t.TempDir(), "utxo.db"), 0600, nil)
+    if err != nil { t.Fatal(err) }
+    t.Cleanup(func() { db.Close() })
+
+    if err := db.Update(CreateBuckets); err != nil { t.Fatal(err) }
+    return NewSet(db)
+}
+
+func newTestBlock(hash byte, transactions ...*types.Transaction) *types.Block {
+    return &types.Block{Transactions: transactions, Hash: common.Hash{hash}}
+}
+
+// 花费 prevTx 的第一个输出，付给 B amount，其余找零给 A，另有一个数据输出
+func newTestSpend(prevTx *types.Transaction, amount int) *types.Transaction {
+    data, _ := types.NewDataOutput([]byte("test"))
+
+    in := types.TXInput{Txid: prevTx.ID, Vout: 0, Sequence: types.SequenceFinal}
+    out := []types.TXOutput{*types.NewTXOutput(amount, addressB), *types.NewTXOutput(prevTx.Vout[0].Value - amount, addressA), *data}
+    tx := &types.Transaction{Vin: []types.TXInput{in}, Vout: out}
+    tx.ID = tx.UnsignedHash()
+    return tx
+}
+
+func checkBalance(t *testing.T, u Set, address string, want int) {
+    t.Helper()
+    balance, err := u.GetBalance(address)
+    if err != nil { t.Fatal(err) }
+    if balance != want { t.Errorf("balance of %s is %d, want %d", address, balance, want) }
+}
+
+func TestUpdate(t *testing.T) {
+    u := newTestSet(t)
+
+    coinbase := types.NewRewardTx(addressA, "coinbase", 0)
+    if err := u.Update(newTestBlock(1, coinbase)); err != nil { t.Fatal(err) }
+    checkBalance(t, u, addressA, types.Subsidy)
+
+    spend := newTestSpend(coinbase, 10)
+    block := newTestBlock(2, types.NewRewardTx(addressB, "coinbase 2", 0), spend)
+
+    want, err := u.StateRootAfter(block.Transactions)
+    if err != nil { t.Fatal(err) }
+    if err := u.Update(block); err != nil { t.Fatal(err) }
+
+    root, err := u.StateRoot()
+    if err != nil { t.Fatal(err) }
+    if root != want { t.Errorf("UTXO root %x, want %x", root, want) }
+
+    checkBalance(t, u, addressA, types.Subsidy - 10)
+    checkBalance(t, u, addressB, types.Subsidy + 10)
+
+    if _, err := u.FindOutput(coinbase.ID, 0); !errors.Is(err, ErrMissingInput) { t.Errorf("spent output: got %v, want %v", err, ErrMissingInput) }
+    // 数据输出不加入 utxo
+    if _, err := u.FindOutput(spend.ID, 2); !errors.Is(err, ErrMissingInput) { t.Errorf("data output: got %v, want %v", err, ErrMissingInput) }
+    if out, err := u.FindOutput(spend.ID, 1); err != nil || out.Value != types.Subsidy - 10 { t.Errorf("change output: %+v, %v", out, err) }
+
+    // 再次花费同一输出，utxo 不变
+    if err := u.Update(newTestBlock(3, newTestSpend(coinbase, 5))); !errors.Is(err, ErrMissingInput) { t.Errorf("double spend: got %v, want %v", err, ErrMissingInput) }
+    checkBalance(t, u, addressA, types.Subsidy - 10)
+}
+
+// 同一 ID 的输出尚未花费完时不能被覆盖
+func TestUpdateRejectsExistingOutputs(t *testing.T) {
+    u := newTestSet(t)
+
+    coinbase := types.NewRewardTx(addressA, "coinbase", 0)
+    if err := u.Update(newTestBlock(1, coinbase)); err != nil { t.Fatal(err) }
+    if err := u.Update(newTestBlock(2, coinbase)); !errors.Is(err, ErrOutputsExist) { t.Errorf("got %v, want %v", err, ErrOutputsExist) }
+    checkBalance(t, u, addressA, types.Subsidy)
+}
+
+// 未花费和已花费输出的证明都能得到当前的树根，编码之后不变
+func TestProveOutput(t *testing.T) {
+    u := newTestSet(t)
+
+    coinbase := types.NewRewardTx(addressA, "coinbase", 0)
+    spend := newTestSpend(coinbase, 10)
+    if err := u.Update(newTestBlock(1, coinbase)); err != nil { t.Fatal(err) }
+    if err := u.Update(newTestBlock(2, spend)); err != nil { t.Fatal(err) }
+
+    want, err := u.StateRoot()
+    if err != nil { t.Fatal(err) }
+
+    for _, outpoint := range []struct{ txID []byte; vout int; included bool }{{spend.ID, 0, true}, {spend.ID, 1, true}, {coinbase.ID, 0, false}} {
+        proof, err := u.ProveOutput(outpoint.txID, outpoint.vout)
+        if err != nil { t.Fatal(err) }
+        if (proof.Output != nil) != outpoint.included { t.Errorf("%s: included %v, want %v", types.OutpointKey(outpoint.txID, outpoint.vout), proof.Output != nil, outpoint.included) }
+
+        decoded, err := DeserializeUTXOProof(proof.Serialize())
+        if err != nil { t.Fatal(err) }
+
+        for _, p := range []*UTXOProof{proof, decoded} {
+            root, err := p.Root()
+            if err != nil { t.Fatal(err) }
+            if root != want { t.Errorf("%s: proof root %x, want %x", types.OutpointKey(outpoint.txID, outpoint.vout), root, want) }
+        }
+    }
+
+    if _, err := DeserializeUTXOProof([]byte{1, 2, 3}); !errors.Is(err, ErrInvalidUTXOProof) { t.Errorf("got %v, want %v", err, ErrInvalidUTXOProof) }
+}
@@ -0,0 +1,182 @@
+package utxo
+
+/*
+utxo 快照和 utxo 证明的编码，整数为 varint，字节串之前写入 uvarint 长度
+    读写出错后不再读写，错误保存在 err 中，全部读写完成后检查一次即可
+    输出的编码也用于 utxo 承诺的叶子，见 commitment.go
+*/
+
+import (
+    "io"
+    "hash"
+    "bufio"
+    "errors"
+    "math/big"
+    "crypto/sha256"
+    "encoding/binary"
+
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 字节串的长度上限，防止读取损坏的文件时分配过多内存
+const maxSnapshotBytes = 1 << 20
+
+var ErrInvalidSnapshot = errors.New("ERROR: Invalid UTXO snapshot")
+
+type SnapshotWriter struct {
+    w   io.Writer
+    Err error
+}
+
+func NewSnapshotWriter(w io.Writer) *SnapshotWriter {
+    return &SnapshotWriter{w, nil}
+}
+
+func (sw *SnapshotWriter) Write(b []byte) {
+    if sw.Err == nil { _, sw.Err = sw.w.Write(b) }
+}
+
+func (sw *SnapshotWriter) WriteVarint(v int64) {
+    buf := make([]byte, binary.MaxVarintLen64)
+    sw.Write(buf[:binary.PutVarint(buf, v)])
+}
+
+func (sw *SnapshotWriter) WriteUvarint(v uint64) {
+    buf := make([]byte, binary.MaxVarintLen64)
+    sw.Write(buf[:binary.PutUvarint(buf, v)])
+}
+
+func (sw *SnapshotWriter) WriteBytes(b []byte) {
+    sw.WriteUvarint(uint64(len(b)))
+    sw.Write(b)
+}
+
+// 区块头，区块 hash 和交易 ID
+func (sw *SnapshotWriter) WriteSnapshotHeader(block *types.Block) {
+    header := block.Header
+    sw.Write(header.ParentHash.Bytes())
+    sw.Write(header.Miner[:])
+    sw.Write(header.TxHash.Bytes())
+    sw.Write(header.UTXORoot.Bytes())
+    sw.WriteVarint(header.Number.Int64())
+    sw.WriteVarint(header.Timestamp.Int64())
+    sw.Write(header.Nonce[:])
+    sw.Write(block.Hash.Bytes())
+
+    sw.WriteUvarint(uint64(len(block.Transactions)))
+    for _, tx := range block.Transactions {
+        sw.WriteBytes(tx.ID)
+    }
+}
+
+func (sw *SnapshotWriter) WriteSnapshotOutput(out types.TXOutput) {
+    sw.WriteVarint(int64(out.Value))
+    sw.WriteBytes(out.PubKeyHash)
+
+    if out.HTLC == nil {
+        sw.Write([]byte{0})
+    } else {
+        sw.Write([]byte{1})
+        sw.WriteBytes(out.HTLC.SecretHash)
+        sw.WriteBytes(out.HTLC.RecipientPubKeyHash)
+        sw.WriteBytes(out.HTLC.RefundPubKeyHash)
+        sw.WriteVarint(out.HTLC.LockTime)
+    }
+
+    sw.WriteBytes(out.Data)
+}
+
+// 读取的内容同时写入 h，用于计算快照 hash
+type SnapshotReader struct {
+    r   *bufio.Reader
+    h   hash.Hash
+    Err error
+}
+
+func NewSnapshotReader(r io.Reader) *SnapshotReader {
+    return &SnapshotReader{bufio.NewReader(r), sha256.New(), nil}
+}
+
+// 已读取的内容的 sha256
+func (sr *SnapshotReader) Sum() []byte {
+    return sr.h.Sum(nil)
+}
+
+func (sr *SnapshotReader) ReadByte() (byte, error) {
+    b, err := sr.r.ReadByte()
+    if err != nil { return 0, err }
+
+    sr.h.Write([]byte{b})
+    return b, nil
+}
+
+func (sr *SnapshotReader) Read(n int) []byte {
+    if sr.Err != nil { return nil }
+
+    buf := make([]byte, n)
+    if _, err := io.ReadFull(sr.r, buf); err != nil {
+        sr.Err = ErrInvalidSnapshot
+        return nil
+    }
+    sr.h.Write(buf)
+    return buf
+}
+
+func (sr *SnapshotReader) ReadVarint() int64 {
+    if sr.Err != nil { return 0 }
+
+    v, err := binary.ReadVarint(sr)
+    if err != nil { sr.Err = ErrInvalidSnapshot }
+    return v
+}
+
+func (sr *SnapshotReader) ReadUvarint() uint64 {
+    if sr.Err != nil { return 0 }
+
+    v, err := binary.ReadUvarint(sr)
+    if err != nil { sr.Err = ErrInvalidSnapshot }
+    return v
+}
+
+// 空的字节串读取为 nil，与 gob 解码的结果相同
+func (sr *SnapshotReader) ReadBytes() []byte {
+    n := sr.ReadUvarint()
+    if n > maxSnapshotBytes { sr.Err = ErrInvalidSnapshot }
+    if n == 0 || sr.Err != nil { return nil }
+
+    return sr.Read(int(n))
+}
+
+func (sr *SnapshotReader) ReadSnapshotHeader() *types.Block {
+    header := &types.Header{}
+    header.ParentHash.SetBytes(sr.Read(common.HashLength))
+    header.Miner.SetBytes(sr.Read(common.AddressLength))
+    header.TxHash.SetBytes(sr.Read(common.HashLength))
+    header.UTXORoot.SetBytes(sr.Read(common.HashLength))
+    header.Number = big.NewInt(sr.ReadVarint())
+    header.Timestamp = big.NewInt(sr.ReadVarint())
+    copy(header.Nonce[:], sr.Read(len(header.Nonce)))
+
+    block := &types.Block{Header: header}
+    block.Hash.SetBytes(sr.Read(common.HashLength))
+
+    count := sr.ReadUvarint()
+    for i := uint64(0); i < count && sr.Err == nil; i++ {
+        block.Transactions = append(block.Transactions, &types.Transaction{ID: sr.ReadBytes()})
+    }
+    return block
+}
+
+func (sr *SnapshotReader) ReadSnapshotOutput() types.TXOutput {
+    out := types.TXOutput{}
+    out.Value = int(sr.ReadVarint())
+    out.PubKeyHash = sr.ReadBytes()
+
+    if hasHTLC := sr.Read(1); hasHTLC != nil && hasHTLC[0] == 1 {
+        out.HTLC = &types.HTLC{SecretHash: sr.ReadBytes(), RecipientPubKeyHash: sr.ReadBytes(), RefundPubKeyHash: sr.ReadBytes(), LockTime: sr.ReadVarint()}
+    }
+
+    out.Data = sr.ReadBytes()
+    return out
+}
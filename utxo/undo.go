@@ -0,0 +1,34 @@
+package utxo
+
+import (
+    "bytes"
+    "encoding/gob"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 区块被花费的输出，以区块 hash 索引
+const UndoBucket = "undo"
+
+// 区块的一个被花费的输出
+type SpentOutput struct {
+    TxID   []byte
+    Vout   int
+    Output types.TXOutput
+}
+
+// 区块的 undo 数据，即区块花费的全部输出，撤销区块时将其放回 utxo
+type BlockUndo struct {
+    Spent []SpentOutput
+}
+
+// Serialize serializes BlockUndo
+func (undo BlockUndo) Serialize() ([]byte, error) {
+    var buff bytes.Buffer
+
+    enc := gob.NewEncoder(&buff)
+    err := enc.Encode(undo)
+    if err != nil { return nil, err }
+
+    return buff.Bytes(), nil
+}
@@ -0,0 +1,224 @@
+package wallet
+
+import (
+    "sort"
+    "errors"
+    "math/rand"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// branch and bound 最多尝试的次数
+const bnbMaxTries = 100000
+
+var ErrInsufficientFunds = errors.New("ERROR: Not enough funds")
+
+// 选币时需要考虑的金额与手续费
+// 手续费按输入/输出的个数计算
+type CoinSelectionParams struct {
+    Target      int // 需要支付的总额，不含手续费
+    BaseFee     int // 不含输入和找零时，交易本身的手续费
+    FeePerInput int // 每增加一个输入的手续费
+    ChangeCost  int // 增加一个找零输出的手续费
+    Dust        int // 不大于该值的找零不值得创建，直接并入手续费
+}
+
+// 选币结果
+type CoinSelection struct {
+    Inputs []types.UTXO
+    Fee    int
+    Change int
+}
+
+// 选币策略
+type CoinSelector interface {
+    Select(utxos []types.UTXO, params CoinSelectionParams) (*CoinSelection, error)
+}
+
+// 根据名称获取选币策略
+func NewCoinSelector(name string) (CoinSelector, error) {
+    switch name {
+    case "bnb", "":
+        return BranchAndBoundSelector{Fallback: RandomImproveSelector{}}, nil
+    case "largest":
+        return LargestFirstSelector{}, nil
+    case "smallest":
+        return SmallestFirstSelector{}, nil
+    case "random":
+        return RandomImproveSelector{}, nil
+    }
+    return nil, errors.New("ERROR: Unknown coin selection strategy: " + name)
+}
+
+// 一个输入扣除其手续费后的实际价值
+func (p CoinSelectionParams) effectiveValue(u types.UTXO) int {
+    return u.Output.Value - p.FeePerInput
+}
+
+// 根据选中的输入计算手续费和找零
+// 找零不足以覆盖其自身手续费或属于 dust 时，并入手续费
+func (p CoinSelectionParams) finalize(inputs []types.UTXO) (*CoinSelection, error) {
+    total := 0
+    for _, u := range inputs { total += u.Output.Value }
+
+    fee := p.BaseFee + p.FeePerInput * len(inputs)
+    if total < p.Target + fee { return nil, ErrInsufficientFunds }
+
+    change := total - p.Target - fee
+    if change - p.ChangeCost > p.Dust {
+        fee += p.ChangeCost
+        change -= p.ChangeCost
+    } else {
+        fee += change
+        change = 0
+    }
+
+    return &CoinSelection{inputs, fee, change}, nil
+}
+
+// 依次选取输入直至足够支付
+func accumulate(utxos []types.UTXO, params CoinSelectionParams) (*CoinSelection, error) {
+    var selected []types.UTXO
+
+    for _, u := range utxos {
+        if params.effectiveValue(u) <= 0 { continue }
+
+        selected = append(selected, u)
+        if result, err := params.finalize(selected); err == nil {
+            return result, nil
+        }
+    }
+    return nil, ErrInsufficientFunds
+}
+
+// 按金额从大到小选取，输入最少
+type LargestFirstSelector struct {}
+
+func (s LargestFirstSelector) Select(utxos []types.UTXO, params CoinSelectionParams) (*CoinSelection, error) {
+    sorted := append([]types.UTXO{}, utxos...)
+    sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Output.Value > sorted[j].Output.Value })
+
+    return accumulate(sorted, params)
+}
+
+// 按金额从小到大选取，合并零碎的输出
+type SmallestFirstSelector struct {}
+
+func (s SmallestFirstSelector) Select(utxos []types.UTXO, params CoinSelectionParams) (*CoinSelection, error) {
+    sorted := append([]types.UTXO{}, utxos...)
+    sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Output.Value < sorted[j].Output.Value })
+
+    return accumulate(sorted, params)
+}
+
+/*
+random-improve
+    1. 随机选取输出直至足够支付
+    2. 继续随机尝试剩余输出，若加入后总额更接近 2 倍的目标金额且不超过 3 倍，则加入
+找零金额与支付金额接近，更难以区分哪个是找零
+*/
+type RandomImproveSelector struct {}
+
+func (s RandomImproveSelector) Select(utxos []types.UTXO, params CoinSelectionParams) (*CoinSelection, error) {
+    shuffled := append([]types.UTXO{}, utxos...)
+    rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+    var selected []types.UTXO
+    total := 0
+    i := 0
+    for ; i < len(shuffled); i++ {
+        if _, err := params.finalize(selected); err == nil { break }
+        if params.effectiveValue(shuffled[i]) <= 0 { continue }
+
+        selected = append(selected, shuffled[i])
+        total += params.effectiveValue(shuffled[i])
+    }
+    if _, err := params.finalize(selected); err != nil { return nil, err }
+
+    ideal, limit := 2 * params.Target, 3 * params.Target
+    for ; i < len(shuffled); i++ {
+        value := params.effectiveValue(shuffled[i])
+        if value <= 0 { continue }
+
+        if total + value <= limit && abs(ideal - total - value) < abs(ideal - total) {
+            selected = append(selected, shuffled[i])
+            total += value
+        }
+    }
+
+    return params.finalize(selected)
+}
+
+/*
+branch and bound
+    寻找实际价值之和落在 [目标, 目标 + 找零成本 + dust] 内的组合，这样的交易不需要找零
+    多出的部分作为手续费，浪费最少的组合胜出
+找不到时使用 Fallback
+*/
+type BranchAndBoundSelector struct {
+    Fallback CoinSelector
+}
+
+func (s BranchAndBoundSelector) Select(utxos []types.UTXO, params CoinSelectionParams) (*CoinSelection, error) {
+    var candidates []types.UTXO
+    for _, u := range utxos {
+        if params.effectiveValue(u) > 0 { candidates = append(candidates, u) }
+    }
+    sort.SliceStable(candidates, func(i, j int) bool {
+        return params.effectiveValue(candidates[i]) > params.effectiveValue(candidates[j])
+    })
+
+    target := params.Target + params.BaseFee
+    upper := target + params.ChangeCost + params.Dust
+
+    // remaining[i] 为 candidates[i:] 的实际价值之和
+    remaining := make([]int, len(candidates) + 1)
+    for i := len(candidates) - 1; i >= 0; i-- {
+        remaining[i] = remaining[i + 1] + params.effectiveValue(candidates[i])
+    }
+
+    var best []int
+    bestWaste := -1
+    tries := 0
+    var current []int
+
+    var search func(depth, value int) bool
+    search = func(depth, value int) bool {
+        tries++
+        if tries > bnbMaxTries { return true }
+
+        if value > upper || value + remaining[depth] < target { return false }
+        if value >= target {
+            waste := value - target
+            if bestWaste < 0 || waste < bestWaste {
+                best = append([]int{}, current...)
+                bestWaste = waste
+            }
+            return waste == 0
+        }
+        if depth == len(candidates) { return false }
+
+        // 先尝试包含，再尝试排除
+        current = append(current, depth)
+        if search(depth + 1, value + params.effectiveValue(candidates[depth])) { return true }
+        current = current[:len(current) - 1]
+
+        return search(depth + 1, value)
+    }
+    search(0, 0)
+
+    if best == nil {
+        if s.Fallback == nil { return nil, ErrInsufficientFunds }
+        return s.Fallback.Select(utxos, params)
+    }
+
+    var selected []types.UTXO
+    for _, idx := range best { selected = append(selected, candidates[idx]) }
+
+    return params.finalize(selected)
+}
+
+func abs(x int) int {
+    if x < 0 { return -x }
+    return x
+}
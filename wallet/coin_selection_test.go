@@ -0,0 +1,83 @@
+package wallet
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+func newTestUTXOs(values ...int) []types.UTXO {
+    var utxos []types.UTXO
+    for i, value := range values {
+        utxos = append(utxos, types.UTXO{TxID: []byte{byte(i)}, Index: 0, Output: types.TXOutput{Value: value}})
+    }
+    return utxos
+}
+
+var testParams = CoinSelectionParams{Target: 30, BaseFee: 2, FeePerInput: 1, ChangeCost: 1, Dust: 1}
+
+// 选中的输入恰好支付金额、手续费和找零，手续费不少于交易大小所需
+func checkSelection(t *testing.T, name string, selection *CoinSelection, params CoinSelectionParams) {
+    t.Helper()
+
+    total := 0
+    for _, u := range selection.Inputs { total += u.Output.Value }
+    if total != params.Target + selection.Fee + selection.Change { t.Errorf("%s: inputs %d, target %d, fee %d, change %d", name, total, params.Target, selection.Fee, selection.Change) }
+
+    minFee := params.BaseFee + params.FeePerInput * len(selection.Inputs)
+    if selection.Change > 0 { minFee += params.ChangeCost }
+    if selection.Fee < minFee { t.Errorf("%s: fee %d, want at least %d", name, selection.Fee, minFee) }
+    if selection.Change != 0 && selection.Change <= params.Dust { t.Errorf("%s: dust change %d", name, selection.Change) }
+}
+
+func TestCoinSelectors(t *testing.T) {
+    utxos := newTestUTXOs(1, 5, 12, 20, 50)
+
+    for _, name := range []string{"bnb", "largest", "smallest", "random"} {
+        selector, err := NewCoinSelector(name)
+        if err != nil { t.Fatal(err) }
+
+        selection, err := selector.Select(utxos, testParams)
+        if err != nil { t.Fatalf("%s: %v", name, err) }
+        checkSelection(t, name, selection, testParams)
+
+        // 实际价值不大于 0 的输出不被选中
+        for _, u := range selection.Inputs {
+            if u.Output.Value <= testParams.FeePerInput { t.Errorf("%s: selected an output worth %d", name, u.Output.Value) }
+        }
+
+        params := testParams
+        params.Target = 100
+        if _, err := selector.Select(utxos, params); !errors.Is(err, ErrInsufficientFunds) { t.Errorf("%s: got %v, want %v", name, err, ErrInsufficientFunds) }
+    }
+
+    if _, err := NewCoinSelector("unknown"); err == nil { t.Errorf("unknown strategy is accepted") }
+}
+
+func TestLargestAndSmallestFirst(t *testing.T) {
+    utxos := newTestUTXOs(5, 12, 20, 50)
+
+    selection, err := LargestFirstSelector{}.Select(utxos, testParams)
+    if err != nil { t.Fatal(err) }
+    if len(selection.Inputs) != 1 || selection.Inputs[0].Output.Value != 50 { t.Errorf("largest first selected %+v", selection.Inputs) }
+
+    selection, err = SmallestFirstSelector{}.Select(utxos, testParams)
+    if err != nil { t.Fatal(err) }
+    if len(selection.Inputs) != 3 { t.Errorf("smallest first selected %d inputs, want 3", len(selection.Inputs)) }
+}
+
+// branch and bound 找到不需要找零的组合：实际价值 11 + 19 + 2 恰好为金额与基础手续费之和
+func TestBranchAndBoundExactMatch(t *testing.T) {
+    utxos := newTestUTXOs(50, 12, 7, 20, 3)
+    params := testParams
+
+    selection, err := BranchAndBoundSelector{}.Select(utxos, params)
+    if err != nil { t.Fatal(err) }
+    checkSelection(t, "bnb", selection, params)
+    if len(selection.Inputs) != 3 || selection.Change != 0 || selection.Fee != 5 { t.Errorf("%d inputs, fee %d, change %d, want 3 inputs and fee 5 without change", len(selection.Inputs), selection.Fee, selection.Change) }
+
+    // 没有不需要找零的组合且没有 Fallback
+    params.Target = 3
+    if _, err := (BranchAndBoundSelector{}).Select(newTestUTXOs(50), params); !errors.Is(err, ErrInsufficientFunds) { t.Errorf("got %v, want %v", err, ErrInsufficientFunds) }
+}
@@ -0,0 +1,79 @@
+package wallet
+
+import (
+    "errors"
+    "encoding/hex"
+    "crypto/ecdsa"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+var ErrNoWalletOutputs = errors.New("ERROR: Transaction has no unspent output to the wallet")
+
+/*
+子交易为父交易支付手续费 (child pays for parent)：
+    花费 parent 中属于钱包的输出，全部转回钱包
+    子交易的手续费使父交易与子交易整体的手续费率达到 feeRate，且子交易自身不低于最低手续费
+    feeRate 为 0 时使用 minRelayFeeRate
+    parent 可以在交易池中，也可以尚未加入交易池（其手续费不足以单独加入）
+*/
+func NewCPFPTransaction(parent *types.Transaction, feeRate int, bc *chain.Blockchain) (*types.Transaction, error) {
+    if feeRate == 0 { feeRate = chain.MinRelayFeeRate }
+
+    mempool := bc.Mempool()
+    view, err := mempool.View()
+    if err != nil { return nil, err }
+
+    entry, ok, err := mempool.Get(parent.ID)
+    if err != nil { return nil, err }
+
+    parentFee := 0
+    if ok {
+        parentFee = entry.Fee
+    } else {
+        fee, err := view.CalculateFee(parent)
+        if err != nil { return nil, err }
+
+        parentFee = fee
+        view.AddTransaction(parent)
+    }
+
+    wallets, err := NewWallets()
+    if err != nil { return nil, err }
+
+    // 父交易中属于钱包且尚未被花费的输出
+    var inputs []types.TXInput
+    var to string
+    total := 0
+    privKeys := make(map[string]ecdsa.PrivateKey)
+    for outIdx, out := range parent.Vout {
+        if out.PubKeyHash == nil { continue }
+
+        wallet := wallets.FindWalletByPubKeyHash(out.PubKeyHash)
+        if wallet == nil { continue }
+        _, err := view.FindOutput(parent.ID, outIdx)
+        if errors.Is(err, utxo.ErrMissingInput) { continue }
+        if err != nil { return nil, err }
+
+        inputs = append(inputs, types.TXInput{Txid: parent.ID, Vout: outIdx, PubKey: wallet.PublicKey, Sequence: types.SequenceFinal})
+        privKeys[hex.EncodeToString(out.PubKeyHash)] = wallet.PrivateKey
+        total += out.Value
+        if to == "" { to = crypto.PubKeyHashToAddress(out.PubKeyHash) }
+    }
+    if len(inputs) == 0 { return nil, ErrNoWalletOutputs }
+
+    size := len(inputs) + 1
+    fee := feeRate * (parent.Size() + size) - parentFee
+    if fee < chain.MinRelayFee(size) { fee = chain.MinRelayFee(size) }
+    if total <= fee { return nil, errors.New("ERROR: Outputs to the wallet are too small to pay the fee") }
+
+    tx := types.Transaction{Vin: inputs, Vout: []types.TXOutput{*types.NewTXOutput(total - fee, to)}}
+    tx.ID = tx.Hash()
+
+    if err := tx.SignWithKeys(privKeys, view.PrevTransactions(&tx)); err != nil { return nil, err }
+
+    return &tx, nil
+}
@@ -0,0 +1,241 @@
+package wallet
+
+import (
+    "os"
+    "sort"
+    "bytes"
+    "errors"
+    "io/ioutil"
+    "encoding/gob"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/common"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+const walletHistoryFile = "data/wallet_history.dat"
+
+var ErrWalletHistoryNotFound = errors.New("ERROR: No wallet history found. Run rescan first.")
+
+// 钱包交易记录的类别
+const (
+    CategoryReceive  = "receive"  // 收到其他地址的转账
+    CategorySend     = "send"     // 转账给其他地址
+    CategoryChange   = "change"   // 钱包发出的交易中转给钱包地址的输出，包括找零
+    CategoryCoinbase = "coinbase" // 挖矿奖励
+)
+
+// 一条钱包交易记录，一笔交易可能对应多条
+type WalletTx struct {
+    TxID      []byte
+    Category  string
+    Address   string
+    From      []string // send 和 change：签名输入的钱包地址
+    Amount    int      // send 为负数
+    Height    int64
+    BlockHash []byte
+    Timestamp int64
+}
+
+// 与钱包地址有关的交易的索引
+// ScannedHeight 为已扫描的最高区块，-1 表示尚未扫描
+type WalletHistory struct {
+    Transactions  []WalletTx
+    ScannedHeight int64
+}
+
+func NewWalletHistory() (*WalletHistory, error) {
+    history := WalletHistory{nil, -1}
+
+    err := history.LoadFromFile()
+
+    return &history, err
+}
+
+// 加入一个区块中与 wallets 有关的交易
+// 已扫描过的区块会被忽略
+func (history *WalletHistory) AddBlock(block *types.Block, wallets *Wallets) {
+    if block.Number().Int64() <= history.ScannedHeight { return }
+
+    keys := wallets.PubKeyHashes()
+
+    for _, tx := range block.Transactions {
+        history.Transactions = append(history.Transactions, walletTransactions(tx, block, keys)...)
+    }
+
+    history.ScannedHeight = block.Number().Int64()
+}
+
+// 从 height 开始重新扫描区块链，重建交易记录
+func (history *WalletHistory) Rescan(bc *chain.Blockchain, wallets *Wallets, height int64) error {
+    var kept []WalletTx
+    for _, wtx := range history.Transactions {
+        if wtx.Height < height { kept = append(kept, wtx) }
+    }
+    history.Transactions = kept
+    history.ScannedHeight = height - 1
+
+    // 迭代器从最新区块往前遍历，收集后按高度从低到高加入
+    var blocks []*types.Block
+    bci := bc.Iterator()
+    for {
+        block, err := bci.Next()
+        if err != nil { return err }
+        if block.Number().Int64() < height { break }
+
+        blocks = append(blocks, block)
+
+        if (block.ParentHash() == common.Hash{}) { break }
+    }
+
+    for i := len(blocks) - 1; i >= 0; i-- {
+        history.AddBlock(blocks[i], wallets)
+    }
+    return nil
+}
+
+// 记录是否与 address 有关：转入 address，或者由 address 发出
+func (wtx WalletTx) Involves(address string) bool {
+    if wtx.Address == address { return true }
+    for _, from := range wtx.From {
+        if from == address { return true }
+    }
+    return false
+}
+
+// 按高度从低到高排列
+func (history *WalletHistory) Sort() {
+    sort.SliceStable(history.Transactions, func(i, j int) bool {
+        return history.Transactions[i].Height < history.Transactions[j].Height
+    })
+}
+
+// 一笔交易对应的钱包交易记录
+// keys: 钱包全部地址的 hex(pubKeyHash)
+func walletTransactions(tx *types.Transaction, block *types.Block, keys map[string]bool) []WalletTx {
+    var result []WalletTx
+
+    // 不为空即为钱包发出的交易
+    var from []string
+    if !tx.IsCoinbase() { from = walletInputAddresses(tx, keys) }
+
+    newWalletTx := func(category string, out types.TXOutput, amount int) WalletTx {
+        address := crypto.PubKeyHashToAddress(out.PubKeyHash)
+        if out.HTLC != nil { address = "HTLC " + hex.EncodeToString(out.HTLC.SecretHash) }
+        if out.IsUnspendable() { address = "DATA " + hex.EncodeToString(out.Data) }
+
+        return WalletTx{
+            tx.ID, category, address, from, amount,
+            block.Number().Int64(), block.Hash.Bytes(), block.Timestamp().Int64(),
+        }
+    }
+
+    if tx.IsCoinbase() {
+        for _, out := range tx.Vout {
+            if keys[hex.EncodeToString(out.PubKeyHash)] {
+                result = append(result, newWalletTx(CategoryCoinbase, out, out.Value))
+            }
+        }
+        return result
+    }
+
+    // 钱包发出的交易中，转给钱包地址的输出为找零（包括新生成的找零地址），不计入收入
+    for _, out := range tx.Vout {
+        mine := keys[hex.EncodeToString(out.PubKeyHash)]
+
+        switch {
+        case from != nil && mine:
+            result = append(result, newWalletTx(CategoryChange, out, out.Value))
+        case from != nil:
+            result = append(result, newWalletTx(CategorySend, out, -out.Value))
+        case mine:
+            result = append(result, newWalletTx(CategoryReceive, out, out.Value))
+        }
+    }
+    return result
+}
+
+// 签名交易输入的钱包地址，按地址排序
+func walletInputAddresses(tx *types.Transaction, keys map[string]bool) []string {
+    var addresses []string
+    seen := make(map[string]bool)
+    for _, vin := range tx.Vin {
+        pubKeyHash := crypto.HashPubKey(vin.PubKey)
+        key := hex.EncodeToString(pubKeyHash)
+        if keys[key] && !seen[key] { addresses = append(addresses, crypto.PubKeyHashToAddress(pubKeyHash)) }
+        seen[key] = true
+    }
+    sort.Strings(addresses)
+
+    return addresses
+}
+
+/*
+新区块写入后更新钱包交易记录，block 需为 bc 的最新区块
+    没有钱包文件时不需要记录
+    交易记录文件不存在或者缺少 block 之前的区块时，从缺少的高度重新扫描，否则这些区块不会再被记录
+*/
+func UpdateWalletHistory(bc *chain.Blockchain, block *types.Block) error {
+    wallets, err := NewWallets()
+    if errors.Is(err, ErrWalletNotFound) { return nil }
+    if err != nil { return err }
+
+    history, err := NewWalletHistory()
+    if err != nil && !errors.Is(err, ErrWalletHistoryNotFound) { return err }
+
+    if from := history.ScannedHeight + 1; from < block.Number().Int64() {
+        if err := bc.CheckBlocksAvailable(from); err != nil { return err }
+        if err := history.Rescan(bc, wallets, from); err != nil { return err }
+    } else {
+        history.AddBlock(block, wallets)
+    }
+
+    return history.SaveToFile()
+}
+
+// 由区块链的全部区块重建钱包交易记录，例如导入新的区块链之后
+// 没有钱包文件时不需要记录
+func RebuildWalletHistory(bc *chain.Blockchain) error {
+    wallets, err := NewWallets()
+    if errors.Is(err, ErrWalletNotFound) { return nil }
+    if err != nil { return err }
+
+    history := WalletHistory{nil, -1}
+    if err := history.Rescan(bc, wallets, 0); err != nil { return err }
+
+    return history.SaveToFile()
+}
+
+// 从文件中加载交易记录，文件不存在时返回 ErrWalletHistoryNotFound
+func (history *WalletHistory) LoadFromFile() error {
+    if _, err := os.Stat(walletHistoryFile); os.IsNotExist(err) {
+        return ErrWalletHistoryNotFound
+    }
+
+    fileContent, err := ioutil.ReadFile(walletHistoryFile)
+    if err != nil { return err }
+
+    var history_loaded WalletHistory
+
+    decoder := gob.NewDecoder(bytes.NewReader(fileContent))
+    err = decoder.Decode(&history_loaded)
+    if err != nil { return err }
+
+    *history = history_loaded
+
+    return nil
+}
+
+func (history WalletHistory) SaveToFile() error {
+    var content bytes.Buffer
+
+    history.Sort()
+
+    encoder := gob.NewEncoder(&content)
+    err := encoder.Encode(history)
+    if err != nil { return err }
+
+    return ioutil.WriteFile(walletHistoryFile, content.Bytes(), 0644)
+}
@@ -0,0 +1,99 @@
+package wallet
+
+import (
+    "os"
+    "reflect"
+    "testing"
+    "math/big"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+// 交易记录文件不存在时，新区块之前的区块也需要被记录
+func TestUpdateWalletHistoryRescansMissingHistory(t *testing.T) {
+    t.Chdir(t.TempDir())
+    if err := os.Mkdir("data", 0755); err != nil { t.Fatal(err) }
+
+    wallets, _ := NewWallets()
+    address, err := wallets.CreateWallet()
+    if err != nil { t.Fatal(err) }
+    if err := wallets.SaveToFile(); err != nil { t.Fatal(err) }
+
+    bc, err := chain.CreateBlockchain(address)
+    if err != nil { t.Fatal(err) }
+    defer bc.Close()
+
+    for height := 1; height <= 2; height++ {
+        block, err := bc.MineBlock(address, nil)
+        if err != nil { t.Fatal(err) }
+        if err := UpdateWalletHistory(bc, block); err != nil { t.Fatal(err) }
+
+        history, err := NewWalletHistory()
+        if err != nil { t.Fatal(err) }
+        if history.ScannedHeight != int64(height) { t.Fatalf("scanned height %d, want %d", history.ScannedHeight, height) }
+        if len(history.Transactions) != height + 1 { t.Fatalf("%d wallet transactions, want %d", len(history.Transactions), height + 1) }
+
+        for i, wtx := range history.Transactions {
+            if wtx.Height != int64(i) || wtx.Category != CategoryCoinbase || wtx.Address != address { t.Errorf("transaction %d: %+v", i, wtx) }
+        }
+    }
+}
+
+// 钱包发出的交易中，转给新生成的找零地址的输出为 change，不记为 receive
+// 发出的记录可以按签名输入的地址找到
+func TestWalletTransactionsChange(t *testing.T) {
+    var keys [3]*Wallet
+    for i := range keys {
+        w, err := NewWallet()
+        if err != nil { t.Fatal(err) }
+        keys[i] = w
+    }
+    sender, change, other := keys[0], keys[1], keys[2]
+    senderAddress, changeAddress, otherAddress := string(sender.GetAddress()), string(change.GetAddress()), string(other.GetAddress())
+
+    wallets := Wallets{map[string]*Wallet{senderAddress: sender, changeAddress: change}, make(map[string][]byte)}
+    block := &types.Block{Header: &types.Header{Number: big.NewInt(1), Timestamp: big.NewInt(0)}}
+
+    send := &types.Transaction{
+        ID:   []byte("send"),
+        Vin:  []types.TXInput{{Txid: []byte("prev"), Vout: 0, PubKey: sender.PublicKey}},
+        Vout: []types.TXOutput{*types.NewTXOutput(10, otherAddress), *types.NewTXOutput(5, changeAddress)},
+    }
+    receive := &types.Transaction{
+        ID:   []byte("receive"),
+        Vin:  []types.TXInput{{Txid: []byte("prev"), Vout: 1, PubKey: other.PublicKey}},
+        Vout: []types.TXOutput{*types.NewTXOutput(7, senderAddress)},
+    }
+
+    history := WalletHistory{nil, 0}
+    block.Transactions = []*types.Transaction{send, receive}
+    history.AddBlock(block, &wallets)
+
+    want := []struct {
+        category string
+        address  string
+        from     []string
+        amount   int
+    }{
+        {CategorySend, otherAddress, []string{senderAddress}, -10},
+        {CategoryChange, changeAddress, []string{senderAddress}, 5},
+        {CategoryReceive, senderAddress, nil, 7},
+    }
+    if len(history.Transactions) != len(want) { t.Fatalf("%d wallet transactions, want %d: %+v", len(history.Transactions), len(want), history.Transactions) }
+    for i, wtx := range history.Transactions {
+        w := want[i]
+        if wtx.Category != w.category || wtx.Address != w.address || !reflect.DeepEqual(wtx.From, w.from) || wtx.Amount != w.amount { t.Errorf("transaction %d: %+v, want %+v", i, wtx, w) }
+    }
+
+    // 发送方的记录包括发出的交易和收到的转账，找零地址只有找零
+    involves := func(address string) []string {
+        var categories []string
+        for _, wtx := range history.Transactions {
+            if wtx.Involves(address) { categories = append(categories, wtx.Category) }
+        }
+        return categories
+    }
+    if got := involves(senderAddress); !reflect.DeepEqual(got, []string{CategorySend, CategoryChange, CategoryReceive}) { t.Errorf("sender transactions %v", got) }
+    if got := involves(changeAddress); !reflect.DeepEqual(got, []string{CategoryChange}) { t.Errorf("change address transactions %v", got) }
+}
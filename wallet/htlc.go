@@ -0,0 +1,41 @@
+package wallet
+
+import (
+    "fmt"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+/*
+花费合约的交易，将合约金额扣除 fee 后转到花费方的地址
+    secret 不为 nil 时为 redeem，否则为 refund
+*/
+func NewHTLCSpendTransaction(txID []byte, vout int, secret []byte, fee int, bc *chain.Blockchain) (*types.Transaction, error) {
+    htlc, value, err := bc.UTXOSet().FindContract(txID, vout)
+    if err != nil { return nil, err }
+    if value <= fee { return nil, fmt.Errorf("%w: fee exceeds contract value", ErrInsufficientFunds) }
+
+    wallets, err := NewWallets()
+    if err != nil { return nil, err }
+
+    in := types.TXInput{Txid: txID, Vout: vout, Sequence: types.SequenceFinal, Secret: secret}
+    lockTime := int64(0)
+    if secret == nil {
+        in.Sequence = types.SequenceFinal - 1
+        lockTime = htlc.LockTime
+    }
+
+    spender := wallets.FindWalletByPubKeyHash(htlc.SpenderPubKeyHash(in))
+    if spender == nil { return nil, fmt.Errorf("%w: the wallet can not spend this contract", ErrUnknownAddress) }
+    in.PubKey = spender.PublicKey
+
+    address := crypto.PubKeyHashToAddress(crypto.HashPubKey(spender.PublicKey))
+    tx := types.Transaction{Vin: []types.TXInput{in}, Vout: []types.TXOutput{*types.NewTXOutput(value - fee, address)}, LockTime: lockTime}
+    tx.ID = tx.Hash()
+
+    if err := bc.SignTransaction(&tx, spender.PrivateKey); err != nil { return nil, err }
+
+    return &tx, nil
+}
@@ -0,0 +1,98 @@
+package wallet
+
+/*
+手动构造交易：
+    createrawtransaction 指定花费的输出和收款方，不选币也不找零，输入与输出之差即为手续费
+    signrawtransaction 使用钱包内的私钥签名
+    sendrawtransaction 校验后加入交易池
+*/
+
+import (
+    "fmt"
+    "errors"
+    "crypto/ecdsa"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+    "github.com/guoxingx/simple-blockchain/utxo"
+)
+
+var ErrMissingSigningKey = errors.New("ERROR: The wallet has no private key for the input")
+
+/*
+构造一笔未签名的交易，inputs 为 TXID:VOUT
+    钱包内有输出地址的公钥时填入输入的 PubKey，否则由签名方填入
+    输入的 Sequence 由 lockTime、relativeLock 和 replaceable 决定，与 NewUnsignedTransaction 相同
+*/
+func NewRawTransaction(inputs []string, recipients []Recipient, lockTime int64, relativeLock uint32, replaceable bool, view *chain.TxView) (*types.Transaction, error) {
+    wallets, err := NewWallets()
+    if err != nil { return nil, err }
+
+    sequence := inputSequence(lockTime, relativeLock, replaceable)
+
+    var vin []types.TXInput
+    for _, input := range inputs {
+        txID, vout, err := types.ParseOutpoint(input)
+        if err != nil { return nil, err }
+
+        in := types.TXInput{Txid: txID, Vout: vout, Sequence: sequence}
+        out, err := view.FindOutput(txID, vout)
+        if err == nil { in.PubKey = wallets.PubKey(out.PubKeyHash) }
+        if err != nil && !errors.Is(err, utxo.ErrMissingInput) { return nil, err }
+        vin = append(vin, in)
+    }
+
+    var vout []types.TXOutput
+    for _, recipient := range recipients {
+        if recipient.Data != nil {
+            out, err := types.NewDataOutput(recipient.Data)
+            if err != nil { return nil, err }
+
+            vout = append(vout, *out)
+            continue
+        }
+        if !crypto.ValidateAddress(recipient.Address) { return nil, fmt.Errorf("%w: %s", crypto.ErrInvalidAddress, recipient.Address) }
+        if recipient.Amount <= 0 { return nil, ErrInvalidAmount }
+        vout = append(vout, *types.NewTXOutput(recipient.Amount, recipient.Address))
+    }
+
+    tx := types.Transaction{Vin: vin, Vout: vout, LockTime: lockTime}
+    tx.ID = tx.Hash()
+
+    return &tx, nil
+}
+
+/*
+使用钱包内的私钥签名交易的全部输入
+    输入的 PubKey 为空时先填入钱包内的公钥，此时交易 ID 重新计算
+    所花费的输出可以在 utxo 或者 view 的 pending 交易中
+*/
+func SignRawTransaction(tx *types.Transaction, wallets *Wallets, view *chain.TxView) error {
+    privKeys := make(map[string]ecdsa.PrivateKey)
+    filled := false
+
+    for i, vin := range tx.Vin {
+        out, err := view.FindOutput(vin.Txid, vin.Vout)
+        if err != nil { return err }
+
+        pubKeyHash := out.SpenderPubKeyHash(vin)
+        wallet := wallets.FindWalletByPubKeyHash(pubKeyHash)
+        if wallet == nil { return fmt.Errorf("%w %d", ErrMissingSigningKey, i) }
+
+        if vin.PubKey == nil {
+            tx.Vin[i].PubKey = wallet.PublicKey
+            filled = true
+        }
+        privKeys[hex.EncodeToString(pubKeyHash)] = wallet.PrivateKey
+    }
+
+    if filled {
+        for i := range tx.Vin {
+            tx.Vin[i].Signature = nil
+        }
+        tx.ID = tx.Hash()
+    }
+    return tx.SignWithKeys(privKeys, view.PrevTransactions(tx))
+}
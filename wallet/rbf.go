@@ -0,0 +1,91 @@
+package wallet
+
+import (
+    "errors"
+    "encoding/hex"
+    "crypto/ecdsa"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+)
+
+var ErrNotInMempool = errors.New("ERROR: Transaction is not in the mempool")
+
+/*
+提高交易池中一笔交易的手续费，返回用于替换它的新交易
+    使用相同的输入和输出，多出的手续费从找零中扣除
+    转回输入地址的输出，或者钱包内唯一的输出被当作找零
+    feeRate 为每个输入/输出的手续费，为 0 时支付最低的替换手续费
+    交易的后代交易会一并被替换
+*/
+func NewBumpFeeTransaction(txID []byte, feeRate int, bc *chain.Blockchain) (*types.Transaction, error) {
+    mempool := bc.Mempool()
+    entry, ok, err := mempool.Get(txID)
+    if err != nil { return nil, err }
+    if !ok { return nil, ErrNotInMempool }
+
+    tx := entry.Tx
+    if !tx.IsReplaceable() { return nil, chain.ErrReplacementNotAllowed }
+
+    wallets, err := NewWallets()
+    if err != nil { return nil, err }
+
+    // 每个输入对应的私钥，以pubKeyHash索引
+    // 输入可以引用交易池中的交易的输出
+    view, err := mempool.View()
+    if err != nil { return nil, err }
+    privKeys := make(map[string]ecdsa.PrivateKey)
+    for _, vin := range tx.Vin {
+        prevTx, err := view.FindTransaction(vin.Txid)
+        if err != nil { return nil, err }
+        prevOut := prevTx.Vout[vin.Vout]
+
+        pubKeyHash := prevOut.SpenderPubKeyHash(vin)
+        wallet := wallets.FindWalletByPubKeyHash(pubKeyHash)
+        if wallet == nil { return nil, errors.New("ERROR: Input is not owned by the wallet") }
+
+        privKeys[hex.EncodeToString(pubKeyHash)] = wallet.PrivateKey
+    }
+
+    // 找零：转回输入地址的输出，找零总是最后一个输出
+    // 没有时为钱包内唯一的输出（账户级别转账的找零地址是新生成的）
+    changeIdx := -1
+    var walletOutputs []int
+    for outIdx, out := range tx.Vout {
+        if out.PubKeyHash == nil || wallets.FindWalletByPubKeyHash(out.PubKeyHash) == nil { continue }
+        walletOutputs = append(walletOutputs, outIdx)
+
+        if _, ok := privKeys[hex.EncodeToString(out.PubKeyHash)]; ok { changeIdx = outIdx }
+    }
+    if changeIdx == -1 && len(walletOutputs) > 1 { return nil, errors.New("ERROR: Transaction has more than one output to the wallet") }
+    if changeIdx == -1 && len(walletOutputs) == 1 { changeIdx = walletOutputs[0] }
+    if changeIdx == -1 { return nil, errors.New("ERROR: Transaction has no change output to pay the fee") }
+
+    // 交易的后代交易会一并被替换，新交易需要支付它们的手续费
+    entries, err := mempool.Entries()
+    if err != nil { return nil, err }
+    graph := chain.NewMempoolGraph(entries)
+    id := hex.EncodeToString(txID)
+    replacedFee, _ := graph.PackageFee(append(graph.Descendants(id), id))
+
+    fee := feeRate * tx.Size()
+    if feeRate == 0 { fee = chain.MinReplacementFee(tx.Size(), replacedFee) }
+    if fee < chain.MinReplacementFee(tx.Size(), replacedFee) { return nil, chain.ErrReplacementFeeTooLow }
+
+    change := tx.Vout[changeIdx].Value - (fee - entry.Fee)
+    if change <= 0 { return nil, errors.New("ERROR: Change output is too small to pay the new fee") }
+
+    var inputs []types.TXInput
+    for _, vin := range tx.Vin {
+        inputs = append(inputs, types.TXInput{Txid: vin.Txid, Vout: vin.Vout, PubKey: vin.PubKey, Sequence: vin.Sequence, Secret: vin.Secret})
+    }
+    outputs := append([]types.TXOutput{}, tx.Vout...)
+    outputs[changeIdx].Value = change
+
+    newTx := types.Transaction{Vin: inputs, Vout: outputs, LockTime: tx.LockTime}
+    newTx.ID = newTx.Hash()
+
+    if err := newTx.SignWithKeys(privKeys, view.PrevTransactions(&newTx)); err != nil { return nil, err }
+
+    return &newTx, nil
+}
@@ -0,0 +1,194 @@
+package wallet
+
+import (
+    "fmt"
+    "errors"
+    "encoding/hex"
+    "crypto/ecdsa"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/core/types"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+var ErrInvalidAmount = errors.New("ERROR: Amount must be positive")
+var ErrNoInputs = errors.New("ERROR: Transaction has no inputs, a fee is required")
+
+// 一个收款方
+// HTLC 不为 nil 时输出为哈希时间锁合约，Address 不生效
+type Recipient struct {
+    Address string
+    Amount  int
+    HTLC    *types.HTLC
+    Data    []byte // 不为 nil 时为数据输出，Address 和 Amount 不生效
+}
+
+// 发起交易
+// feeRate 为每个输入/输出需要支付的手续费，selector 决定花费哪些未花费输出
+// 余额不足时返回 ErrInsufficientFunds
+func NewUTXOTransaction(from, to string, amount, feeRate int, selector CoinSelector, bc *chain.Blockchain) (*types.Transaction, error) {
+    return NewSendManyTransaction([]string{from}, []Recipient{{to, amount, nil, nil}}, from, feeRate, 0, 0, false, selector, bc)
+}
+
+// 发起一笔账户级别的交易
+// 从钱包内全部地址选币，找零转到一个新生成的钱包地址
+// 新地址只在内存中，交易发送成功之后由调用者用 SaveChangeWallet 保存，交易失败时不会留下没有用过的地址
+func NewAccountTransaction(recipients []Recipient, feeRate int, lockTime int64, relativeLock uint32, replaceable bool, selector CoinSelector, bc *chain.Blockchain) (*types.Transaction, *Wallet, error) {
+    wallets, err := NewWallets()
+    if err != nil { return nil, nil, err }
+
+    change, err := NewWallet()
+    if err != nil { return nil, nil, err }
+
+    tx, err := NewSendManyTransaction(wallets.GetAddresses(), recipients, string(change.GetAddress()), feeRate, lockTime, relativeLock, replaceable, selector, bc)
+    if err != nil { return nil, nil, err }
+
+    return tx, change, nil
+}
+
+// 将 NewAccountTransaction 生成的找零地址保存到钱包文件，change 为 nil 时什么都不做
+func SaveChangeWallet(change *Wallet) error {
+    if change == nil { return nil }
+
+    wallets, err := NewWallets()
+    if err != nil { return err }
+
+    wallets.ImportWallet(change)
+    return wallets.SaveToFile()
+}
+
+// 发起一笔向多个收款方转账的交易
+// 从 from 中的全部地址选币，找零转到 change
+// lockTime 不为 0 时，交易在 lockTime 之后才能被写入区块
+// relativeLock 不为 0 时为输入的相对时间锁，见 types.RelativeLockSequence
+// replaceable 时交易在交易池中可以被手续费更高的交易替换，见 chain/rbf.go
+func NewSendManyTransaction(from []string, recipients []Recipient, change string, feeRate int, lockTime int64, relativeLock uint32, replaceable bool, selector CoinSelector, bc *chain.Blockchain) (*types.Transaction, error) {
+    wallets, err := NewWallets()
+    if err != nil { return nil, err }
+
+    // 从wallet获取每个address对应的私钥，以pubKeyHash索引
+    // 只读地址没有私钥，同样返回 ErrUnknownAddress
+    privKeys := make(map[string]ecdsa.PrivateKey)
+    for _, address := range from {
+        if _, ok := wallets.Wallets[address]; !ok {
+            return nil, fmt.Errorf("%w: %s has no private key", ErrUnknownAddress, address)
+        }
+        wallet := wallets.GetWallet(address)
+        privKeys[hex.EncodeToString(crypto.HashPubKey(wallet.PublicKey))] = wallet.PrivateKey
+    }
+
+    tx, err := NewUnsignedTransaction(from, recipients, change, feeRate, lockTime, relativeLock, replaceable, selector, bc)
+    if err != nil { return nil, err }
+
+    // 交易签名
+    if err := bc.SignTransactionWithKeys(tx, privKeys); err != nil { return nil, err }
+
+    return tx, nil
+}
+
+// 构造一笔未签名的交易，用于在其他持有私钥的地方签名
+// from 可以是只读地址，只读地址的公钥未知时，输入的 PubKey 需由签名方填入
+func NewUnsignedTransaction(from []string, recipients []Recipient, change string, feeRate int, lockTime int64, relativeLock uint32, replaceable bool, selector CoinSelector, bc *chain.Blockchain) (*types.Transaction, error) {
+    var inputs []types.TXInput
+    var outputs []types.TXOutput
+
+    wallets, err := NewWallets()
+    if err != nil { return nil, err }
+    if len(from) == 0 { return nil, errors.New("ERROR: No address to send from") }
+
+    // 获取每个address对应的公钥，以pubKeyHash索引
+    // 已被交易池中的交易花费的输出不能再被选中
+    var candidates []types.UTXO
+    pubKeys := make(map[string][]byte)
+    mempoolSpent, err := bc.Mempool().SpentOutputs()
+    if err != nil { return nil, err }
+    for _, address := range from {
+        var pubKey []byte
+        if wallet, ok := wallets.Wallets[address]; ok {
+            pubKey = wallet.PublicKey
+        } else if wallets.IsWatchOnly(address) {
+            pubKey = wallets.WatchOnly[address]
+        } else {
+            return nil, fmt.Errorf("%w: %s", ErrUnknownAddress, address)
+        }
+        pubKeyHash := crypto.AddressToPubKeyHash(address)
+
+        pubKeys[hex.EncodeToString(pubKeyHash)] = pubKey
+        unspent, err := bc.UTXOSet().FindUnspentOutputs(pubKeyHash)
+        if err != nil { return nil, err }
+        for _, utxo := range unspent {
+            if !mempoolSpent[types.OutpointKey(utxo.TxID, utxo.Index)] {
+                candidates = append(candidates, utxo)
+            }
+        }
+    }
+
+    total := 0
+    for _, recipient := range recipients {
+        if recipient.Data != nil { continue }
+        if recipient.HTLC == nil && !crypto.ValidateAddress(recipient.Address) { return nil, fmt.Errorf("%w: %s", crypto.ErrInvalidAddress, recipient.Address) }
+        if recipient.Amount <= 0 { return nil, ErrInvalidAmount }
+        total += recipient.Amount
+    }
+
+    // 选币 获取需要花费的 UTXOs 以及手续费、找零
+    params := CoinSelectionParams{
+        Target:      total,
+        BaseFee:     feeRate * len(recipients),
+        FeePerInput: feeRate,
+        ChangeCost:  feeRate,
+        Dust:        feeRate,
+    }
+    selection, err := selector.Select(candidates, params)
+    if err != nil { return nil, err }
+    if len(selection.Inputs) == 0 { return nil, ErrNoInputs }
+
+    // 花费：将选中的每一个输出都引用并创建一个新的输入
+    sequence := inputSequence(lockTime, relativeLock, replaceable)
+    for _, utxo := range selection.Inputs {
+        pubKey := pubKeys[hex.EncodeToString(utxo.Output.PubKeyHash)]
+        input := types.TXInput{Txid: utxo.TxID, Vout: utxo.Index, PubKey: pubKey, Sequence: sequence}
+        inputs = append(inputs, input)
+    }
+
+    // 向每个收款方转账的输出
+    for _, recipient := range recipients {
+        if recipient.Data != nil {
+            out, err := types.NewDataOutput(recipient.Data)
+            if err != nil { return nil, err }
+
+            outputs = append(outputs, *out)
+            continue
+        }
+        if recipient.HTLC != nil {
+            outputs = append(outputs, *types.NewHTLCOutput(recipient.Amount, recipient.HTLC))
+            continue
+        }
+        outputs = append(outputs, *types.NewTXOutput(recipient.Amount, recipient.Address))
+    }
+
+    // 转账 找零 的输出
+    if selection.Change > 0 {
+        if !crypto.ValidateAddress(change) { return nil, fmt.Errorf("%w: change %s", crypto.ErrInvalidAddress, change) }
+        outputs = append(outputs, *types.NewTXOutput(selection.Change, change)) // a change
+    }
+
+    tx := types.Transaction{Vin: inputs, Vout: outputs, LockTime: lockTime}
+    tx.ID = tx.Hash()
+
+    return &tx, nil
+}
+
+/*
+交易输入的 Sequence
+    有绝对时间锁时不能为 SequenceFinal，否则时间锁不生效
+    relativeLock 不为 0 时即为 Sequence，相对时间锁的 Sequence 同时允许替换，绝对时间锁也生效
+*/
+func inputSequence(lockTime int64, relativeLock uint32, replaceable bool) uint32 {
+    if relativeLock != 0 { return relativeLock }
+
+    sequence := types.SequenceFinal
+    if lockTime != 0 { sequence = types.SequenceFinal - 1 }
+    if replaceable { sequence = types.SequenceMaxReplaceable }
+    return sequence
+}
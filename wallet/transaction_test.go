@@ -0,0 +1,55 @@
+package wallet
+
+import (
+    "os"
+    "errors"
+    "testing"
+
+    "github.com/guoxingx/simple-blockchain/chain"
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+// 账户级别转账的找零地址在 SaveChangeWallet 之前不写入钱包文件，交易失败时钱包不变
+func TestNewAccountTransactionChange(t *testing.T) {
+    t.Chdir(t.TempDir())
+    if err := os.Mkdir("data", 0755); err != nil { t.Fatal(err) }
+
+    wallets, _ := NewWallets()
+    address, err := wallets.CreateWallet()
+    if err != nil { t.Fatal(err) }
+    recipient, err := wallets.CreateWallet()
+    if err != nil { t.Fatal(err) }
+    if err := wallets.SaveToFile(); err != nil { t.Fatal(err) }
+
+    bc, err := chain.CreateBlockchain(address)
+    if err != nil { t.Fatal(err) }
+    defer bc.Close()
+
+    walletAddresses := func() []string {
+        wallets, err := NewWallets()
+        if err != nil { t.Fatal(err) }
+        return wallets.GetAddresses()
+    }
+    before := walletAddresses()
+
+    recipients := []Recipient{{Address: recipient, Amount: 1000}}
+    if _, _, err := NewAccountTransaction(recipients, 1, 0, 0, false, BranchAndBoundSelector{RandomImproveSelector{}}, bc); !errors.Is(err, ErrInsufficientFunds) { t.Fatalf("got %v, want %v", err, ErrInsufficientFunds) }
+    if got := walletAddresses(); len(got) != len(before) { t.Fatalf("failed transaction changed the wallet: %v", got) }
+
+    recipients = []Recipient{{Address: recipient, Amount: 10}}
+    tx, change, err := NewAccountTransaction(recipients, 1, 0, 0, false, BranchAndBoundSelector{RandomImproveSelector{}}, bc)
+    if err != nil { t.Fatal(err) }
+    changeAddress := string(change.GetAddress())
+
+    found := false
+    for _, out := range tx.Vout {
+        if out.IsLockedWithKey(crypto.AddressToPubKeyHash(changeAddress)) { found = true }
+    }
+    if !found { t.Fatalf("no output to change address %s", changeAddress) }
+    if got := walletAddresses(); len(got) != len(before) { t.Fatalf("change address saved before the transaction was sent: %v", got) }
+
+    if err := SaveChangeWallet(change); err != nil { t.Fatal(err) }
+    wallets, err = NewWallets()
+    if err != nil { t.Fatal(err) }
+    if _, ok := wallets.Wallets[changeAddress]; !ok || len(wallets.Wallets) != len(before) + 1 { t.Fatalf("change address not saved: %v", wallets.GetAddresses()) }
+}
@@ -0,0 +1,126 @@
+package wallet
+
+/*
+将一个公钥转换成一个 Base58 地址：
+    使用 RIPEMD160(SHA256(PubKey)) 哈希算法，取公钥并对其哈希两次
+    给哈希加上地址生成算法版本的前缀
+    对于第二步生成的结果，使用 SHA256(SHA256(payload)) 再哈希，计算校验和。校验和是结果哈希的前四个字节。
+    将校验和附加到 version+PubKeyHash 的组合中。
+    使用 Base58 对 version+PubKeyHash+checksum 组合进行编码。
+*/
+
+import (
+    "bytes"
+    "errors"
+    "math/big"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+const privKeyVersion = byte(0x80)
+const privKeyLen = 32
+
+type Wallet struct {
+    PrivateKey ecdsa.PrivateKey
+    PublicKey  []byte
+}
+
+func NewWallet() (*Wallet, error) {
+    private, public, err := newKeyPair()
+    if err != nil { return nil, err }
+    wallet := Wallet{private, public}
+
+    return &wallet, nil
+}
+
+// 根据私钥 D 恢复钱包
+func newWalletFromD(d []byte) (*Wallet, error) {
+    curve := elliptic.P256()
+    private := ecdsa.PrivateKey{}
+    private.Curve = curve
+    private.D = new(big.Int).SetBytes(d)
+
+    if private.D.Sign() == 0 || private.D.Cmp(curve.Params().N) >= 0 {
+        return nil, errors.New("ERROR: Private key is out of range")
+    }
+    private.PublicKey.X, private.PublicKey.Y = curve.ScalarBaseMult(d)
+
+    pubKey := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
+
+    return &Wallet{private, pubKey}, nil
+}
+
+/*
+私钥的文本格式，与比特币的 WIF 相同：
+    Base58(0x80 + 32 字节的私钥 D + checksum)
+*/
+func (w Wallet) ExportPrivateKey() string {
+    versionedPayload := append([]byte{ privKeyVersion }, w.privateKeyBytes()...)
+    fullPayload := append(versionedPayload, crypto.Checksum(versionedPayload)...)
+
+    return string(crypto.Base58Encode(fullPayload))
+}
+
+// 从 WIF 格式的私钥导入钱包
+func ImportPrivateKey(wif string) (*Wallet, error) {
+    payload, err := crypto.Base58Decode([]byte(wif))
+    if err != nil || len(payload) != 1 + privKeyLen + crypto.AddressChecksumLen || payload[0] != privKeyVersion {
+        return nil, errors.New("ERROR: Private key is not valid")
+    }
+
+    versionedPayload := payload[:len(payload) - crypto.AddressChecksumLen]
+    actualChecksum := payload[len(payload) - crypto.AddressChecksumLen:]
+    if bytes.Compare(actualChecksum, crypto.Checksum(versionedPayload)) != 0 {
+        return nil, errors.New("ERROR: Private key checksum mismatch")
+    }
+
+    return newWalletFromD(versionedPayload[1:])
+}
+
+// 32 字节的私钥 D，不足时补齐前导 0
+func (w Wallet) privateKeyBytes() []byte {
+    d := make([]byte, privKeyLen)
+    w.PrivateKey.D.FillBytes(d)
+
+    return d
+}
+
+// gob 只保存私钥 D，公钥由私钥推导
+// 新版本 Go 中 elliptic.Curve 没有可导出的字段，无法直接 gob 编码
+func (w Wallet) GobEncode() ([]byte, error) {
+    return w.privateKeyBytes(), nil
+}
+
+func (w *Wallet) GobDecode(data []byte) error {
+    wallet, err := newWalletFromD(data)
+    if err != nil { return err }
+
+    *w = *wallet
+    return nil
+}
+
+// 生成新的公私钥
+func newKeyPair() (ecdsa.PrivateKey, []byte, error) {
+    curve := elliptic.P256()
+    private, err := ecdsa.GenerateKey(curve, rand.Reader)
+    if err != nil { return ecdsa.PrivateKey{}, nil, err }
+
+    // ... 在这里类似于python的 *list
+    // 将Y.Bytes() 逐个append到 X.Bytes()
+    pubKey := append(private.PublicKey.X.Bytes(), private.PublicKey.Y.Bytes()...)
+
+    return *private, pubKey, nil
+}
+
+/*
+地址由三个部分组成
+    1. Version
+    2. Public key hash
+    3. Checksum 来自sha256(sha256(PublicKeyHash))
+*/
+func (w Wallet) GetAddress() []byte {
+    return []byte(crypto.PubKeyHashToAddress(crypto.HashPubKey(w.PublicKey)))
+}
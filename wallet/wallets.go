@@ -0,0 +1,164 @@
+package wallet
+
+import (
+    "os"
+    "fmt"
+    "sort"
+    "bytes"
+    "errors"
+    "io/ioutil"
+    "encoding/gob"
+    "encoding/hex"
+
+    "github.com/guoxingx/simple-blockchain/crypto"
+)
+
+const walletFile = "data/wallet.dat"
+
+var ErrWalletNotFound = errors.New("ERROR: No wallet found. Create one first.")
+var ErrInvalidWalletFile = errors.New("ERROR: Wallet file is corrupted")
+var ErrUnknownAddress = errors.New("ERROR: Address is not in the wallet")
+
+type Wallets struct {
+    Wallets   map[string]*Wallet
+    WatchOnly map[string][]byte // 只读地址，没有私钥，值为公钥（可能未知）
+}
+
+// 加载钱包文件，文件不存在时返回空的钱包和 ErrWalletNotFound
+func NewWallets() (*Wallets, error) {
+    wallets := Wallets{}
+    wallets.Wallets = make(map[string]*Wallet)
+    wallets.WatchOnly = make(map[string][]byte)
+
+    err := wallets.LoadFromFile()
+
+    return &wallets, err
+}
+
+//
+func (wallets *Wallets) CreateWallet() (string, error) {
+    wallet, err := NewWallet()
+    if err != nil { return "", err }
+
+    // wallet.GetAddress return []byte
+    address := fmt.Sprintf("%s", wallet.GetAddress())
+    wallets.Wallets[address] = wallet
+
+    return address, nil
+}
+
+// 导入一个钱包，返回其地址
+// 该地址之前作为只读地址导入时，取消只读，之后由私钥管理
+func (wallets *Wallets) ImportWallet(wallet *Wallet) string {
+    address := fmt.Sprintf("%s", wallet.GetAddress())
+    wallets.Wallets[address] = wallet
+    delete(wallets.WatchOnly, address)
+
+    return address
+}
+
+//
+func (wallets *Wallets) GetAddresses() []string {
+    var addresses []string
+
+    for address := range wallets.Wallets {
+        addresses = append(addresses, address)
+    }
+    sort.Strings(addresses)
+
+    return addresses
+}
+
+// 添加一个只读地址，pubKey 可以为 nil
+func (wallets *Wallets) AddWatchOnly(address string, pubKey []byte) {
+    wallets.WatchOnly[address] = pubKey
+}
+
+// 全部只读地址
+func (wallets *Wallets) GetWatchOnlyAddresses() []string {
+    var addresses []string
+
+    for address := range wallets.WatchOnly {
+        addresses = append(addresses, address)
+    }
+    sort.Strings(addresses)
+
+    return addresses
+}
+
+// 是否为只读地址
+func (wallets *Wallets) IsWatchOnly(address string) bool {
+    _, ok := wallets.WatchOnly[address]
+    return ok
+}
+
+// 钱包全部地址（包括只读地址）的 pubKeyHash，以 hex 编码索引
+func (wallets *Wallets) PubKeyHashes() map[string]bool {
+    keys := make(map[string]bool)
+
+    for _, wallet := range wallets.Wallets {
+        keys[hex.EncodeToString(crypto.HashPubKey(wallet.PublicKey))] = true
+    }
+    for address := range wallets.WatchOnly {
+        keys[hex.EncodeToString(crypto.AddressToPubKeyHash(address))] = true
+    }
+
+    return keys
+}
+
+// 根据 pubKeyHash 找到钱包，没有时返回 nil
+func (wallets *Wallets) FindWalletByPubKeyHash(pubKeyHash []byte) *Wallet {
+    for _, wallet := range wallets.Wallets {
+        if bytes.Compare(crypto.HashPubKey(wallet.PublicKey), pubKeyHash) == 0 { return wallet }
+    }
+    return nil
+}
+
+// pubKeyHash 对应的公钥，包括只读地址，没有时返回 nil
+func (wallets *Wallets) PubKey(pubKeyHash []byte) []byte {
+    if wallet := wallets.FindWalletByPubKeyHash(pubKeyHash); wallet != nil { return wallet.PublicKey }
+    return wallets.WatchOnly[crypto.PubKeyHashToAddress(pubKeyHash)]
+}
+
+//
+func (wallets *Wallets) GetWallet(address string) Wallet {
+    return *wallets.Wallets[address]
+}
+
+// 从文件中加载wallet
+func (wallets *Wallets) LoadFromFile() error {
+    if _, err := os.Stat(walletFile); os.IsNotExist(err) {
+        return ErrWalletNotFound
+    }
+
+    fileContent, err := ioutil.ReadFile(walletFile)
+    if err != nil { return err }
+
+    var wallets_loaded Wallets
+
+    // gob.NewDecoder f func(r io.Reader) *gob.NewDecoder 
+    decoder := gob.NewDecoder(bytes.NewReader(fileContent))
+
+    // decoder.Decode      f func(e interface{}) error
+    err = decoder.Decode(&wallets_loaded)
+    if err != nil { return fmt.Errorf("%w: %v", ErrInvalidWalletFile, err) }
+
+    if wallets_loaded.Wallets != nil { wallets.Wallets = wallets_loaded.Wallets }
+    if wallets_loaded.WatchOnly != nil { wallets.WatchOnly = wallets_loaded.WatchOnly }
+
+    return nil
+}
+
+func (wallets Wallets) SaveToFile() error {
+    var content bytes.Buffer
+
+    // gob.NewEncoder  f func(w io.Writer) *gob.Encoder
+    encoder := gob.NewEncoder(&content)
+
+    // encoder.Encode  f func(e interface{}) error
+    err := encoder.Encode(wallets)
+    if err != nil { return err }
+
+    // ioutil.WriteFile  f func(filename string, data []byte, perm os.FileMode) error
+    return ioutil.WriteFile(walletFile, content.Bytes(), 0644)
+}
@@ -0,0 +1,19 @@
+package wallet
+
+import (
+    "testing"
+)
+
+// 导入只读地址的私钥后，该地址不再是只读地址
+func TestImportWalletReplacesWatchOnly(t *testing.T) {
+    w, err := NewWallet()
+    if err != nil { t.Fatal(err) }
+    address := string(w.GetAddress())
+
+    wallets := Wallets{make(map[string]*Wallet), make(map[string][]byte)}
+    wallets.AddWatchOnly(address, nil)
+
+    if got := wallets.ImportWallet(w); got != address { t.Fatalf("imported address %s, want %s", got, address) }
+    if wallets.IsWatchOnly(address) { t.Errorf("%s is still watch-only", address) }
+    if len(wallets.GetWatchOnlyAddresses()) != 0 { t.Errorf("watch-only addresses %v, want none", wallets.GetWatchOnlyAddresses()) }
+}